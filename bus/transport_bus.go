@@ -9,11 +9,15 @@ import (
 type TransportBus struct {
 	SpanBus chan []db.Span
 	LogBus  chan []db.Log
+	// StatusBus has messages to show in the header, e.g. when a command run
+	// by otelly exits
+	StatusBus chan string
 }
 
 func NewTransportBus() *TransportBus {
 	return &TransportBus{
-		SpanBus: make(chan []db.Span),
-		LogBus:  make(chan []db.Log),
+		SpanBus:   make(chan []db.Span),
+		LogBus:    make(chan []db.Log),
+		StatusBus: make(chan string),
 	}
 }
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/fredrikaugust/otelly/bus"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/expect"
+	"github.com/fredrikaugust/otelly/telemetry"
+)
+
+const (
+	// checkInterval is how often the received spans are checked
+	checkInterval = 250 * time.Millisecond
+	// exitGrace is how long spans are waited for after the command exits,
+	// as SDKs export them in batches
+	exitGrace = 2 * time.Second
+)
+
+// expressions are the values of a flag given several times.
+type expressions []string
+
+func (e *expressions) String() string {
+	return strings.Join(*e, ", ")
+}
+
+func (e *expressions) Set(value string) error {
+	*e = append(*e, value)
+	return nil
+}
+
+// assertOptions are the arguments of otelly assert.
+type assertOptions struct {
+	expected []expect.Span
+	timeout  time.Duration
+	command  []string
+}
+
+// parseAssertArgs reads the arguments after otelly assert.
+func parseAssertArgs(args []string) (assertOptions, error) {
+	var options assertOptions
+	var exprs expressions
+	var file string
+
+	flags := flag.NewFlagSet("otelly assert", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Var(&exprs, "e", "")
+	flags.StringVar(&file, "f", "", "")
+	flags.DurationVar(&options.timeout, "timeout", 30*time.Second, "")
+	if err := flags.Parse(args); err != nil {
+		return options, err
+	}
+	options.command = flags.Args()
+
+	for _, expr := range exprs {
+		s, err := expect.Parse(expr)
+		if err != nil {
+			return options, err
+		}
+		options.expected = append(options.expected, s)
+	}
+
+	if file != "" {
+		spans, err := expect.Load(file)
+		if err != nil {
+			return options, err
+		}
+		options.expected = append(options.expected, spans...)
+	}
+
+	if len(options.expected) == 0 {
+		return options, errors.New("no spans to expect, give them with -e or -f")
+	}
+
+	return options, nil
+}
+
+// runAssert receives telemetry without the UI until the expected spans
+// are found, the command exits, or it times out, and prints a report. It
+// returns the exit code.
+func runAssert(options assertOptions, telemetryOptions telemetry.Options) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Not ./local.db, so spans from earlier runs can't pass the check
+	database, err := db.NewDB(":memory:")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not create database:", err)
+		return 1
+	}
+	defer database.Close()
+	if err := database.Migrate(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "could not create database:", err)
+		return 1
+	}
+
+	// Nothing shows what's received, but the receivers wait on the bus
+	bus := bus.NewTransportBus()
+	go drain(ctx, bus)
+
+	received, cancelReceiver := context.WithCancel(ctx)
+	defer cancelReceiver()
+	go func() {
+		if err := telemetry.Start(received, bus, database, telemetryOptions); err != nil {
+			slog.Error("failed to start receiver", "error", err)
+			fmt.Fprintln(os.Stderr, "could not start receiver:", err)
+			stop()
+		}
+	}()
+	if err := telemetry.WaitForReceiver(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	waiting, cancelWaiting := context.WithTimeout(ctx, options.timeout)
+	defer cancelWaiting()
+
+	var exited error
+	commandDone := make(chan struct{})
+	if len(options.command) > 0 {
+		running, cancelCommand := context.WithCancel(ctx)
+		defer func() {
+			// Interrupts the command, e.g. a server, if it's still running,
+			// and skips waiting out the grace period once it exits, as the
+			// report is final by now
+			cancelCommand()
+			cancelWaiting()
+			<-commandDone
+		}()
+
+		cmd, err := telemetry.StartCommand(running, options.command, bus, database)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not run %s: %v\n", options.command[0], err)
+			return 1
+		}
+
+		go func() {
+			defer close(commandDone)
+
+			exited = cmd.Wait()
+			select {
+			case <-time.After(exitGrace):
+				cancelWaiting()
+			case <-waiting.Done():
+			}
+		}()
+	} else {
+		close(commandDone)
+	}
+
+	start := time.Now()
+	report, err := expect.Wait(waiting, database, options.expected, checkInterval)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Print(report)
+	if report.OK() {
+		fmt.Printf("found all spans after %s\n", time.Since(start).Round(time.Millisecond))
+		return 0
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		fmt.Println("interrupted")
+	case errors.Is(waiting.Err(), context.DeadlineExceeded):
+		fmt.Printf("timed out after %s\n", options.timeout)
+	default:
+		<-commandDone
+		if exited != nil {
+			fmt.Printf("%s exited before all spans were found: %v\n", options.command[0], exited)
+		} else {
+			fmt.Printf("%s finished before all spans were found\n", options.command[0])
+		}
+	}
+
+	return 1
+}
+
+// drain empties the bus, which the UI would otherwise do.
+func drain(ctx context.Context, bus *bus.TransportBus) {
+	for {
+		select {
+		case <-bus.SpanBus:
+		case <-bus.LogBus:
+		case <-bus.StatusBus:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fredrikaugust/otelly/telemetry"
+)
+
+// forwardFlag adds an endpoint to forward to.
+type forwardFlag struct {
+	forwards *[]telemetry.Forward
+}
+
+func (f forwardFlag) String() string {
+	return ""
+}
+
+func (f forwardFlag) Set(value string) error {
+	forward, err := telemetry.ParseForward(value)
+	if err != nil {
+		return err
+	}
+	*f.forwards = append(*f.forwards, forward)
+
+	return nil
+}
+
+// scrapeFlag adds a Prometheus endpoint to scrape.
+type scrapeFlag struct {
+	targets *[]string
+}
+
+func (f scrapeFlag) String() string {
+	return ""
+}
+
+func (f scrapeFlag) Set(value string) error {
+	target, err := telemetry.ParseScrapeTarget(value)
+	if err != nil {
+		return err
+	}
+	*f.targets = append(*f.targets, target)
+
+	return nil
+}
+
+// logFileFlag adds a glob of log files to tail.
+type logFileFlag struct {
+	globs *[]string
+}
+
+func (f logFileFlag) String() string {
+	return ""
+}
+
+func (f logFileFlag) Set(value string) error {
+	if _, err := filepath.Match(value, ""); err != nil {
+		return fmt.Errorf("bad glob %q: %w", value, err)
+	}
+	*f.globs = append(*f.globs, value)
+
+	return nil
+}
+
+// forwardSettingFlag changes the endpoint given by the -forward before it,
+// so each endpoint can have its own headers and TLS settings.
+type forwardSettingFlag struct {
+	forwards *[]telemetry.Forward
+	set      func(f *telemetry.Forward, value string) error
+	isBool   bool
+}
+
+func (f forwardSettingFlag) String() string {
+	return ""
+}
+
+func (f forwardSettingFlag) Set(value string) error {
+	if len(*f.forwards) == 0 {
+		return errors.New("give -forward before its settings")
+	}
+
+	return f.set(&(*f.forwards)[len(*f.forwards)-1], value)
+}
+
+func (f forwardSettingFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// parseFlags reads the flags before the command, e.g. otelly -forward
+// grpc://collector:4317 run -- go test, and returns the arguments after
+// them.
+func parseFlags(args []string) (telemetry.Options, []string, error) {
+	var options telemetry.Options
+
+	flags := flag.NewFlagSet("otelly", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	flags.BoolVar(&options.Zipkin, "zipkin", false, "")
+	flags.BoolVar(&options.Jaeger, "jaeger", false, "")
+	flags.Var(scrapeFlag{&options.Scrape}, "scrape", "")
+	flags.DurationVar(&options.ScrapeInterval, "scrape-interval", telemetry.DefaultScrapeInterval, "")
+	flags.BoolVar(&options.RemoteWrite, "remote-write", false, "")
+	flags.Var(logFileFlag{&options.LogFiles}, "log-file", "")
+	flags.BoolVar(&options.Syslog, "syslog", false, "")
+	flags.Func("log-format", "", func(value string) error {
+		options.LogFormat = value
+		return telemetry.ValidateLogFormat(value)
+	})
+	flags.Var(forwardFlag{&options.Forward}, "forward", "")
+	setting := func(name string, isBool bool, set func(f *telemetry.Forward, value string) error) {
+		flags.Var(forwardSettingFlag{&options.Forward, set, isBool}, name, "")
+	}
+	setting("forward-header", false, func(f *telemetry.Forward, value string) error {
+		name, v, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("header %q isn't like name=value", value)
+		}
+		if f.Headers == nil {
+			f.Headers = make(map[string]string)
+		}
+		f.Headers[name] = v
+		return nil
+	})
+	setting("forward-ca", false, func(f *telemetry.Forward, value string) error {
+		f.CAFile = value
+		return nil
+	})
+	setting("forward-cert", false, func(f *telemetry.Forward, value string) error {
+		f.CertFile = value
+		return nil
+	})
+	setting("forward-key", false, func(f *telemetry.Forward, value string) error {
+		f.KeyFile = value
+		return nil
+	})
+	setting("forward-insecure-skip-verify", true, func(f *telemetry.Forward, value string) error {
+		skip, err := strconv.ParseBool(value)
+		f.InsecureSkipVerify = skip
+		return err
+	})
+
+	if err := flags.Parse(args); err != nil {
+		return options, nil, err
+	}
+
+	return options, flags.Args(), nil
+}
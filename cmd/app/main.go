@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,11 +19,75 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+const usage = `usage:
+  otelly                     show telemetry sent to localhost:4317 and :4318
+  otelly run -- <command>    run the command with its telemetry and output sent to otelly
+  otelly assert [-e <expression>]... [-f <file>] [-timeout 30s] [-- <command>]
+                             wait for the expected spans without the UI, and exit 1 if
+                             they're not received, e.g.
+                             otelly assert -e 'name="POST /orders" status=ok > db.query' -- go test ./...
+
+flags, given before the command:
+  -zipkin                    also receive Zipkin spans on :9411
+  -jaeger                    also receive Jaeger spans on :14268 (Thrift over HTTP)
+                             and :14250 (gRPC)
+  -scrape <target>           scrape the metrics of a Prometheus endpoint, e.g.
+                             localhost:9090/metrics, every -scrape-interval (10s)
+  -remote-write              also receive Prometheus remote write 2.0 on
+                             :19291/api/v1/write
+  -log-file <glob>           tail log files, e.g. /var/log/app/*.log, from where they
+                             end now
+  -syslog                    also receive RFC 5424 syslog on :5514 (UDP and TCP)
+  -log-format <format>       parse lines of -log-file and -syslog as json, logfmt or
+                             a regular expression with named groups like (?P<msg>.*),
+                             rather than telling json and logfmt apart by line
+  -forward <url>             also send everything received to the OTLP endpoint, e.g.
+                             grpc://collector:4317 or https://otlp.example.com
+  -forward-header <name=value>, -forward-ca <file>, -forward-cert <file>,
+  -forward-key <file>, -forward-insecure-skip-verify
+                             set up the -forward given before them`
+
 func main() {
+	options, args, err := parseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if len(args) > 0 && args[0] == "assert" {
+		assertOptions, err := parseAssertArgs(args[1:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+
+		cleanup := configureLogging()
+		code := runAssert(assertOptions, options)
+		cleanup()
+		os.Exit(code)
+	}
+
+	command, err := parseArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// A mistake in the config is told about before the UI takes over the
+	// terminal
+	if err := ui.LoadConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't load config:", err)
+		os.Exit(1)
+	}
+
 	cleanup := configureLogging()
 	defer cleanup()
 
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	bus := bus.NewTransportBus()
 
@@ -32,12 +99,26 @@ func main() {
 	defer db.Close()
 
 	go func() {
-		if err := telemetry.Start(ctx, bus, db); err != nil {
+		if err := telemetry.Start(ctx, bus, db, options); err != nil {
 			slog.Error("failed to start receiver", "error", err)
 			cancel()
 		}
 	}()
 
+	if len(options.Forward) > 0 {
+		go func() {
+			endpoints := make([]string, 0, len(options.Forward))
+			for _, f := range options.Forward {
+				endpoints = append(endpoints, f.Endpoint)
+			}
+
+			select {
+			case bus.StatusBus <- "Forwarding to " + strings.Join(endpoints, ", "):
+			case <-ctx.Done():
+			}
+		}()
+	}
+
 	logs, err := db.GetLogs(ctx)
 	if err != nil {
 		zap.L().Error("couldn't get logs", zap.Error(err))
@@ -49,17 +130,82 @@ func main() {
 		return
 	}
 
-	p := tea.NewProgram(ui.NewEntryModel(spans, logs, bus, db), tea.WithAltScreen(), tea.WithContext(ctx))
+	commandDone := make(chan struct{})
+	if command != nil {
+		go func() {
+			defer close(commandDone)
+			runCommand(ctx, command, bus, db)
+		}()
+	} else {
+		close(commandDone)
+	}
+
+	p := tea.NewProgram(ui.NewEntryModel(spans, logs, bus, db), tea.WithAltScreen(), tea.WithMouseCellMotion(), tea.WithContext(ctx))
 	if _, err := p.Run(); err != nil {
 		slog.Error("failed to start ui", "error", err)
 	}
 
 	cancel()
 
+	// Gives the command a chance to exit and its output to be stored
+	<-commandDone
 	<-ctx.Done()
 	zap.L().Info("application quit successfully")
 }
 
+// parseArgs returns the command to run, if any.
+func parseArgs(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	if args[0] != "run" {
+		return nil, fmt.Errorf("unknown command %q", args[0])
+	}
+
+	command := args[1:]
+	if len(command) > 0 && command[0] == "--" {
+		command = command[1:]
+	}
+	if len(command) == 0 {
+		return nil, errors.New("no command to run")
+	}
+
+	return command, nil
+}
+
+// runCommand runs the command with its telemetry sent to otelly, and
+// tells the UI when it starts and exits.
+func runCommand(ctx context.Context, command []string, bus *bus.TransportBus, db *db.Database) {
+	status := func(text string) {
+		select {
+		case bus.StatusBus <- text:
+		case <-ctx.Done():
+		}
+	}
+
+	if err := telemetry.WaitForReceiver(ctx); err != nil {
+		slog.Error("receiver didn't start", "error", err)
+		status(fmt.Sprintf("Could not run %s: %v", command[0], err))
+		return
+	}
+
+	cmd, err := telemetry.StartCommand(ctx, command, bus, db)
+	if err != nil {
+		slog.Error("couldn't start command", "error", err)
+		status(fmt.Sprintf("Could not run %s: %v", command[0], err))
+		return
+	}
+	status(fmt.Sprintf("Running %s as %s", strings.Join(command, " "), cmd.ServiceName()))
+
+	if err := cmd.Wait(); err != nil {
+		slog.Info("command exited", "error", err)
+		status(fmt.Sprintf("%s exited: %v", command[0], err))
+		return
+	}
+	status(command[0] + " finished")
+}
+
 func configureLogging() func() error {
 	logFile, err := os.OpenFile("debug.log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
 	if err != nil {
@@ -52,11 +52,12 @@ func (d *Database) Close() error {
 func (d *Database) Migrate(ctx context.Context) error {
 	migrations := []string{
 		`CREATE TABLE IF NOT EXISTS resource (id VARCHAR PRIMARY KEY, service_name VARCHAR, service_namespace VARCHAR)`,
+		`ALTER TABLE resource ADD COLUMN IF NOT EXISTS instance VARCHAR DEFAULT ''`,
 		`CREATE TABLE IF NOT EXISTS span (
 			id VARCHAR PRIMARY KEY,
 			name VARCHAR,
 			start_time TIMESTAMP,
-			duration_ns INTEGER,
+			duration_ns BIGINT,
 			trace_id VARCHAR,
 			kind VARCHAR,
 			parent_span_id VARCHAR,
@@ -68,6 +69,7 @@ func (d *Database) Migrate(ctx context.Context) error {
 		)`,
 		`CREATE INDEX IF NOT EXISTS t_id_idx ON span (trace_id)`,
 		`CREATE INDEX IF NOT EXISTS p_id_idx ON span (parent_span_id)`,
+		`ALTER TABLE span ADD COLUMN IF NOT EXISTS events JSON DEFAULT '[]'`,
 		`CREATE TABLE IF NOT EXISTS log (
 			span_id VARCHAR,
 			body VARCHAR,
@@ -78,6 +80,29 @@ func (d *Database) Migrate(ctx context.Context) error {
 			attributes JSON,
 			FOREIGN KEY (resource_id) REFERENCES resource (id)
 		)`,
+		`ALTER TABLE log ADD COLUMN IF NOT EXISTS trace_id VARCHAR`,
+		`ALTER TABLE log ADD COLUMN IF NOT EXISTS flags UINTEGER DEFAULT 0`,
+		`ALTER TABLE log ADD COLUMN IF NOT EXISTS body_type VARCHAR DEFAULT 'Str'`,
+		`ALTER TABLE log ADD COLUMN IF NOT EXISTS body_json JSON`,
+		`ALTER TABLE log ADD COLUMN IF NOT EXISTS observed_timestamp TIMESTAMP`,
+		`ALTER TABLE log ADD COLUMN IF NOT EXISTS event_name VARCHAR DEFAULT ''`,
+		`CREATE INDEX IF NOT EXISTS log_t_id_idx ON log (trace_id)`,
+		`CREATE INDEX IF NOT EXISTS log_s_id_idx ON log (span_id)`,
+		`CREATE TABLE IF NOT EXISTS metric (
+			name VARCHAR,
+			description VARCHAR,
+			unit VARCHAR,
+			type VARCHAR,
+			timestamp TIMESTAMP,
+			start_timestamp TIMESTAMP,
+			value DOUBLE,
+			count BIGINT,
+			sum DOUBLE,
+			attributes JSON,
+			resource_id VARCHAR,
+			FOREIGN KEY (resource_id) REFERENCES resource (id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS metric_name_idx ON metric (name)`,
 	}
 
 	for _, migration := range migrations {
@@ -87,11 +112,51 @@ func (d *Database) Migrate(ctx context.Context) error {
 			return err
 		}
 	}
+
+	if err := d.widenSpanDuration(ctx); err != nil {
+		slog.Error("failed to widen span duration column")
+		return err
+	}
+
 	slog.Info("finished migrating DB", "numMigrations", len(migrations))
 
 	return nil
 }
 
+// widenSpanDuration changes the type of span.duration_ns from INTEGER to
+// BIGINT in databases created before it was changed, as spans longer than
+// ~2.1s didn't fit and were dropped. DuckDB won't alter a column with indexes
+// on the table, so they're dropped and recreated around it.
+func (d *Database) widenSpanDuration(ctx context.Context) error {
+	var dataType string
+	err := d.sqlDB.GetContext(
+		ctx,
+		&dataType,
+		`SELECT data_type FROM information_schema.columns WHERE table_name = 'span' AND column_name = 'duration_ns'`,
+	)
+	if err != nil {
+		return err
+	}
+
+	if dataType == "BIGINT" {
+		return nil
+	}
+
+	for _, statement := range []string{
+		`DROP INDEX IF EXISTS t_id_idx`,
+		`DROP INDEX IF EXISTS p_id_idx`,
+		`ALTER TABLE span ALTER duration_ns TYPE BIGINT`,
+		`CREATE INDEX IF NOT EXISTS t_id_idx ON span (trace_id)`,
+		`CREATE INDEX IF NOT EXISTS p_id_idx ON span (parent_span_id)`,
+	} {
+		if _, err := d.sqlDB.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (d *Database) BeginTx(ctx context.Context) (*sql.Tx, error) {
 	return d.sqlDB.BeginTx(ctx, &sql.TxOptions{})
 }
@@ -2,9 +2,12 @@ package db_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/fredrikaugust/otelly/db"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
 func TestDB(t *testing.T) {
@@ -31,3 +34,42 @@ func getDB(t *testing.T) (*db.Database, error) {
 	}
 	return db, nil
 }
+
+type testSpan struct {
+	traceID   byte
+	spanID    byte
+	parentID  byte
+	name      string
+	start     time.Time
+	duration  time.Duration
+	kind      ptrace.SpanKind
+	errorCode bool
+}
+
+// insertSpans inserts the spans under a resource with the given service name.
+func insertSpans(t *testing.T, database *db.Database, service string, spans ...testSpan) {
+	t.Helper()
+
+	rs := ptrace.NewResourceSpans()
+	rs.Resource().Attributes().PutStr("service.name", service)
+	slice := rs.ScopeSpans().AppendEmpty().Spans()
+	for _, s := range spans {
+		span := slice.AppendEmpty()
+		span.SetTraceID(pcommon.TraceID{s.traceID})
+		span.SetSpanID(pcommon.SpanID{s.spanID})
+		if s.parentID != 0 {
+			span.SetParentSpanID(pcommon.SpanID{s.parentID})
+		}
+		span.SetName(s.name)
+		span.SetKind(s.kind)
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(s.start))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(s.start.Add(s.duration)))
+		if s.errorCode {
+			span.Status().SetCode(ptrace.StatusCodeError)
+		}
+	}
+
+	if err := database.InsertResourceSpans(t.Context(), rs); err != nil {
+		t.Fatalf("could not insert spans: %v", err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.uber.org/zap"
@@ -30,21 +32,55 @@ func (d *Database) InsertResourceLogs(ctx context.Context, logs plog.ResourceLog
 				attrs = []byte("{}")
 			}
 
-			zap.L().Debug("inserting new log record", zap.String("body", logRecord.Body().Str()))
+			body, err := json.Marshal(logRecord.Body().AsRaw())
+			if err != nil {
+				zap.L().Warn("could not serialize log body to JSON", zap.Error(err))
+				body = []byte("null")
+			}
+
+			// The timestamp is optional, in which case the time the log was
+			// observed by the collector is the best we have.
+			timestamp := logRecord.Timestamp()
+			if timestamp == 0 {
+				timestamp = logRecord.ObservedTimestamp()
+			}
+
+			zap.L().Debug("inserting new log record", zap.String("body", logRecord.Body().AsString()))
 
 			_, err = tx.ExecContext(
 				ctx,
-				`INSERT INTO log VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				`
+				INSERT INTO log (
+					span_id,
+					body,
+					timestamp,
+					severity_number,
+					severity_text,
+					resource_id,
+					attributes,
+					trace_id,
+					flags,
+					body_type,
+					body_json,
+					observed_timestamp,
+					event_name
+				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 				sql.NullString{String: logRecord.SpanID().String(), Valid: !logRecord.SpanID().IsEmpty()},
-				logRecord.Body().Str(),
-				logRecord.Timestamp().AsTime(),
+				logRecord.Body().AsString(),
+				timestamp.AsTime(),
 				logRecord.SeverityNumber(),
 				logRecord.SeverityText(),
 				resID,
 				attrs,
+				sql.NullString{String: logRecord.TraceID().String(), Valid: !logRecord.TraceID().IsEmpty()},
+				uint32(logRecord.Flags()),
+				logRecord.Body().Type().String(),
+				body,
+				sql.NullTime{Time: logRecord.ObservedTimestamp().AsTime(), Valid: logRecord.ObservedTimestamp() != 0},
+				logRecord.EventName(),
 			)
 			if err != nil {
-				zap.L().Warn("failed to create log", zap.String("body", logRecord.Body().Str()))
+				zap.L().Warn("failed to create log", zap.String("body", logRecord.Body().AsString()), zap.Error(err))
 			}
 		}
 	}
@@ -73,11 +109,159 @@ func (d *Database) GetLogs(ctx context.Context) ([]Log, error) {
 		&logs,
 		`
 		SELECT
-			*
+			log.*,
+			COALESCE(resource.service_name, '') AS service_name
+		FROM
+			log
+			LEFT JOIN resource ON log.resource_id = resource.id
+		ORDER BY
+			log.timestamp DESC`,
+	)
+	if err != nil {
+		return logs, err
+	}
+
+	return logs, nil
+}
+
+// GetLogsForTrace returns the logs emitted within any span of the trace,
+// oldest first.
+func (d *Database) GetLogsForTrace(ctx context.Context, traceID string) ([]Log, error) {
+	logs := make([]Log, 0)
+	err := d.sqlDB.SelectContext(
+		ctx,
+		&logs,
+		`
+		SELECT
+			log.*,
+			COALESCE(resource.service_name, '') AS service_name
+		FROM
+			log
+			LEFT JOIN resource ON log.resource_id = resource.id
+		WHERE
+			log.trace_id = ?
+		ORDER BY
+			log.timestamp`,
+		traceID,
+	)
+	if err != nil {
+		return logs, err
+	}
+
+	return logs, nil
+}
+
+// GetLogsForSpan returns the logs emitted within the span, oldest first.
+func (d *Database) GetLogsForSpan(ctx context.Context, spanID string) ([]Log, error) {
+	logs := make([]Log, 0)
+	err := d.sqlDB.SelectContext(
+		ctx,
+		&logs,
+		`
+		SELECT
+			log.*,
+			COALESCE(resource.service_name, '') AS service_name
+		FROM
+			log
+			LEFT JOIN resource ON log.resource_id = resource.id
+		WHERE
+			log.span_id = ?
+		ORDER BY
+			log.timestamp`,
+		spanID,
+	)
+	if err != nil {
+		return logs, err
+	}
+
+	return logs, nil
+}
+
+// LogFilter narrows down the logs returned by FilterLogs. The zero value
+// matches every log.
+type LogFilter struct {
+	// Text matches logs containing it anywhere in the body, ignoring case.
+	// For structured bodies this includes both keys and values.
+	Text string
+	// Fields match logs where the value at a dot separated path within the
+	// body or the attributes is equal to the given value, e.g.
+	// {"user.id", "42"} matches the body {"user": {"id": 42}}.
+	Fields []LogFieldFilter
+	// MinSeverity matches logs with at least this severity number
+	MinSeverity int
+	// ServiceName matches logs from the service if set
+	ServiceName string
+	// From and To match logs from (inclusive) and to (exclusive) the times
+	// if set.
+	From time.Time
+	To   time.Time
+}
+
+type LogFieldFilter struct {
+	Path  string
+	Value string
+}
+
+// where returns the conditions of the filter and their arguments.
+func (f LogFilter) where() (string, []any) {
+	conditions := []string{"TRUE"}
+	args := make([]any, 0)
+
+	if f.Text != "" {
+		conditions = append(conditions, "log.body ILIKE ?")
+		args = append(args, "%"+f.Text+"%")
+	}
+
+	for _, field := range f.Fields {
+		path := jsonPath(field.Path)
+		conditions = append(conditions, "(json_extract_string(log.body_json, ?) = ? OR json_extract_string(log.attributes, ?) = ?)")
+		args = append(args, path, field.Value, path, field.Value)
+	}
+
+	if f.MinSeverity > 0 {
+		conditions = append(conditions, "log.severity_number >= ?")
+		args = append(args, f.MinSeverity)
+	}
+
+	if f.ServiceName != "" {
+		conditions = append(conditions, "resource.service_name = ?")
+		args = append(args, f.ServiceName)
+	}
+
+	if !f.From.IsZero() {
+		conditions = append(conditions, "log.timestamp >= ?")
+		args = append(args, f.From)
+	}
+
+	if !f.To.IsZero() {
+		conditions = append(conditions, "log.timestamp < ?")
+		args = append(args, f.To)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// FilterLogs returns up to limit logs matching the filter, newest first.
+func (d *Database) FilterLogs(ctx context.Context, filter LogFilter, limit int) ([]Log, error) {
+	where, args := filter.where()
+
+	logs := make([]Log, 0)
+	err := d.sqlDB.SelectContext(
+		ctx,
+		&logs,
+		fmt.Sprintf(`
+		SELECT
+			log.*,
+			COALESCE(resource.service_name, '') AS service_name
 		FROM
 			log
+			LEFT JOIN resource ON log.resource_id = resource.id
+		WHERE
+			%s
 		ORDER BY
-			timestamp DESC`,
+			log.timestamp DESC
+		LIMIT ?`, where),
+		append(args, limit)...,
 	)
 	if err != nil {
 		return logs, err
@@ -85,3 +269,113 @@ func (d *Database) GetLogs(ctx context.Context) ([]Log, error) {
 
 	return logs, nil
 }
+
+// LogCount is the number of logs with a severity number within a bucket of
+// time.
+type LogCount struct {
+	Bucket         int `db:"bucket"`
+	SeverityNumber int `db:"severity_number"`
+	Count          int `db:"count"`
+}
+
+// GetLogTimeRange returns the timestamps of the oldest and newest logs
+// matching the filter, or zero times if there are none.
+func (d *Database) GetLogTimeRange(ctx context.Context, filter LogFilter) (time.Time, time.Time, error) {
+	where, args := filter.where()
+
+	var bounds struct {
+		First sql.NullTime `db:"first"`
+		Last  sql.NullTime `db:"last"`
+	}
+	err := d.sqlDB.GetContext(
+		ctx,
+		&bounds,
+		fmt.Sprintf(`
+		SELECT
+			MIN(log.timestamp) AS first,
+			MAX(log.timestamp) AS last
+		FROM
+			log
+			LEFT JOIN resource ON log.resource_id = resource.id
+		WHERE
+			%s`, where),
+		args...,
+	)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return bounds.First.Time, bounds.Last.Time, nil
+}
+
+// GetLogCounts returns the number of logs matching the filter per severity
+// number in each bucket of bucketSize since the given time. Buckets without
+// logs are left out.
+func (d *Database) GetLogCounts(ctx context.Context, filter LogFilter, since time.Time, bucketSize time.Duration) ([]LogCount, error) {
+	where, args := filter.where()
+
+	counts := make([]LogCount, 0)
+	err := d.sqlDB.SelectContext(
+		ctx,
+		&counts,
+		fmt.Sprintf(`
+		SELECT
+			CAST(FLOOR((epoch_us(log.timestamp) - ?) / ?) AS BIGINT) AS bucket,
+			log.severity_number AS severity_number,
+			COUNT(*) AS count
+		FROM
+			log
+			LEFT JOIN resource ON log.resource_id = resource.id
+		WHERE
+			log.timestamp >= ?
+			AND %s
+		GROUP BY
+			bucket,
+			log.severity_number
+		ORDER BY
+			bucket,
+			log.severity_number`, where),
+		append([]any{since.UnixMicro(), max(bucketSize.Microseconds(), 1), since}, args...)...,
+	)
+	if err != nil {
+		return counts, err
+	}
+
+	return counts, nil
+}
+
+// GetLogServiceNames returns the names of the services which have sent
+// logs, sorted.
+func (d *Database) GetLogServiceNames(ctx context.Context) ([]string, error) {
+	names := make([]string, 0)
+	err := d.sqlDB.SelectContext(
+		ctx,
+		&names,
+		`
+		SELECT DISTINCT
+			resource.service_name
+		FROM
+			log
+			JOIN resource ON log.resource_id = resource.id
+		WHERE
+			resource.service_name IS NOT NULL
+		ORDER BY
+			resource.service_name`,
+	)
+	if err != nil {
+		return names, err
+	}
+
+	return names, nil
+}
+
+// jsonPath turns a dot separated path into a JSON path with every key
+// quoted, so keys like http-status don't need escaping by the user.
+func jsonPath(path string) string {
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		keys[i] = `"` + strings.ReplaceAll(key, `"`, `\"`) + `"`
+	}
+
+	return "$." + strings.Join(keys, ".")
+}
@@ -0,0 +1,290 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+type testLog struct {
+	traceID   byte
+	spanID    byte
+	body      string
+	timestamp time.Time
+	severity  plog.SeverityNumber
+}
+
+// insertLogs inserts the logs under a resource with the given service name.
+func insertLogs(t *testing.T, database *db.Database, service string, logs ...testLog) {
+	t.Helper()
+
+	rl := plog.NewResourceLogs()
+	rl.Resource().Attributes().PutStr("service.name", service)
+	slice := rl.ScopeLogs().AppendEmpty().LogRecords()
+	for _, l := range logs {
+		record := slice.AppendEmpty()
+		if l.traceID != 0 {
+			record.SetTraceID(pcommon.TraceID{l.traceID})
+			record.SetFlags(plog.DefaultLogRecordFlags.WithIsSampled(true))
+		}
+		if l.spanID != 0 {
+			record.SetSpanID(pcommon.SpanID{l.spanID})
+		}
+		record.Body().SetStr(l.body)
+		record.SetTimestamp(pcommon.NewTimestampFromTime(l.timestamp))
+		record.SetSeverityNumber(l.severity)
+	}
+
+	if err := database.InsertResourceLogs(t.Context(), rl); err != nil {
+		t.Fatalf("could not insert logs: %v", err)
+	}
+}
+
+func TestLogsForTrace(t *testing.T) {
+	database, err := getDB(t)
+	assert.Nil(t, err)
+	defer database.Close()
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	insertLogs(t, database, "api",
+		testLog{traceID: 1, spanID: 2, body: "second", timestamp: start.Add(time.Second)},
+		testLog{traceID: 1, spanID: 1, body: "first", timestamp: start},
+		testLog{traceID: 2, spanID: 3, body: "other trace", timestamp: start},
+		testLog{body: "no trace", timestamp: start},
+	)
+
+	t.Run("gets logs for trace, oldest first", func(t *testing.T) {
+		logs, err := database.GetLogsForTrace(t.Context(), pcommon.TraceID{1}.String())
+
+		assert.Nil(t, err)
+		assert.Len(t, logs, 2)
+		assert.Equal(t, "first", logs[0].Body)
+		assert.Equal(t, "second", logs[1].Body)
+		assert.Equal(t, pcommon.TraceID{1}.String(), logs[0].TraceID.String)
+		assert.Equal(t, uint32(1), logs[0].Flags)
+	})
+
+	t.Run("gets logs for span", func(t *testing.T) {
+		logs, err := database.GetLogsForSpan(t.Context(), pcommon.SpanID{2}.String())
+
+		assert.Nil(t, err)
+		assert.Len(t, logs, 1)
+		assert.Equal(t, "second", logs[0].Body)
+	})
+
+	t.Run("stores logs without trace context", func(t *testing.T) {
+		logs, err := database.GetLogs(t.Context())
+
+		assert.Nil(t, err)
+		assert.Len(t, logs, 4)
+		for _, l := range logs {
+			if l.Body == "no trace" {
+				assert.False(t, l.TraceID.Valid)
+				assert.False(t, l.SpanID.Valid)
+			}
+		}
+	})
+}
+
+func TestMigrateLogColumns(t *testing.T) {
+	database, err := db.NewDB(":memory:")
+	assert.Nil(t, err)
+	defer database.Close()
+
+	// The log table as it was created before trace context and structured
+	// bodies were stored
+	for _, statement := range []string{
+		`CREATE TABLE resource (id VARCHAR PRIMARY KEY, service_name VARCHAR, service_namespace VARCHAR)`,
+		`CREATE TABLE log (
+			span_id VARCHAR,
+			body VARCHAR,
+			timestamp TIMESTAMP,
+			severity_number INTEGER,
+			severity_text VARCHAR,
+			resource_id VARCHAR,
+			attributes JSON,
+			FOREIGN KEY (resource_id) REFERENCES resource (id)
+		)`,
+		`INSERT INTO resource VALUES ('r', 'api', '')`,
+		`INSERT INTO log VALUES (NULL, 'old', '2025-01-01 12:00:00', 9, 'INFO', 'r', '{}')`,
+	} {
+		_, err := database.ExecContext(t.Context(), statement)
+		assert.Nil(t, err)
+	}
+
+	err = database.Migrate(t.Context())
+	assert.Nil(t, err)
+
+	logs, err := database.GetLogs(t.Context())
+	assert.Nil(t, err)
+	assert.Len(t, logs, 1)
+	assert.False(t, logs[0].TraceID.Valid)
+	assert.Equal(t, uint32(0), logs[0].Flags)
+	assert.Equal(t, "Str", logs[0].BodyType)
+	assert.Nil(t, logs[0].BodyValue)
+	assert.Equal(t, "old", logs[0].Body)
+}
+
+func TestStructuredLogBodies(t *testing.T) {
+	database, err := getDB(t)
+	assert.Nil(t, err)
+	defer database.Close()
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	rl := plog.NewResourceLogs()
+	rl.Resource().Attributes().PutStr("service.name", "worker")
+	records := rl.ScopeLogs().AppendEmpty().LogRecords()
+
+	structured := records.AppendEmpty()
+	structured.SetTimestamp(pcommon.NewTimestampFromTime(start))
+	structured.SetObservedTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Second)))
+	structured.SetEventName("order.created")
+	body := structured.Body().SetEmptyMap()
+	body.PutStr("message", "order created")
+	user := body.PutEmptyMap("user")
+	user.PutInt("id", 42)
+	user.PutStr("http-status", "ok")
+	structured.Attributes().PutStr("tenant", "acme")
+
+	number := records.AppendEmpty()
+	number.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Second)))
+	number.Body().SetInt(7)
+
+	// Without a timestamp the observed timestamp is used instead
+	observed := records.AppendEmpty()
+	observed.SetObservedTimestamp(pcommon.NewTimestampFromTime(start.Add(2 * time.Second)))
+	observed.Body().SetBool(true)
+
+	assert.Nil(t, database.InsertResourceLogs(t.Context(), rl))
+
+	t.Run("keeps the body type and value", func(t *testing.T) {
+		logs, err := database.GetLogs(t.Context())
+
+		assert.Nil(t, err)
+		assert.Len(t, logs, 3)
+
+		assert.Equal(t, "Bool", logs[0].BodyType)
+		assert.Equal(t, true, logs[0].BodyValue)
+		assert.Equal(t, start.Add(2*time.Second), logs[0].Timestamp.UTC())
+
+		assert.Equal(t, "Int", logs[1].BodyType)
+		assert.Equal(t, "7", logs[1].Body)
+
+		assert.Equal(t, "Map", logs[2].BodyType)
+		assert.Equal(t, "order.created", logs[2].EventName)
+		assert.Equal(t, start.Add(time.Second), logs[2].ObservedTimestamp.Time.UTC())
+		assert.Equal(t, "worker", logs[2].ServiceName)
+		assert.Contains(t, logs[2].Body, `"message":"order created"`)
+
+		value, ok := logs[2].BodyValue.(map[string]any)
+		assert.True(t, ok)
+		assert.Equal(t, "order created", value["message"])
+	})
+
+	t.Run("filters by text", func(t *testing.T) {
+		logs, err := database.FilterLogs(t.Context(), db.LogFilter{Text: "ORDER"}, 10)
+
+		assert.Nil(t, err)
+		assert.Len(t, logs, 1)
+		assert.Equal(t, "Map", logs[0].BodyType)
+	})
+
+	t.Run("filters by body and attribute fields", func(t *testing.T) {
+		for _, f := range []db.LogFieldFilter{
+			{"user.id", "42"},
+			{"user.http-status", "ok"},
+			{"tenant", "acme"},
+		} {
+			logs, err := database.FilterLogs(t.Context(), db.LogFilter{Fields: []db.LogFieldFilter{f}}, 10)
+
+			assert.Nil(t, err)
+			assert.Len(t, logs, 1, f.Path)
+		}
+
+		logs, err := database.FilterLogs(t.Context(), db.LogFilter{Fields: []db.LogFieldFilter{{"user.id", "43"}}}, 10)
+		assert.Nil(t, err)
+		assert.Empty(t, logs)
+	})
+
+	t.Run("limits the number of logs", func(t *testing.T) {
+		logs, err := database.FilterLogs(t.Context(), db.LogFilter{}, 2)
+
+		assert.Nil(t, err)
+		assert.Len(t, logs, 2)
+	})
+}
+
+func TestLogHistogram(t *testing.T) {
+	database, err := getDB(t)
+	assert.Nil(t, err)
+	defer database.Close()
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	insertLogs(t, database, "api",
+		testLog{body: "started", timestamp: start, severity: plog.SeverityNumberInfo},
+		testLog{body: "slow query", timestamp: start.Add(time.Second), severity: plog.SeverityNumberWarn},
+		testLog{body: "failed", timestamp: start.Add(5 * time.Second), severity: plog.SeverityNumberError},
+	)
+	insertLogs(t, database, "worker",
+		testLog{body: "failed", timestamp: start.Add(6 * time.Second), severity: plog.SeverityNumberError},
+	)
+
+	t.Run("filters by severity, service and time", func(t *testing.T) {
+		for _, tc := range []struct {
+			filter   db.LogFilter
+			expected int
+		}{
+			{db.LogFilter{MinSeverity: int(plog.SeverityNumberWarn)}, 3},
+			{db.LogFilter{ServiceName: "worker"}, 1},
+			{db.LogFilter{MinSeverity: int(plog.SeverityNumberError), ServiceName: "api"}, 1},
+			{db.LogFilter{From: start.Add(time.Second), To: start.Add(6 * time.Second)}, 2},
+		} {
+			logs, err := database.FilterLogs(t.Context(), tc.filter, 10)
+
+			assert.Nil(t, err)
+			assert.Len(t, logs, tc.expected, tc.filter)
+		}
+	})
+
+	t.Run("gets the time range", func(t *testing.T) {
+		first, last, err := database.GetLogTimeRange(t.Context(), db.LogFilter{ServiceName: "api"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, start, first.UTC())
+		assert.Equal(t, start.Add(5*time.Second), last.UTC())
+	})
+
+	t.Run("gets no time range without logs", func(t *testing.T) {
+		first, last, err := database.GetLogTimeRange(t.Context(), db.LogFilter{ServiceName: "nope"})
+
+		assert.Nil(t, err)
+		assert.True(t, first.IsZero())
+		assert.True(t, last.IsZero())
+	})
+
+	t.Run("counts per bucket and severity", func(t *testing.T) {
+		counts, err := database.GetLogCounts(t.Context(), db.LogFilter{}, start, 2*time.Second)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []db.LogCount{
+			{Bucket: 0, SeverityNumber: int(plog.SeverityNumberInfo), Count: 1},
+			{Bucket: 0, SeverityNumber: int(plog.SeverityNumberWarn), Count: 1},
+			{Bucket: 2, SeverityNumber: int(plog.SeverityNumberError), Count: 1},
+			{Bucket: 3, SeverityNumber: int(plog.SeverityNumberError), Count: 1},
+		}, counts)
+	})
+
+	t.Run("lists services", func(t *testing.T) {
+		names, err := database.GetLogServiceNames(t.Context())
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"api", "worker"}, names)
+	})
+}
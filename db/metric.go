@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+// InsertResourceMetrics inserts the resource and a row for each data point
+// of its metrics. Only the count and sum of histograms and summaries are
+// kept, not their buckets and quantiles.
+func (d *Database) InsertResourceMetrics(ctx context.Context, metrics pmetric.ResourceMetrics) error {
+	resID, err := d.InsertResource(ctx, metrics.Resource())
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, scopeMetrics := range metrics.ScopeMetrics().All() {
+		for _, metric := range scopeMetrics.Metrics().All() {
+			insert := func(attributes pcommon.Map, start, timestamp pcommon.Timestamp, value, sum sql.NullFloat64, count sql.NullInt64) {
+				attrs, err := json.Marshal(attributes.AsRaw())
+				if err != nil {
+					zap.L().Warn("could not serialize metric attributes to JSON", zap.Error(err))
+					attrs = []byte("{}")
+				}
+
+				_, err = tx.ExecContext(
+					ctx,
+					`INSERT INTO metric VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+					metric.Name(),
+					metric.Description(),
+					metric.Unit(),
+					metric.Type().String(),
+					timestamp.AsTime(),
+					sql.NullTime{Time: start.AsTime(), Valid: start != 0},
+					value,
+					count,
+					sum,
+					attrs,
+					resID,
+				)
+				if err != nil {
+					zap.L().Warn("failed to create metric", zap.String("name", metric.Name()), zap.Error(err))
+				}
+			}
+
+			insertNumbers := func(points pmetric.NumberDataPointSlice) {
+				for _, dp := range points.All() {
+					value := dp.DoubleValue()
+					if dp.ValueType() == pmetric.NumberDataPointValueTypeInt {
+						value = float64(dp.IntValue())
+					}
+					insert(dp.Attributes(), dp.StartTimestamp(), dp.Timestamp(), sql.NullFloat64{Float64: value, Valid: true}, sql.NullFloat64{}, sql.NullInt64{})
+				}
+			}
+
+			switch metric.Type() {
+			case pmetric.MetricTypeGauge:
+				insertNumbers(metric.Gauge().DataPoints())
+			case pmetric.MetricTypeSum:
+				insertNumbers(metric.Sum().DataPoints())
+			case pmetric.MetricTypeHistogram:
+				for _, dp := range metric.Histogram().DataPoints().All() {
+					insert(dp.Attributes(), dp.StartTimestamp(), dp.Timestamp(), sql.NullFloat64{}, sql.NullFloat64{Float64: dp.Sum(), Valid: dp.HasSum()}, sql.NullInt64{Int64: int64(dp.Count()), Valid: true})
+				}
+			case pmetric.MetricTypeExponentialHistogram:
+				for _, dp := range metric.ExponentialHistogram().DataPoints().All() {
+					insert(dp.Attributes(), dp.StartTimestamp(), dp.Timestamp(), sql.NullFloat64{}, sql.NullFloat64{Float64: dp.Sum(), Valid: dp.HasSum()}, sql.NullInt64{Int64: int64(dp.Count()), Valid: true})
+				}
+			case pmetric.MetricTypeSummary:
+				for _, dp := range metric.Summary().DataPoints().All() {
+					insert(dp.Attributes(), dp.StartTimestamp(), dp.Timestamp(), sql.NullFloat64{}, sql.NullFloat64{Float64: dp.Sum(), Valid: true}, sql.NullInt64{Int64: int64(dp.Count()), Valid: true})
+				}
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction metric: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Database) ClearMetrics(ctx context.Context) error {
+	_, err := d.ExecContext(ctx, `TRUNCATE TABLE metric`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetMetrics returns the data points of all metrics, newest first.
+func (d *Database) GetMetrics(ctx context.Context) ([]Metric, error) {
+	metrics := make([]Metric, 0)
+	err := d.sqlDB.SelectContext(
+		ctx,
+		&metrics,
+		`
+		SELECT
+			metric.*,
+			COALESCE(resource.service_name, '') AS service_name
+		FROM
+			metric
+			LEFT JOIN resource ON metric.resource_id = resource.id
+		ORDER BY
+			metric.timestamp DESC,
+			metric.name`,
+	)
+	if err != nil {
+		return metrics, err
+	}
+
+	return metrics, nil
+}
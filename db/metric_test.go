@@ -0,0 +1,75 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestInsertResourceMetrics(t *testing.T) {
+	database, err := getDB(t)
+	assert.Nil(t, err)
+	defer database.Close()
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	rm := pmetric.NewResourceMetrics()
+	rm.Resource().Attributes().PutStr("service.name", "shop")
+	metrics := rm.ScopeMetrics().AppendEmpty().Metrics()
+
+	requests := metrics.AppendEmpty()
+	requests.SetName("http.server.requests")
+	requests.SetUnit("{request}")
+	dp := requests.SetEmptySum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Minute)))
+	dp.SetIntValue(42)
+	dp.Attributes().PutStr("http.route", "/orders")
+
+	duration := metrics.AppendEmpty()
+	duration.SetName("http.server.duration")
+	hdp := duration.SetEmptyHistogram().DataPoints().AppendEmpty()
+	hdp.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(2 * time.Minute)))
+	hdp.SetCount(5)
+	hdp.SetSum(1.5)
+
+	assert.Nil(t, database.InsertResourceMetrics(t.Context(), rm))
+
+	stored, err := database.GetMetrics(t.Context())
+	assert.Nil(t, err)
+	assert.Len(t, stored, 2)
+
+	t.Run("stores the value of sums and gauges", func(t *testing.T) {
+		m := stored[1]
+
+		assert.Equal(t, "http.server.requests", m.Name)
+		assert.Equal(t, "Sum", m.Type)
+		assert.Equal(t, "{request}", m.Unit)
+		assert.Equal(t, 42.0, m.Value.Float64)
+		assert.False(t, m.Count.Valid)
+		assert.True(t, start.Equal(m.StartTimestamp.Time))
+		assert.Equal(t, map[string]any{"http.route": "/orders"}, m.Attributes)
+		assert.Equal(t, "shop", m.ServiceName)
+	})
+
+	t.Run("stores the count and sum of histograms", func(t *testing.T) {
+		m := stored[0]
+
+		assert.Equal(t, "Histogram", m.Type)
+		assert.False(t, m.Value.Valid)
+		assert.Equal(t, int64(5), m.Count.Int64)
+		assert.Equal(t, 1.5, m.Sum.Float64)
+		assert.False(t, m.StartTimestamp.Valid)
+	})
+
+	t.Run("clears metrics", func(t *testing.T) {
+		assert.Nil(t, database.ClearMetrics(t.Context()))
+
+		stored, err := database.GetMetrics(t.Context())
+		assert.Nil(t, err)
+		assert.Empty(t, stored)
+	})
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -18,22 +19,105 @@ type Span struct {
 	StatusMessage sql.NullString `db:"status_message"`
 
 	Attributes map[string]any `db:"attributes"`
+	// Events are what happened during the span, e.g. exceptions
+	Events SpanEvents `db:"events"`
 
 	ResourceID string `db:"resource_id"`
+	// ServiceName is joined in from the resource table
+	ServiceName string `db:"service_name"`
+	// ServiceInstance is joined in from the resource table, see
+	// Resource.Instance
+	ServiceInstance string `db:"service_instance"`
+}
+
+// SpanEvent is something which happened at a point in time during a span,
+// e.g. an exception with its stack trace.
+type SpanEvent struct {
+	Name       string         `json:"name"`
+	Timestamp  time.Time      `json:"timestamp"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// SpanEvents are stored as a JSON array on the span.
+type SpanEvents []SpanEvent
+
+// Scan reads the events from JSON, which the driver may already have
+// decoded.
+func (e *SpanEvents) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		var err error
+		if b, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+
+	return json.Unmarshal(b, e)
+}
+
+type SpanNameCount struct {
+	Name  string `db:"name"`
+	Count int    `db:"count"`
 }
 
 type Log struct {
-	SpanID         sql.NullString `db:"span_id"`
-	Body           string         `db:"body"`
-	Timestamp      time.Time      `db:"timestamp"`
-	SeverityNumber int            `db:"severity_number"`
-	SeverityText   string         `db:"severity_text"`
-	ResourceID     string         `db:"resource_id"`
-	Attributes     map[string]any `db:"attributes"`
+	TraceID sql.NullString `db:"trace_id"`
+	SpanID  sql.NullString `db:"span_id"`
+	// Flags are the W3C trace flags of the span the log was emitted in
+	Flags uint32 `db:"flags"`
+	// Body is the body formatted as a string, with structured bodies
+	// formatted as JSON
+	Body string `db:"body"`
+	// BodyType is the type of the body as formatted by pcommon.ValueType,
+	// e.g. Str or Map
+	BodyType string `db:"body_type"`
+	// BodyValue is the body decoded from JSON, e.g. map[string]any for Map
+	// bodies. It's nil for logs stored before bodies were kept as JSON.
+	BodyValue         any            `db:"body_json"`
+	ObservedTimestamp sql.NullTime   `db:"observed_timestamp"`
+	EventName         string         `db:"event_name"`
+	Timestamp         time.Time      `db:"timestamp"`
+	SeverityNumber    int            `db:"severity_number"`
+	SeverityText      string         `db:"severity_text"`
+	ResourceID        string         `db:"resource_id"`
+	Attributes        map[string]any `db:"attributes"`
+	// ServiceName is joined in from the resource table
+	ServiceName string `db:"service_name"`
+}
+
+// Metric is a data point of a metric. Gauges and sums have a value, and
+// histograms and summaries a count and sum.
+type Metric struct {
+	Name        string `db:"name"`
+	Description string `db:"description"`
+	Unit        string `db:"unit"`
+	// Type is the type of metric as formatted by pmetric.MetricType, e.g.
+	// Sum or Histogram
+	Type           string          `db:"type"`
+	Timestamp      time.Time       `db:"timestamp"`
+	StartTimestamp sql.NullTime    `db:"start_timestamp"`
+	Value          sql.NullFloat64 `db:"value"`
+	Count          sql.NullInt64   `db:"count"`
+	Sum            sql.NullFloat64 `db:"sum"`
+	ResourceID     string          `db:"resource_id"`
+	Attributes     map[string]any  `db:"attributes"`
+	// ServiceName is joined in from the resource table
+	ServiceName string `db:"service_name"`
 }
 
 type Resource struct {
 	ID               string `db:"id"`
 	ServiceName      string `db:"service_name"`
 	ServiceNamespace string `db:"service_namespace"`
+	// Instance tells replicas of a service apart, and is the
+	// service.instance.id or else the host.name of the resource
+	Instance string `db:"instance"`
 }
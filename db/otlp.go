@@ -0,0 +1,91 @@
+package db
+
+import (
+	"encoding/hex"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+var spanKinds = map[string]ptrace.SpanKind{
+	ptrace.SpanKindUnspecified.String(): ptrace.SpanKindUnspecified,
+	ptrace.SpanKindInternal.String():    ptrace.SpanKindInternal,
+	ptrace.SpanKindServer.String():      ptrace.SpanKindServer,
+	ptrace.SpanKindClient.String():      ptrace.SpanKindClient,
+	ptrace.SpanKindProducer.String():    ptrace.SpanKindProducer,
+	ptrace.SpanKindConsumer.String():    ptrace.SpanKindConsumer,
+}
+
+var statusCodes = map[string]ptrace.StatusCode{
+	ptrace.StatusCodeUnset.String(): ptrace.StatusCodeUnset,
+	ptrace.StatusCodeOk.String():    ptrace.StatusCodeOk,
+	ptrace.StatusCodeError.String(): ptrace.StatusCodeError,
+}
+
+// SpansToTraces converts stored spans back into OTLP, with a resource per
+// service. Only what is stored comes back, so e.g. resource attributes
+// other than the service name are lost, and as attributes are stored as
+// JSON integers come back as doubles.
+func SpansToTraces(spans []Span) ptrace.Traces {
+	traces := ptrace.NewTraces()
+
+	byResource := make(map[string]ptrace.SpanSlice)
+	for _, s := range spans {
+		slice, ok := byResource[s.ResourceID]
+		if !ok {
+			rs := traces.ResourceSpans().AppendEmpty()
+			if s.ServiceName != "" {
+				rs.Resource().Attributes().PutStr("service.name", s.ServiceName)
+			}
+			slice = rs.ScopeSpans().AppendEmpty().Spans()
+			byResource[s.ResourceID] = slice
+		}
+
+		span := slice.AppendEmpty()
+		span.SetTraceID(traceIDFromHex(s.TraceID))
+		span.SetSpanID(spanIDFromHex(s.ID))
+		if s.ParentSpanID.Valid {
+			span.SetParentSpanID(spanIDFromHex(s.ParentSpanID.String))
+		}
+		span.SetName(s.Name)
+		span.SetKind(spanKinds[s.Kind])
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(s.StartTime))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(s.StartTime.Add(s.Duration)))
+		span.Status().SetCode(statusCodes[s.StatusCode])
+		span.Status().SetMessage(s.StatusMessage.String)
+		if err := span.Attributes().FromRaw(s.Attributes); err != nil {
+			zap.L().Warn("could not convert span attributes", zap.String("spanID", s.ID), zap.Error(err))
+		}
+		for _, e := range s.Events {
+			event := span.Events().AppendEmpty()
+			event.SetName(e.Name)
+			event.SetTimestamp(pcommon.NewTimestampFromTime(e.Timestamp))
+			if err := event.Attributes().FromRaw(e.Attributes); err != nil {
+				zap.L().Warn("could not convert span event attributes", zap.String("spanID", s.ID), zap.Error(err))
+			}
+		}
+	}
+
+	return traces
+}
+
+// traceIDFromHex decodes a stored trace ID, leaving it empty if it isn't
+// valid.
+func traceIDFromHex(s string) pcommon.TraceID {
+	var id pcommon.TraceID
+	if b, err := hex.DecodeString(s); err == nil && len(b) == len(id) {
+		copy(id[:], b)
+	}
+
+	return id
+}
+
+func spanIDFromHex(s string) pcommon.SpanID {
+	var id pcommon.SpanID
+	if b, err := hex.DecodeString(s); err == nil && len(b) == len(id) {
+		copy(id[:], b)
+	}
+
+	return id
+}
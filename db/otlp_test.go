@@ -0,0 +1,80 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestSpansToTraces(t *testing.T) {
+	database, err := getDB(t)
+	assert.Nil(t, err)
+	defer database.Close()
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, service := range []string{"frontend", "checkout"} {
+		rs := ptrace.NewResourceSpans()
+		rs.Resource().Attributes().PutStr("service.name", service)
+		span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetTraceID(pcommon.TraceID{1})
+		span.SetName(service + " span")
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Second)))
+		if service == "frontend" {
+			span.SetSpanID(pcommon.SpanID{1})
+			span.SetKind(ptrace.SpanKindServer)
+		} else {
+			span.SetSpanID(pcommon.SpanID{2})
+			span.SetParentSpanID(pcommon.SpanID{1})
+			span.SetKind(ptrace.SpanKindClient)
+			span.Status().SetCode(ptrace.StatusCodeError)
+			span.Status().SetMessage("out of stock")
+			span.Attributes().PutInt("items", 3)
+			event := span.Events().AppendEmpty()
+			event.SetName("exception")
+			event.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Millisecond)))
+			event.Attributes().PutStr("exception.stacktrace", "at /app/stock.go:12")
+		}
+		assert.Nil(t, database.InsertResourceSpans(t.Context(), rs))
+	}
+
+	spans, err := database.GetSpansForTrace(t.Context(), pcommon.TraceID{1}.String())
+	assert.Nil(t, err)
+
+	traces := db.SpansToTraces(spans)
+
+	assert.Equal(t, 2, traces.ResourceSpans().Len())
+	assert.Equal(t, 2, traces.SpanCount())
+
+	for _, rs := range traces.ResourceSpans().All() {
+		service, _ := rs.Resource().Attributes().Get("service.name")
+		span := rs.ScopeSpans().At(0).Spans().At(0)
+
+		assert.Equal(t, pcommon.TraceID{1}, span.TraceID())
+		assert.Equal(t, service.Str()+" span", span.Name())
+		assert.Equal(t, start, span.StartTimestamp().AsTime())
+		assert.Equal(t, start.Add(time.Second), span.EndTimestamp().AsTime())
+
+		if service.Str() == "checkout" {
+			assert.Equal(t, pcommon.SpanID{2}, span.SpanID())
+			assert.Equal(t, pcommon.SpanID{1}, span.ParentSpanID())
+			assert.Equal(t, ptrace.SpanKindClient, span.Kind())
+			assert.Equal(t, ptrace.StatusCodeError, span.Status().Code())
+			assert.Equal(t, "out of stock", span.Status().Message())
+			assert.Equal(t, map[string]any{"items": float64(3)}, span.Attributes().AsRaw())
+			assert.Equal(t, 1, span.Events().Len())
+			assert.Equal(t, "exception", span.Events().At(0).Name())
+			assert.Equal(t, start.Add(time.Millisecond), span.Events().At(0).Timestamp().AsTime())
+			assert.Equal(t, map[string]any{"exception.stacktrace": "at /app/stock.go:12"}, span.Events().At(0).Attributes().AsRaw())
+		} else {
+			assert.True(t, span.ParentSpanID().IsEmpty())
+			assert.Equal(t, 0, span.Events().Len())
+			assert.Equal(t, ptrace.SpanKindServer, span.Kind())
+		}
+	}
+}
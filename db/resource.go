@@ -44,10 +44,22 @@ func (d *Database) InsertResource(ctx context.Context, res pcommon.Resource) (st
 	}
 	resID := fmt.Sprintf("%s:%s", resName.Str(), resNamespace.Str())
 
-	_, err := d.ExecContext(ctx, `INSERT OR REPLACE INTO resource VALUES (?, ?, ?)`,
+	// Replicas of a service are told apart, as e.g. their clocks can differ
+	instance := ""
+	if v, exists := res.Attributes().Get(string(semconv.ServiceInstanceIDKey)); exists {
+		instance = v.AsString()
+	} else if v, exists := res.Attributes().Get(string(semconv.HostNameKey)); exists {
+		instance = v.AsString()
+	}
+	if instance != "" {
+		resID = fmt.Sprintf("%s:%s", resID, instance)
+	}
+
+	_, err := d.ExecContext(ctx, `INSERT OR REPLACE INTO resource (id, service_name, service_namespace, instance) VALUES (?, ?, ?, ?)`,
 		resID,
 		resName.Str(),
 		resNamespace.Str(),
+		instance,
 	)
 
 	return resID, err
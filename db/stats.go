@@ -0,0 +1,274 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// OperationStats are the request count, error count and latency
+// percentiles (the RED metrics) for a span name within a service.
+type OperationStats struct {
+	ServiceName string `db:"service_name"`
+	// Name is null for the rows aggregating every operation in a service
+	Name   sql.NullString `db:"name"`
+	Count  int            `db:"count"`
+	Errors int            `db:"errors"`
+	P50    time.Duration  `db:"p50_ns"`
+	P90    time.Duration  `db:"p90_ns"`
+	P99    time.Duration  `db:"p99_ns"`
+}
+
+// OperationRate is the number of spans started within a bucket of time for
+// a span name within a service.
+type OperationRate struct {
+	ServiceName string `db:"service_name"`
+	// Name is null for the rows aggregating every operation in a service
+	Name   sql.NullString `db:"name"`
+	Bucket int            `db:"bucket"`
+	Count  int            `db:"count"`
+	Errors int            `db:"errors"`
+}
+
+// GetOperationStats returns the stats of spans started since the given time,
+// both per service and per span name within each service. The service rows
+// come before the rows of its operations.
+func (d *Database) GetOperationStats(ctx context.Context, since time.Time) ([]OperationStats, error) {
+	stats := make([]OperationStats, 0)
+	err := d.sqlDB.SelectContext(
+		ctx,
+		&stats,
+		`
+		SELECT
+			COALESCE(resource.service_name, '') AS service_name,
+			span.name AS name,
+			COUNT(*) AS count,
+			COUNT(*) FILTER (WHERE span.status_code = 'Error') AS errors,
+			CAST(quantile_cont(span.duration_ns, 0.5) AS BIGINT) AS p50_ns,
+			CAST(quantile_cont(span.duration_ns, 0.9) AS BIGINT) AS p90_ns,
+			CAST(quantile_cont(span.duration_ns, 0.99) AS BIGINT) AS p99_ns
+		FROM
+			span
+			LEFT JOIN resource ON span.resource_id = resource.id
+		WHERE
+			span.start_time >= ?
+		GROUP BY
+			GROUPING SETS ((service_name), (service_name, span.name))
+		ORDER BY
+			service_name,
+			name NULLS FIRST`,
+		since,
+	)
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// GetOperationRates returns the number of spans started in each bucket of
+// bucketSize since the given time, both per service and per span name within
+// each service. Buckets without spans are left out.
+func (d *Database) GetOperationRates(ctx context.Context, since time.Time, bucketSize time.Duration) ([]OperationRate, error) {
+	rates := make([]OperationRate, 0)
+	err := d.sqlDB.SelectContext(
+		ctx,
+		&rates,
+		`
+		SELECT
+			COALESCE(resource.service_name, '') AS service_name,
+			span.name AS name,
+			CAST(FLOOR((epoch_us(span.start_time) - ?) / ?) AS BIGINT) AS bucket,
+			COUNT(*) AS count,
+			COUNT(*) FILTER (WHERE span.status_code = 'Error') AS errors
+		FROM
+			span
+			LEFT JOIN resource ON span.resource_id = resource.id
+		WHERE
+			span.start_time >= ?
+		GROUP BY
+			GROUPING SETS ((service_name, bucket), (service_name, span.name, bucket))
+		ORDER BY
+			service_name,
+			name NULLS FIRST,
+			bucket`,
+		since.UnixMicro(),
+		max(bucketSize.Microseconds(), 1),
+		since,
+	)
+	if err != nil {
+		return rates, err
+	}
+
+	return rates, nil
+}
+
+// GetEarliestSpanStartTime returns the start time of the earliest span, or
+// the zero time if there are no spans.
+func (d *Database) GetEarliestSpanStartTime(ctx context.Context) (time.Time, error) {
+	var earliest sql.NullTime
+	err := d.sqlDB.GetContext(ctx, &earliest, `SELECT MIN(start_time) FROM span`)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return earliest.Time, nil
+}
+
+// LatencyBucket is a bucket of a latency histogram covering durations from
+// Lower (inclusive) to Upper (exclusive).
+type LatencyBucket struct {
+	Lower time.Duration
+	Upper time.Duration
+	Count int
+}
+
+// GetLatencyHistogram splits the durations of spans with the given service
+// and span name started since the given time into buckets from the fastest
+// to the slowest, see latencyEdges. An empty spanName includes every span in
+// the service. Empty buckets are included so the histogram can be drawn as
+// is.
+func (d *Database) GetLatencyHistogram(ctx context.Context, serviceName, spanName string, since time.Time, buckets int) ([]LatencyBucket, error) {
+	var bounds struct {
+		Lower sql.NullInt64 `db:"lower_ns"`
+		Upper sql.NullInt64 `db:"upper_ns"`
+	}
+	err := d.sqlDB.GetContext(
+		ctx,
+		&bounds,
+		`
+		SELECT
+			MIN(span.duration_ns) AS lower_ns,
+			MAX(span.duration_ns) + 1 AS upper_ns
+		FROM
+			span
+			LEFT JOIN resource ON span.resource_id = resource.id
+		WHERE
+			COALESCE(resource.service_name, '') = ?
+			AND (? = '' OR span.name = ?)
+			AND span.start_time >= ?`,
+		serviceName,
+		spanName,
+		spanName,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bounds.Lower.Valid {
+		return []LatencyBucket{}, nil
+	}
+
+	edges := latencyEdges(time.Duration(bounds.Lower.Int64), time.Duration(bounds.Upper.Int64), buckets)
+
+	// The bucket of a span is the number of edges between buckets it's at
+	// or past
+	past := []string{"0"}
+	args := make([]any, 0, buckets+3)
+	for _, edge := range edges[1:buckets] {
+		past = append(past, "CAST(span.duration_ns >= ? AS INTEGER)")
+		args = append(args, int64(edge))
+	}
+
+	counts := make([]struct {
+		Bucket int `db:"bucket"`
+		Count  int `db:"count"`
+	}, 0)
+	err = d.sqlDB.SelectContext(
+		ctx,
+		&counts,
+		fmt.Sprintf(`
+		SELECT
+			%s AS bucket,
+			COUNT(*) AS count
+		FROM
+			span
+			LEFT JOIN resource ON span.resource_id = resource.id
+		WHERE
+			COALESCE(resource.service_name, '') = ?
+			AND (? = '' OR span.name = ?)
+			AND span.start_time >= ?
+		GROUP BY
+			bucket`, strings.Join(past, " + ")),
+		append(args, serviceName, spanName, spanName, since)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	histogram := make([]LatencyBucket, buckets)
+	for i := range histogram {
+		histogram[i].Lower = edges[i]
+		histogram[i].Upper = edges[i+1]
+	}
+
+	for _, c := range counts {
+		histogram[c.Bucket].Count = c.Count
+	}
+
+	return histogram, nil
+}
+
+// latencyEdges are the edges of the buckets from lower to upper, each the
+// same factor wider than the one before, so that a few slow outliers don't
+// squash every other span into the first bucket. Buckets are at least a
+// nanosecond wide, so the last edge may be past upper.
+func latencyEdges(lower, upper time.Duration, buckets int) []time.Duration {
+	// Logarithms of durations of 0 are infinite
+	from := math.Log(float64(max(lower, 1)))
+	// step is the logarithm of the factor
+	step := (math.Log(float64(upper)) - from) / float64(buckets)
+
+	edges := make([]time.Duration, buckets+1)
+	edges[0] = lower
+	for i := 1; i < buckets; i++ {
+		edges[i] = max(time.Duration(math.Round(math.Exp(from+step*float64(i)))), edges[i-1]+1)
+	}
+	edges[buckets] = max(upper, edges[buckets-1]+1)
+
+	return edges
+}
+
+// GetSpansInLatencyRange returns the spans with the given service and span
+// name started since the given time with a duration from lower (inclusive)
+// to upper (exclusive), slowest first. An empty spanName includes every span
+// in the service.
+func (d *Database) GetSpansInLatencyRange(ctx context.Context, serviceName, spanName string, since time.Time, lower, upper time.Duration, limit int) ([]Span, error) {
+	spans := make([]Span, 0)
+	err := d.sqlDB.SelectContext(
+		ctx,
+		&spans,
+		`
+		SELECT
+			span.*,
+			COALESCE(resource.service_name, '') AS service_name
+		FROM
+			span
+			LEFT JOIN resource ON span.resource_id = resource.id
+		WHERE
+			COALESCE(resource.service_name, '') = ?
+			AND (? = '' OR span.name = ?)
+			AND span.duration_ns >= ?
+			AND span.duration_ns < ?
+			AND span.start_time >= ?
+		ORDER BY
+			span.duration_ns DESC
+		LIMIT ?`,
+		serviceName,
+		spanName,
+		spanName,
+		int64(lower),
+		int64(upper),
+		since,
+		limit,
+	)
+	if err != nil {
+		return spans, err
+	}
+
+	return spans, nil
+}
@@ -0,0 +1,151 @@
+package db_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationStats(t *testing.T) {
+	database, err := getDB(t)
+	assert.Nil(t, err)
+	defer database.Close()
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	insertSpans(t, database, "api",
+		testSpan{traceID: 1, spanID: 1, name: "GET /", start: start, duration: time.Second},
+		testSpan{traceID: 2, spanID: 2, name: "GET /", start: start.Add(time.Minute), duration: 3 * time.Second, errorCode: true},
+		testSpan{traceID: 3, spanID: 3, name: "POST /", start: start.Add(2 * time.Minute), duration: 5 * time.Second},
+		testSpan{traceID: 4, spanID: 4, name: "old", start: start.Add(-time.Hour), duration: time.Second},
+	)
+	insertSpans(t, database, "db",
+		testSpan{traceID: 1, spanID: 5, parentID: 1, name: "query", start: start, duration: time.Millisecond},
+	)
+
+	t.Run("aggregates per service and operation", func(t *testing.T) {
+		stats, err := database.GetOperationStats(t.Context(), start)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []db.OperationStats{
+			{ServiceName: "api", Count: 3, Errors: 1, P50: 3 * time.Second, P90: 4600 * time.Millisecond, P99: 4960 * time.Millisecond},
+			{ServiceName: "api", Name: sql.NullString{String: "GET /", Valid: true}, Count: 2, Errors: 1, P50: 2 * time.Second, P90: 2800 * time.Millisecond, P99: 2980 * time.Millisecond},
+			{ServiceName: "api", Name: sql.NullString{String: "POST /", Valid: true}, Count: 1, P50: 5 * time.Second, P90: 5 * time.Second, P99: 5 * time.Second},
+			{ServiceName: "db", Count: 1, P50: time.Millisecond, P90: time.Millisecond, P99: time.Millisecond},
+			{ServiceName: "db", Name: sql.NullString{String: "query", Valid: true}, Count: 1, P50: time.Millisecond, P90: time.Millisecond, P99: time.Millisecond},
+		}, stats)
+	})
+
+	t.Run("buckets the rate", func(t *testing.T) {
+		rates, err := database.GetOperationRates(t.Context(), start, 90*time.Second)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []db.OperationRate{
+			{ServiceName: "api", Bucket: 0, Count: 2, Errors: 1},
+			{ServiceName: "api", Bucket: 1, Count: 1},
+			{ServiceName: "api", Name: sql.NullString{String: "GET /", Valid: true}, Bucket: 0, Count: 2, Errors: 1},
+			{ServiceName: "api", Name: sql.NullString{String: "POST /", Valid: true}, Bucket: 1, Count: 1},
+			{ServiceName: "db", Bucket: 0, Count: 1},
+			{ServiceName: "db", Name: sql.NullString{String: "query", Valid: true}, Bucket: 0, Count: 1},
+		}, rates)
+	})
+
+	t.Run("finds the earliest span", func(t *testing.T) {
+		earliest, err := database.GetEarliestSpanStartTime(t.Context())
+
+		assert.Nil(t, err)
+		assert.True(t, start.Add(-time.Hour).Equal(earliest), earliest)
+	})
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	database, err := getDB(t)
+	assert.Nil(t, err)
+	defer database.Close()
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	insertSpans(t, database, "api",
+		testSpan{traceID: 1, spanID: 1, name: "GET /", start: start, duration: 10 * time.Millisecond},
+		testSpan{traceID: 2, spanID: 2, name: "GET /", start: start, duration: 12 * time.Millisecond},
+		testSpan{traceID: 3, spanID: 3, name: "GET /", start: start, duration: 50 * time.Millisecond},
+		testSpan{traceID: 4, spanID: 4, name: "GET /", start: start, duration: 90 * time.Millisecond},
+		testSpan{traceID: 5, spanID: 5, name: "POST /", start: start, duration: time.Second},
+	)
+
+	t.Run("buckets durations", func(t *testing.T) {
+		histogram, err := database.GetLatencyHistogram(t.Context(), "api", "GET /", start, 4)
+
+		assert.Nil(t, err)
+		assert.Len(t, histogram, 4)
+		assert.Equal(t, []int{2, 0, 1, 1}, []int{histogram[0].Count, histogram[1].Count, histogram[2].Count, histogram[3].Count})
+		assert.Equal(t, 10*time.Millisecond, histogram[0].Lower)
+		assert.Equal(t, histogram[0].Upper, histogram[1].Lower)
+		assert.Greater(t, histogram[3].Upper, 90*time.Millisecond)
+	})
+
+	t.Run("includes the whole service without a span name", func(t *testing.T) {
+		histogram, err := database.GetLatencyHistogram(t.Context(), "api", "", start, 2)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []int{4, 1}, []int{histogram[0].Count, histogram[1].Count})
+	})
+
+	t.Run("spreads out fast spans despite slow outliers", func(t *testing.T) {
+		insertSpans(t, database, "worker",
+			testSpan{traceID: 6, spanID: 6, name: "poll", start: start, duration: time.Millisecond},
+			testSpan{traceID: 7, spanID: 7, name: "poll", start: start, duration: 2 * time.Millisecond},
+			testSpan{traceID: 8, spanID: 8, name: "poll", start: start, duration: 4 * time.Millisecond},
+			testSpan{traceID: 9, spanID: 9, name: "poll", start: start, duration: time.Second},
+		)
+
+		histogram, err := database.GetLatencyHistogram(t.Context(), "worker", "poll", start, 10)
+
+		assert.Nil(t, err)
+		assert.Len(t, histogram, 10)
+		assert.Equal(t, []int{1, 1, 1}, []int{histogram[0].Count, histogram[1].Count, histogram[2].Count})
+		assert.Equal(t, 1, histogram[9].Count)
+		assert.Equal(t, histogram[4].Upper, histogram[5].Lower)
+	})
+
+	t.Run("is empty without spans", func(t *testing.T) {
+		histogram, err := database.GetLatencyHistogram(t.Context(), "nope", "", start, 2)
+
+		assert.Nil(t, err)
+		assert.Empty(t, histogram)
+	})
+
+	t.Run("lists spans in a bucket, slowest first", func(t *testing.T) {
+		histogram, err := database.GetLatencyHistogram(t.Context(), "api", "GET /", start, 4)
+		assert.Nil(t, err)
+
+		spans, err := database.GetSpansInLatencyRange(t.Context(), "api", "GET /", start, histogram[0].Lower, histogram[0].Upper, 10)
+
+		assert.Nil(t, err)
+		assert.Len(t, spans, 2)
+		assert.Equal(t, 12*time.Millisecond, spans[0].Duration)
+		assert.Equal(t, 10*time.Millisecond, spans[1].Duration)
+	})
+
+	t.Run("leaves out spans from before the window", func(t *testing.T) {
+		insertSpans(t, database, "api",
+			testSpan{traceID: 10, spanID: 10, name: "GET /", start: start.Add(-time.Hour), duration: 5 * time.Second},
+		)
+
+		histogram, err := database.GetLatencyHistogram(t.Context(), "api", "GET /", start, 4)
+		assert.Nil(t, err)
+		assert.Equal(t, []int{2, 0, 1, 1}, []int{histogram[0].Count, histogram[1].Count, histogram[2].Count, histogram[3].Count})
+		assert.Less(t, histogram[3].Upper, 5*time.Second)
+
+		spans, err := database.GetSpansInLatencyRange(t.Context(), "api", "GET /", start, 0, time.Minute, 10)
+		assert.Nil(t, err)
+		assert.Len(t, spans, 4)
+
+		histogram, err = database.GetLatencyHistogram(t.Context(), "api", "GET /", start.Add(-time.Hour), 4)
+		assert.Nil(t, err)
+		assert.Greater(t, histogram[3].Upper, 5*time.Second)
+	})
+}
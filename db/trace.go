@@ -32,11 +32,17 @@ func (d *Database) InsertResourceSpans(ctx context.Context, spans ptrace.Resourc
 				attrs = []byte("{}")
 			}
 
+			events, err := json.Marshal(spanEvents(span.Events()))
+			if err != nil {
+				zap.L().Warn("could not serialize span events to JSON", zap.Error(err))
+				events = []byte("[]")
+			}
+
 			zap.L().Debug("inserting new span", zap.Bool("root", span.ParentSpanID().IsEmpty()), zap.String("name", span.Name()))
 
 			_, err = tx.ExecContext(
 				ctx,
-				`INSERT INTO span VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				`INSERT INTO span VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 				span.SpanID().String(),
 				span.Name(),
 				span.StartTimestamp().AsTime(),
@@ -48,6 +54,7 @@ func (d *Database) InsertResourceSpans(ctx context.Context, spans ptrace.Resourc
 				sql.NullString{String: span.Status().Message(), Valid: span.Status().Message() != ""},
 				attrs,
 				resID,
+				events,
 			)
 			if err != nil {
 				zap.L().Warn("failed to create span", zap.String("name", span.Name()), zap.String("resourceID", resID))
@@ -63,6 +70,19 @@ func (d *Database) InsertResourceSpans(ctx context.Context, spans ptrace.Resourc
 	return nil
 }
 
+func spanEvents(events ptrace.SpanEventSlice) SpanEvents {
+	converted := make(SpanEvents, 0, events.Len())
+	for _, event := range events.All() {
+		converted = append(converted, SpanEvent{
+			Name:       event.Name(),
+			Timestamp:  event.Timestamp().AsTime(),
+			Attributes: event.Attributes().AsRaw(),
+		})
+	}
+
+	return converted
+}
+
 func (d *Database) ClearSpans(ctx context.Context) error {
 	_, err := d.sqlDB.ExecContext(ctx, `TRUNCATE TABLE span`)
 	if err != nil {
@@ -79,9 +99,12 @@ func (d *Database) GetSpans(ctx context.Context) ([]Span, error) {
 		&spans,
 		`
 		SELECT
-			*
+			span.*,
+			COALESCE(resource.service_name, '') AS service_name,
+			COALESCE(resource.instance, '') AS service_instance
 		FROM
 			span
+			LEFT JOIN resource ON span.resource_id = resource.id
 		ORDER BY
 			start_time DESC`,
 	)
@@ -99,9 +122,12 @@ func (d *Database) GetSpansForTrace(ctx context.Context, traceID string) ([]Span
 		&spans,
 		`
 		SELECT
-			*
+			span.*,
+			COALESCE(resource.service_name, '') AS service_name,
+			COALESCE(resource.instance, '') AS service_instance
 		FROM
 			span
+			LEFT JOIN resource ON span.resource_id = resource.id
 		WHERE
 			trace_id = ?
 		ORDER BY
@@ -115,6 +141,74 @@ func (d *Database) GetSpansForTrace(ctx context.Context, traceID string) ([]Span
 	return spans, nil
 }
 
+// GetSpansForRootSpanName returns the spans of the most recent traces
+// whose root span is called rootSpanName, at most limit traces.
+func (d *Database) GetSpansForRootSpanName(ctx context.Context, rootSpanName string, limit int) ([]Span, error) {
+	spans := make([]Span, 0)
+	err := d.sqlDB.SelectContext(
+		ctx,
+		&spans,
+		`
+		SELECT
+			span.*,
+			COALESCE(resource.service_name, '') AS service_name,
+			COALESCE(resource.instance, '') AS service_instance
+		FROM
+			span
+			LEFT JOIN resource ON span.resource_id = resource.id
+		WHERE
+			trace_id IN (
+				SELECT
+					trace_id
+				FROM
+					span
+				WHERE
+					parent_span_id IS NULL
+					AND name = ?
+				ORDER BY
+					start_time DESC
+				LIMIT ?
+			)
+		ORDER BY
+			start_time DESC`,
+		rootSpanName,
+		limit,
+	)
+	if err != nil {
+		return spans, err
+	}
+
+	return spans, nil
+}
+
+// GetRootSpanNames returns the names of root spans along with how many
+// traces have a root span with that name, most common first.
+func (d *Database) GetRootSpanNames(ctx context.Context) ([]SpanNameCount, error) {
+	names := make([]SpanNameCount, 0)
+	err := d.sqlDB.SelectContext(
+		ctx,
+		&names,
+		`
+		SELECT
+			name,
+			COUNT(*) AS count
+		FROM
+			span
+		WHERE
+			parent_span_id IS NULL
+		GROUP BY
+			name
+		ORDER BY
+			count DESC,
+			name`,
+	)
+	if err != nil {
+		return names, err
+	}
+
+	return names, nil
+}
+
 func FilterRootSpans(spans []Span) []Span {
 	rootSpans := make([]Span, 0)
 	for _, span := range spans {
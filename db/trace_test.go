@@ -3,9 +3,12 @@ package db_test
 import (
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/fredrikaugust/otelly/db"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
 func TestFilterRootSpans(t *testing.T) {
@@ -23,3 +26,104 @@ func TestFilterRootSpans(t *testing.T) {
 		assert.Len(t, rootSpans, 1)
 	})
 }
+
+func TestGetSpansForTrace(t *testing.T) {
+	t.Run("joins in the service name", func(t *testing.T) {
+		database, err := getDB(t)
+		assert.Nil(t, err)
+		defer database.Close()
+
+		rs := ptrace.NewResourceSpans()
+		rs.Resource().Attributes().PutStr("service.name", "checkout")
+		span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetTraceID(pcommon.TraceID{1})
+		span.SetSpanID(pcommon.SpanID{1})
+		span.SetName("GET /cart")
+
+		assert.Nil(t, database.InsertResourceSpans(t.Context(), rs))
+
+		spans, err := database.GetSpansForTrace(t.Context(), pcommon.TraceID{1}.String())
+		assert.Nil(t, err)
+		assert.Len(t, spans, 1)
+		assert.Equal(t, "checkout", spans[0].ServiceName)
+	})
+
+	t.Run("tells instances of a service apart", func(t *testing.T) {
+		database, err := getDB(t)
+		assert.Nil(t, err)
+		defer database.Close()
+
+		for i, attributes := range []map[string]any{
+			{"service.name": "checkout", "service.instance.id": "checkout-1"},
+			{"service.name": "checkout", "host.name": "node-2"},
+		} {
+			rs := ptrace.NewResourceSpans()
+			assert.Nil(t, rs.Resource().Attributes().FromRaw(attributes))
+			span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+			span.SetTraceID(pcommon.TraceID{1})
+			span.SetSpanID(pcommon.SpanID{byte(i + 1)})
+			span.SetStartTimestamp(pcommon.NewTimestampFromTime(time.Unix(int64(i), 0)))
+			assert.Nil(t, database.InsertResourceSpans(t.Context(), rs))
+		}
+
+		spans, err := database.GetSpansForTrace(t.Context(), pcommon.TraceID{1}.String())
+		assert.Nil(t, err)
+		assert.Len(t, spans, 2)
+		assert.Equal(t, "node-2", spans[0].ServiceInstance)
+		assert.Equal(t, "checkout-1", spans[1].ServiceInstance)
+		assert.NotEqual(t, spans[0].ResourceID, spans[1].ResourceID)
+	})
+}
+
+func TestGetSpansForRootSpanName(t *testing.T) {
+	database, err := getDB(t)
+	assert.Nil(t, err)
+	defer database.Close()
+
+	rs := ptrace.NewResourceSpans()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	spans := rs.ScopeSpans().AppendEmpty().Spans()
+	for i, s := range []struct {
+		traceID byte
+		parent  byte
+		name    string
+	}{
+		{1, 0, "GET /cart"},
+		{1, 1, "db"},
+		{2, 0, "GET /cart"},
+		{3, 0, "POST /cart"},
+	} {
+		span := spans.AppendEmpty()
+		span.SetTraceID(pcommon.TraceID{s.traceID})
+		span.SetSpanID(pcommon.SpanID{byte(i + 1)})
+		if s.parent != 0 {
+			span.SetParentSpanID(pcommon.SpanID{s.parent})
+		}
+		span.SetName(s.name)
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(time.Unix(int64(i), 0)))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(time.Unix(int64(i+1), 0)))
+	}
+	assert.Nil(t, database.InsertResourceSpans(t.Context(), rs))
+
+	t.Run("counts root span names", func(t *testing.T) {
+		names, err := database.GetRootSpanNames(t.Context())
+
+		assert.Nil(t, err)
+		assert.Equal(t, []db.SpanNameCount{{"GET /cart", 2}, {"POST /cart", 1}}, names)
+	})
+
+	t.Run("returns all spans of matching traces", func(t *testing.T) {
+		spans, err := database.GetSpansForRootSpanName(t.Context(), "GET /cart", 10)
+
+		assert.Nil(t, err)
+		assert.Len(t, spans, 3)
+	})
+
+	t.Run("limits the number of traces", func(t *testing.T) {
+		spans, err := database.GetSpansForRootSpanName(t.Context(), "GET /cart", 1)
+
+		assert.Nil(t, err)
+		assert.Len(t, spans, 1)
+		assert.Equal(t, pcommon.TraceID{2}.String(), spans[0].TraceID)
+	})
+}
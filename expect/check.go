@@ -0,0 +1,264 @@
+package expect
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fredrikaugust/otelly/db"
+)
+
+// maxClosest is how many of the spans closest to matching are shown
+const maxClosest = 3
+
+// Result is whether an expected span was found.
+type Result struct {
+	Expected Span
+	// Found is the most recent span which matched, if any
+	Found *db.Span
+	// Problems explain why no span matched, from the spans closest to
+	// matching
+	Problems []string
+}
+
+// Report has the result of each expected span.
+type Report []Result
+
+// OK is whether all the expected spans were found.
+func (r Report) OK() bool {
+	return !slices.ContainsFunc(r, func(result Result) bool { return result.Found == nil })
+}
+
+func (r Report) String() string {
+	var b strings.Builder
+	for _, result := range r {
+		if result.Found != nil {
+			fmt.Fprintf(&b, "✓ %s\n", result.Expected)
+			fmt.Fprintf(&b, "    found %s\n", describe(*result.Found))
+			continue
+		}
+
+		fmt.Fprintf(&b, "✗ %s\n", result.Expected)
+		for _, problem := range result.Problems {
+			fmt.Fprintf(&b, "    %s\n", problem)
+		}
+	}
+
+	return b.String()
+}
+
+// Check looks for each of the expected spans among the spans.
+func Check(spans []db.Span, expected []Span) Report {
+	t := newTree(spans)
+
+	all := make([]int, len(spans))
+	for i := range spans {
+		all[i] = i
+	}
+
+	report := make(Report, 0, len(expected))
+	for _, s := range expected {
+		result := Result{Expected: s}
+		if i := slices.IndexFunc(all, func(i int) bool { return t.matches(s, i) }); i != -1 {
+			result.Found = &spans[i]
+		} else if len(spans) == 0 {
+			result.Problems = []string{"no spans were received"}
+		} else {
+			result.Problems = t.explain(s, all)
+		}
+
+		report = append(report, result)
+	}
+
+	return report
+}
+
+// Wait checks the spans in the database every interval until all the
+// expected spans are found or the context is done, and returns the last
+// report.
+func Wait(ctx context.Context, database *db.Database, expected []Span, interval time.Duration) (Report, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Not ctx, so the last check is made after it's done
+		spans, err := database.GetSpans(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("could not get spans: %w", err)
+		}
+
+		report := Check(spans, expected)
+		if report.OK() {
+			return report, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return report, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// tree finds the spans below a span.
+type tree struct {
+	spans []db.Span
+	// children has the indices of the spans directly below each span, by
+	// trace and span ID
+	children map[string][]int
+}
+
+func newTree(spans []db.Span) tree {
+	t := tree{spans: spans, children: make(map[string][]int)}
+	for i, span := range spans {
+		if span.ParentSpanID.Valid {
+			parent := span.TraceID + "/" + span.ParentSpanID.String
+			t.children[parent] = append(t.children[parent], i)
+		}
+	}
+
+	return t
+}
+
+// below returns the indices of all spans below the spans.
+func (t tree) below(spans []int) []int {
+	found := make([]int, 0)
+	queue := slices.Clone(spans)
+	for len(queue) > 0 {
+		span := t.spans[queue[0]]
+		queue = queue[1:]
+
+		children := t.children[span.TraceID+"/"+span.ID]
+		found = append(found, children...)
+		queue = append(queue, children...)
+	}
+
+	return found
+}
+
+func (t tree) matches(s Span, i int) bool {
+	if len(s.differences(t.spans[i])) > 0 {
+		return false
+	}
+
+	for _, child := range s.Children {
+		if !slices.ContainsFunc(t.below([]int{i}), func(j int) bool { return t.matches(child, j) }) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// explain says why none of the candidates match, either by how the
+// closest ones differ, or by which spans are missing below those which
+// match.
+func (t tree) explain(s Span, candidates []int) []string {
+	matching := slices.DeleteFunc(slices.Clone(candidates), func(i int) bool {
+		return len(s.differences(t.spans[i])) > 0
+	})
+
+	if len(matching) == 0 {
+		// Spans with the expected name are closest, then those with the
+		// fewest differences
+		distance := func(i int) int {
+			d := len(s.differences(t.spans[i]))
+			if s.Name != "" && t.spans[i].Name != s.Name {
+				d += 100
+			}
+			return d
+		}
+		closest := slices.Clone(candidates)
+		slices.SortStableFunc(closest, func(a, b int) int { return distance(a) - distance(b) })
+
+		problems := make([]string, 0, maxClosest)
+		for _, i := range closest[:min(maxClosest, len(closest))] {
+			span := t.spans[i]
+			problems = append(problems, fmt.Sprintf("closest is %s, where %s", describe(span), strings.Join(s.differences(span), ", ")))
+		}
+
+		return problems
+	}
+
+	for _, child := range s.Children {
+		below := t.below(matching)
+		if slices.ContainsFunc(below, func(j int) bool { return t.matches(child, j) }) {
+			continue
+		}
+
+		problem := fmt.Sprintf("%s, but without %s below", count(len(matching)), child)
+		if len(below) == 0 {
+			return []string{problem + ", as there are no spans below"}
+		}
+
+		problems := []string{problem + ":"}
+		for _, p := range t.explain(child, below) {
+			problems = append(problems, "  "+p)
+		}
+
+		return problems
+	}
+
+	// Each span below is found, but not all below the same span
+	return []string{fmt.Sprintf("%s, but none with all of the spans below", count(len(matching)))}
+}
+
+// count says how many spans match.
+func count(n int) string {
+	if n == 1 {
+		return "1 span matches"
+	}
+
+	return fmt.Sprintf("%d spans match", n)
+}
+
+// differences lists how the span differs from the expected one, not
+// counting the spans below it.
+func (s Span) differences(span db.Span) []string {
+	differences := make([]string, 0)
+	if s.Name != "" && span.Name != s.Name {
+		differences = append(differences, fmt.Sprintf("name is %q", span.Name))
+	}
+	if s.Service != "" && span.ServiceName != s.Service {
+		differences = append(differences, fmt.Sprintf("service is %q", span.ServiceName))
+	}
+	if s.Status != "" && !strings.EqualFold(span.StatusCode, strings.TrimPrefix(strings.ToUpper(s.Status), "STATUS_CODE_")) {
+		differences = append(differences, "status is "+span.StatusCode)
+	}
+	if s.Kind != "" && !strings.EqualFold(span.Kind, strings.TrimPrefix(strings.ToUpper(s.Kind), "SPAN_KIND_")) {
+		differences = append(differences, "kind is "+span.Kind)
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(s.Attributes)) {
+		v, ok := span.Attributes[k]
+		if !ok {
+			differences = append(differences, k+" is missing")
+		} else if text := attributeText(v); text != s.Attributes[k] {
+			differences = append(differences, fmt.Sprintf("%s is %q", k, text))
+		}
+	}
+
+	return differences
+}
+
+// attributeText is the attribute value as it would be written in an
+// expression.
+func attributeText(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		// Not fmt, which writes large numbers with exponents
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func describe(span db.Span) string {
+	return fmt.Sprintf("%q from %s in trace %s", span.Name, span.ServiceName, span.TraceID)
+}
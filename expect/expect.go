@@ -0,0 +1,221 @@
+// Package expect checks that received spans match what's expected, e.g. a
+// span named "POST /orders" with a db.query span below it. It's used by
+// otelly assert to test instrumentation in CI.
+package expect
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Span matches spans by their fields, and the spans below them. Empty
+// fields match anything.
+type Span struct {
+	Name    string `yaml:"name"`
+	Service string `yaml:"service"`
+	// Status is Ok, Error or Unset, in any case
+	Status string `yaml:"status"`
+	// Kind is e.g. Server or Client, in any case
+	Kind string `yaml:"kind"`
+	// Attributes are compared to the attribute values as text, so 200
+	// matches both the number and the string
+	Attributes map[string]string `yaml:"attributes"`
+	// Children must each match a span anywhere below the span, not only
+	// directly below it
+	Children []Span `yaml:"children"`
+}
+
+// Parse reads an expression of space separated field=value pairs, where
+// the fields are name, service, status and kind, and any other field is an
+// attribute. A word without = is the name. > starts the span expected
+// below, e.g.
+//
+//	name="POST /orders" status=OK http.response.status_code=201 > db.query
+func Parse(expr string) (Span, error) {
+	words, err := split(expr)
+	if err != nil {
+		return Span{}, err
+	}
+
+	spans := []Span{{}}
+	for _, word := range words {
+		s := &spans[len(spans)-1]
+
+		if word == ">" {
+			if s.empty() {
+				return Span{}, fmt.Errorf("nothing to match before > in %q", expr)
+			}
+			spans = append(spans, Span{})
+			continue
+		}
+
+		field, value, ok := strings.Cut(word, "=")
+		if !ok {
+			field, value = "name", word
+		}
+		if field == "" {
+			return Span{}, fmt.Errorf("missing field before =%s in %q", value, expr)
+		}
+
+		switch field {
+		case "name":
+			s.Name = value
+		case "service", "service.name":
+			s.Service = value
+		case "status":
+			s.Status = value
+		case "kind":
+			s.Kind = value
+		default:
+			if s.Attributes == nil {
+				s.Attributes = make(map[string]string)
+			}
+			s.Attributes[field] = value
+		}
+	}
+
+	if spans[len(spans)-1].empty() {
+		return Span{}, fmt.Errorf("nothing to match in %q", expr)
+	}
+
+	// Nest each span below the one before it
+	for i := len(spans) - 1; i > 0; i-- {
+		spans[i-1].Children = append(spans[i-1].Children, spans[i])
+	}
+
+	return spans[0], nil
+}
+
+// split splits the expression into words, keeping text in double quotes
+// together, and > on its own.
+func split(expr string) ([]string, error) {
+	words := make([]string, 0)
+
+	var word strings.Builder
+	inWord, quoted := false, false
+	end := func() {
+		if inWord {
+			words = append(words, word.String())
+		}
+		word.Reset()
+		inWord = false
+	}
+
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case quoted:
+			word.WriteRune(r)
+		case r == ' ' || r == '\t' || r == '\n':
+			end()
+		case r == '>':
+			end()
+			words = append(words, ">")
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("missing closing quote in %q", expr)
+	}
+	end()
+
+	return words, nil
+}
+
+// UnmarshalYAML reads a span either as a mapping of its fields, or as an
+// expression for Parse.
+func (s *Span) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		span, err := Parse(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		*s = span
+		return nil
+	}
+
+	// The alias stops this method from being called again
+	type fields Span
+	return node.Decode((*fields)(s))
+}
+
+// Load reads the spans to expect from the file, e.g.
+//
+//	spans:
+//	  - name: POST /orders
+//	    status: ok
+//	    children:
+//	      - name: db.query
+//	  - service=shop name="GET /health"
+func Load(path string) ([]Span, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("could not find %s", path)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Spans []Span `yaml:"spans"`
+	}
+	if err := yaml.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	if len(file.Spans) == 0 {
+		return nil, fmt.Errorf("no spans expected in %s", path)
+	}
+
+	return file.Spans, nil
+}
+
+func (s Span) empty() bool {
+	return s.Name == "" && s.Service == "" && s.Status == "" && s.Kind == "" && len(s.Attributes) == 0 && len(s.Children) == 0
+}
+
+// String is the span as an expression for Parse. Several children, which
+// expressions can't have, are shown in parentheses.
+func (s Span) String() string {
+	words := make([]string, 0)
+	add := func(field, value string) {
+		if value == "" {
+			return
+		}
+		if strings.ContainsAny(value, " \t>") {
+			value = `"` + value + `"`
+		}
+		words = append(words, field+"="+value)
+	}
+
+	add("name", s.Name)
+	add("service", s.Service)
+	add("status", s.Status)
+	add("kind", s.Kind)
+	for _, k := range slices.Sorted(maps.Keys(s.Attributes)) {
+		add(k, s.Attributes[k])
+	}
+
+	switch len(s.Children) {
+	case 0:
+	case 1:
+		words = append(words, ">", s.Children[0].String())
+	default:
+		children := make([]string, 0, len(s.Children))
+		for _, c := range s.Children {
+			children = append(children, c.String())
+		}
+		words = append(words, ">", "("+strings.Join(children, ", ")+")")
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,172 @@
+package expect_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/expect"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("reads fields, attributes and spans below", func(t *testing.T) {
+		s, err := expect.Parse(`name="POST /orders" status=OK http.response.status_code=201 > service=db db.query`)
+
+		assert.Nil(t, err)
+		assert.Equal(t, expect.Span{
+			Name:       "POST /orders",
+			Status:     "OK",
+			Attributes: map[string]string{"http.response.status_code": "201"},
+			Children:   []expect.Span{{Name: "db.query", Service: "db"}},
+		}, s)
+		assert.Equal(t, `name="POST /orders" status=OK http.response.status_code=201 > name=db.query service=db`, s.String())
+	})
+
+	for _, expr := range []string{"", "> db.query", "GET >", `name="GET`, "=GET"} {
+		t.Run("fails on "+expr, func(t *testing.T) {
+			_, err := expect.Parse(expr)
+
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "expect.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte(`spans:
+  - name: POST /orders
+    kind: server
+    attributes:
+      http.response.status_code: 201
+    children:
+      - name: db.query
+  - service=shop "GET /health"
+`), 0o644))
+
+	spans, err := expect.Load(path)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []expect.Span{
+		{
+			Name:       "POST /orders",
+			Kind:       "server",
+			Attributes: map[string]string{"http.response.status_code": "201"},
+			Children:   []expect.Span{{Name: "db.query"}},
+		},
+		{Name: "GET /health", Service: "shop"},
+	}, spans)
+
+	_, err = expect.Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.NotNil(t, err)
+}
+
+func TestCheck(t *testing.T) {
+	span := func(id, parent, name, status string, attributes map[string]any) db.Span {
+		return db.Span{
+			TraceID:      "t1",
+			ID:           id,
+			ParentSpanID: sql.NullString{String: parent, Valid: parent != ""},
+			Name:         name,
+			Kind:         "Server",
+			StatusCode:   status,
+			Attributes:   attributes,
+			ServiceName:  "shop",
+		}
+	}
+	spans := []db.Span{
+		span("1", "", "POST /orders", "Ok", map[string]any{"http.response.status_code": 201.0}),
+		span("2", "1", "reserve", "Unset", nil),
+		span("3", "2", "db.query", "Ok", nil),
+		span("4", "", "GET /health", "Error", nil),
+	}
+
+	check := func(expr string) expect.Result {
+		s, err := expect.Parse(expr)
+		assert.Nil(t, err)
+
+		report := expect.Check(spans, []expect.Span{s})
+		assert.Len(t, report, 1)
+
+		return report[0]
+	}
+
+	t.Run("finds spans anywhere below", func(t *testing.T) {
+		result := check("name=\"POST /orders\" status=OK kind=SPAN_KIND_SERVER http.response.status_code=201 > db.query")
+
+		assert.Equal(t, &spans[0], result.Found)
+		assert.Empty(t, result.Problems)
+	})
+
+	t.Run("shows how the closest spans differ", func(t *testing.T) {
+		result := check(`"GET /health" status=ok`)
+
+		assert.Nil(t, result.Found)
+		assert.Equal(t, `closest is "GET /health" from shop in trace t1, where status is Error`, result.Problems[0])
+		assert.Len(t, result.Problems, 3)
+	})
+
+	t.Run("shows which spans are missing below", func(t *testing.T) {
+		result := check(`"POST /orders" > reserve > cache.get`)
+
+		assert.Nil(t, result.Found)
+		assert.Equal(t, []string{
+			"1 span matches, but without name=reserve > name=cache.get below:",
+			"  1 span matches, but without name=cache.get below:",
+			`    closest is "db.query" from shop in trace t1, where name is "db.query"`,
+		}, result.Problems)
+	})
+
+	t.Run("reports each span", func(t *testing.T) {
+		report := expect.Check(spans, []expect.Span{{Name: "reserve"}, {Name: "GET /missing", Status: "Ok"}})
+
+		assert.False(t, report.OK())
+		assert.Contains(t, report.String(), "✓ name=reserve\n    found \"reserve\" from shop in trace t1\n")
+		assert.Contains(t, report.String(), "✗ name=\"GET /missing\" status=Ok\n    closest is")
+		assert.True(t, report[:1].OK())
+	})
+}
+
+func TestWait(t *testing.T) {
+	database, err := db.NewDB(":memory:")
+	assert.Nil(t, err)
+	defer database.Close()
+	assert.Nil(t, database.Migrate(t.Context()))
+
+	expected := []expect.Span{{Name: "GET /orders"}}
+
+	t.Run("gives up when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+
+		report, err := expect.Wait(ctx, database, expected, 10*time.Millisecond)
+
+		assert.Nil(t, err)
+		assert.False(t, report.OK())
+		assert.Equal(t, []string{"no spans were received"}, report[0].Problems)
+	})
+
+	t.Run("returns once the spans are found", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+
+			rs := ptrace.NewResourceSpans()
+			span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+			span.SetTraceID(pcommon.TraceID{1})
+			span.SetSpanID(pcommon.SpanID{1})
+			span.SetName("GET /orders")
+			assert.Nil(t, database.InsertResourceSpans(t.Context(), rs))
+		}()
+
+		report, err := expect.Wait(t.Context(), database, expected, 10*time.Millisecond)
+
+		assert.Nil(t, err)
+		assert.True(t, report.OK())
+	})
+}
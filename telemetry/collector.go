@@ -2,19 +2,56 @@ package telemetry
 
 import (
 	"context"
+	_ "embed"
+	"fmt"
 	"log/slog"
+	"strconv"
+	"time"
 
 	"github.com/fredrikaugust/otelly/bus"
 	"github.com/fredrikaugust/otelly/db"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/confmap"
-	"go.opentelemetry.io/collector/confmap/provider/fileprovider"
 	"go.opentelemetry.io/collector/otelcol"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-func Start(ctx context.Context, bus *bus.TransportBus, db *db.Database) error {
+// config is the collector config, built in so otelly can be run from any
+// directory
+//
+//go:embed config.yml
+var config []byte
+
+// Options change what the collector does besides storing what it
+// receives.
+type Options struct {
+	// Forward are upstream endpoints which also get everything received
+	Forward []Forward
+	// Zipkin receives spans in the Zipkin format on ZipkinEndpoint
+	Zipkin bool
+	// Jaeger receives spans from Jaeger clients on JaegerHTTPEndpoint and
+	// JaegerGRPCEndpoint
+	Jaeger bool
+	// Scrape are Prometheus endpoints whose metrics are scraped, as given
+	// to ParseScrapeTarget
+	Scrape []string
+	// ScrapeInterval is how often they're scraped, DefaultScrapeInterval
+	// if it's zero
+	ScrapeInterval time.Duration
+	// RemoteWrite receives Prometheus remote write 2.0 on
+	// RemoteWriteEndpoint
+	RemoteWrite bool
+	// LogFiles are globs of log files to tail, e.g. /var/log/app/*.log
+	LogFiles []string
+	// Syslog receives syslog messages on SyslogEndpoint
+	Syslog bool
+	// LogFormat is how lines of log files and syslog messages are parsed,
+	// as given to ValidateLogFormat
+	LogFormat string
+}
+
+func Start(ctx context.Context, bus *bus.TransportBus, db *db.Database, options Options) error {
 	slog.Info("starting collector")
 	col, err := otelcol.NewCollector(otelcol.CollectorSettings{
 		Factories: func() (otelcol.Factories, error) {
@@ -28,9 +65,12 @@ func Start(ctx context.Context, bus *bus.TransportBus, db *db.Database) error {
 		},
 		ConfigProviderSettings: otelcol.ConfigProviderSettings{
 			ResolverSettings: confmap.ResolverSettings{
-				URIs: []string{"./telemetry/config.yml"},
+				// The options are merged over config.yml
+				URIs: []string{"otelly:config.yml", "otelly:options"},
 				ProviderFactories: []confmap.ProviderFactory{
-					fileprovider.NewFactory(),
+					confmap.NewProviderFactory(func(confmap.ProviderSettings) confmap.Provider {
+						return configProvider{options}
+					}),
 				},
 			},
 		},
@@ -41,3 +81,116 @@ func Start(ctx context.Context, bus *bus.TransportBus, db *db.Database) error {
 
 	return col.Run(ctx)
 }
+
+// configProvider provides config.yml and the config for the options.
+type configProvider struct {
+	options Options
+}
+
+func (p configProvider) Retrieve(_ context.Context, uri string, _ confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	switch uri {
+	case "otelly:config.yml":
+		return confmap.NewRetrievedFromYAML(config)
+	case "otelly:options":
+		options, err := p.options.config()
+		if err != nil {
+			return nil, err
+		}
+		return confmap.NewRetrieved(options)
+	default:
+		return nil, fmt.Errorf("unknown config %q", uri)
+	}
+}
+
+func (configProvider) Scheme() string {
+	return "otelly"
+}
+
+func (configProvider) Shutdown(context.Context) error {
+	return nil
+}
+
+// config is the collector config for the options, which is merged over
+// config.yml. Lists replace those in config.yml rather than being added
+// to.
+func (o Options) config() (map[string]any, error) {
+	receivers := make(map[string]any)
+	tracesReceivers := []any{"otlp"}
+	if o.Zipkin {
+		receivers[zipkinReceiverName] = map[string]any{"endpoint": ZipkinEndpoint}
+		tracesReceivers = append(tracesReceivers, zipkinReceiverName)
+	}
+	if o.Jaeger {
+		receivers[jaegerReceiverName] = map[string]any{
+			"protocols": map[string]any{
+				"grpc":        map[string]any{"endpoint": JaegerGRPCEndpoint},
+				"thrift_http": map[string]any{"endpoint": JaegerHTTPEndpoint},
+			},
+		}
+		tracesReceivers = append(tracesReceivers, jaegerReceiverName)
+	}
+
+	metricsReceivers := []any{"otlp"}
+	if len(o.Scrape) > 0 {
+		jobs, err := scrapeJobs(o.Scrape)
+		if err != nil {
+			return nil, err
+		}
+		interval := o.ScrapeInterval
+		if interval == 0 {
+			interval = DefaultScrapeInterval
+		}
+		receivers[prometheusReceiverName] = scrapeConfig(jobs, interval)
+		metricsReceivers = append(metricsReceivers, prometheusReceiverName)
+	}
+	if o.RemoteWrite {
+		receivers[remoteWriteReceiverName] = map[string]any{"endpoint": RemoteWriteEndpoint}
+		metricsReceivers = append(metricsReceivers, remoteWriteReceiverName)
+	}
+
+	logsReceivers := []any{"otlp"}
+	if len(o.LogFiles) > 0 {
+		include := make([]any, 0, len(o.LogFiles))
+		for _, glob := range o.LogFiles {
+			include = append(include, glob)
+		}
+		receivers[fileLogReceiverName] = map[string]any{"include": include, "format": o.LogFormat}
+		logsReceivers = append(logsReceivers, fileLogReceiverName)
+	}
+	if o.Syslog {
+		for id, config := range syslogConfigs(o.LogFormat) {
+			receivers[id] = config
+			logsReceivers = append(logsReceivers, id)
+		}
+	}
+
+	exporters := make(map[string]any)
+	extensions := make(map[string]any)
+	forwards := make([]any, 0, len(o.Forward))
+	statuses := make([]any, 0, len(o.Forward))
+	for i, f := range o.Forward {
+		name := strconv.Itoa(i + 1)
+		status := forwardStatusExtensionName + "/" + name
+		extensions[status] = map[string]any{"endpoint": f.statusEndpoint()}
+		statuses = append(statuses, status)
+
+		id, config := f.exporter(name, status)
+		exporters[id] = config
+		forwards = append(forwards, id)
+	}
+	all := append([]any{ExporterName}, forwards...)
+
+	return map[string]any{
+		"receivers":  receivers,
+		"exporters":  exporters,
+		"extensions": extensions,
+		"service": map[string]any{
+			"extensions": statuses,
+			"pipelines": map[string]any{
+				"traces":  map[string]any{"receivers": tracesReceivers, "exporters": all},
+				"logs":    map[string]any{"receivers": logsReceivers, "exporters": all},
+				"metrics": map[string]any{"receivers": metricsReceivers, "exporters": all},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,266 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fredrikaugust/otelly/bus"
+	"github.com/fredrikaugust/otelly/db"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+const (
+	// HTTPEndpoint is where the OTLP/HTTP receiver in config.yml listens
+	HTTPEndpoint = "localhost:4318"
+
+	// outputFlushInterval is how often lines of output are stored as logs
+	outputFlushInterval = 250 * time.Millisecond
+	// outputBatchSize is the most lines stored at once
+	outputBatchSize = 500
+	// maxLineLength is the longest line of output kept whole
+	maxLineLength = 1024 * 1024
+)
+
+// Command is a process whose telemetry is sent to otelly, and whose output
+// is stored as logs.
+type Command struct {
+	cmd         *exec.Cmd
+	serviceName string
+
+	// stdout and stderr are written to by the command until it exits
+	stdout *io.PipeWriter
+	stderr *io.PipeWriter
+	// output is done when all output has been stored
+	output sync.WaitGroup
+}
+
+// CommandEnv adds the environment variables which make OpenTelemetry SDKs
+// export to otelly. Variables already set are left alone, so e.g. a
+// service name can still be given.
+func CommandEnv(environ []string, serviceName string) []string {
+	defaults := map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "http://" + HTTPEndpoint,
+		"OTEL_EXPORTER_OTLP_PROTOCOL": "http/protobuf",
+		"OTEL_SERVICE_NAME":           serviceName,
+		"OTEL_TRACES_EXPORTER":        "otlp",
+		"OTEL_LOGS_EXPORTER":          "otlp",
+		"OTEL_METRICS_EXPORTER":       "otlp",
+		// Show spans soon after they end rather than every 5 seconds
+		"OTEL_BSP_SCHEDULE_DELAY":  "500",
+		"OTEL_BLRP_SCHEDULE_DELAY": "500",
+	}
+
+	// Added to rather than replaced, so runs can be told apart
+	attributes := "otelly.run=true"
+
+	env := make([]string, 0, len(environ)+len(defaults)+1)
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		delete(defaults, name)
+
+		if name != "OTEL_RESOURCE_ATTRIBUTES" {
+			env = append(env, kv)
+		} else if value != "" {
+			attributes = value + "," + attributes
+		}
+	}
+
+	for name, value := range defaults {
+		env = append(env, name+"="+value)
+	}
+
+	return append(env, "OTEL_RESOURCE_ATTRIBUTES="+attributes)
+}
+
+// StartCommand starts the command with its stdout and stderr stored as
+// logs from a service named after it, unless OTEL_SERVICE_NAME is set. The
+// command is interrupted when the context is done.
+func StartCommand(ctx context.Context, args []string, bus *bus.TransportBus, db *db.Database) (*Command, error) {
+	if len(args) == 0 {
+		return nil, errors.New("no command to run")
+	}
+
+	c := &Command{serviceName: filepath.Base(args[0])}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		c.serviceName = name
+	}
+
+	c.cmd = exec.CommandContext(ctx, args[0], args[1:]...)
+	c.cmd.Env = CommandEnv(os.Environ(), c.serviceName)
+	c.cmd.Cancel = func() error {
+		return c.cmd.Process.Signal(os.Interrupt)
+	}
+	c.cmd.WaitDelay = 5 * time.Second
+
+	// Not StdoutPipe, as Wait closes it before it's been read, and this way
+	// Wait only waits WaitDelay for processes started by the command which
+	// hold on to its output
+	stdout, stdoutWriter := io.Pipe()
+	stderr, stderrWriter := io.Pipe()
+	c.cmd.Stdout, c.stdout = stdoutWriter, stdoutWriter
+	c.cmd.Stderr, c.stderr = stderrWriter, stderrWriter
+
+	if err := c.cmd.Start(); err != nil {
+		return nil, err
+	}
+	zap.L().Info("started command", zap.Strings("args", args), zap.Int("pid", c.cmd.Process.Pid))
+
+	resource := pcommon.NewResource()
+	resource.Attributes().PutStr("service.name", c.serviceName)
+	resource.Attributes().PutStr("process.command_line", strings.Join(args, " "))
+	resource.Attributes().PutInt("process.pid", int64(c.cmd.Process.Pid))
+
+	lines := make(chan outputLine)
+	var readers sync.WaitGroup
+	readers.Go(func() { readLines(stdout, "stdout", lines) })
+	readers.Go(func() { readLines(stderr, "stderr", lines) })
+	go func() {
+		readers.Wait()
+		close(lines)
+	}()
+
+	c.output.Go(func() {
+		// Output is stored after the command is interrupted, hence not ctx
+		storeOutput(context.WithoutCancel(ctx), resource, lines, bus, db)
+	})
+
+	return c, nil
+}
+
+// ServiceName is the service the output of the command is stored as.
+func (c *Command) ServiceName() string {
+	return c.serviceName
+}
+
+// Wait waits for the command to exit and all its output to be stored.
+func (c *Command) Wait() error {
+	err := c.cmd.Wait()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// The command exited fine, but a process it started kept its
+		// output open
+		zap.L().Info("command's output is still open after it exited", zap.Error(err))
+		err = nil
+	}
+
+	c.stdout.Close()
+	c.stderr.Close()
+	c.output.Wait()
+
+	return err
+}
+
+type outputLine struct {
+	stream string
+	text   string
+	time   time.Time
+}
+
+func readLines(r io.Reader, stream string, lines chan<- outputLine) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+	for scanner.Scan() {
+		lines <- outputLine{stream, scanner.Text(), time.Now()}
+	}
+
+	if err := scanner.Err(); err != nil {
+		zap.L().Warn("could not read command output", zap.String("stream", stream), zap.Error(err))
+		// Drain the rest so the command doesn't block on a full pipe
+		_, _ = io.Copy(io.Discard, r)
+	}
+}
+
+// storeOutput stores the lines as logs in batches, until there are no
+// more lines.
+func storeOutput(ctx context.Context, resource pcommon.Resource, lines <-chan outputLine, bus *bus.TransportBus, db *db.Database) {
+	ticker := time.NewTicker(outputFlushInterval)
+	defer ticker.Stop()
+
+	// Tells JSON and logfmt apart by line, which can't fail
+	parser, _ := newLogParser("")
+
+	batch := make([]outputLine, 0, outputBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := logReceiver(ctx, outputLogs(resource, batch, parser), bus, db); err != nil {
+			zap.L().Warn("could not store command output", zap.Error(err))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				flush()
+				return
+			}
+
+			batch = append(batch, line)
+			if len(batch) >= outputBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// outputLogs makes a log record of each line, parsed like a line of a log
+// file. Lines only have a severity when they say so themselves, as plenty of
+// commands print more than errors to stderr.
+func outputLogs(resource pcommon.Resource, lines []outputLine, parser *logParser) plog.Logs {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	resource.CopyTo(rl.Resource())
+
+	records := rl.ScopeLogs().AppendEmpty().LogRecords()
+	for _, line := range lines {
+		record := records.AppendEmpty()
+		record.SetTimestamp(pcommon.NewTimestampFromTime(line.time))
+		record.SetObservedTimestamp(pcommon.NewTimestampFromTime(line.time))
+		parser.parse(line.text, record)
+		record.Attributes().PutStr("log.iostream", line.stream)
+	}
+
+	return logs
+}
+
+// WaitForReceiver waits until the receiver accepts connections, so the
+// first exports of a command aren't refused.
+func WaitForReceiver(ctx context.Context) error {
+	return waitForListener(ctx, HTTPEndpoint)
+}
+
+func waitForListener(ctx context.Context, addr string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var dialer net.Dialer
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return conn.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("receiver isn't listening on %s: %w", addr, err)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
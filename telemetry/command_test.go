@@ -0,0 +1,112 @@
+package telemetry_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/bus"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/telemetry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandEnv(t *testing.T) {
+	t.Run("points the SDK at otelly", func(t *testing.T) {
+		env := telemetry.CommandEnv([]string{"PATH=/bin"}, "shop")
+
+		assert.Contains(t, env, "PATH=/bin")
+		assert.Contains(t, env, "OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4318")
+		assert.Contains(t, env, "OTEL_EXPORTER_OTLP_PROTOCOL=http/protobuf")
+		assert.Contains(t, env, "OTEL_SERVICE_NAME=shop")
+		assert.Contains(t, env, "OTEL_RESOURCE_ATTRIBUTES=otelly.run=true")
+	})
+
+	t.Run("keeps what's already set", func(t *testing.T) {
+		env := telemetry.CommandEnv([]string{
+			"OTEL_SERVICE_NAME=checkout",
+			"OTEL_METRICS_EXPORTER=none",
+			"OTEL_RESOURCE_ATTRIBUTES=deployment.environment=dev",
+		}, "shop")
+
+		assert.Contains(t, env, "OTEL_SERVICE_NAME=checkout")
+		assert.NotContains(t, env, "OTEL_SERVICE_NAME=shop")
+		assert.Contains(t, env, "OTEL_METRICS_EXPORTER=none")
+		assert.NotContains(t, env, "OTEL_METRICS_EXPORTER=otlp")
+		assert.Contains(t, env, "OTEL_RESOURCE_ATTRIBUTES=deployment.environment=dev,otelly.run=true")
+	})
+}
+
+func TestStartCommand(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "")
+
+	database, err := db.NewDB(":memory:")
+	assert.Nil(t, err)
+	defer database.Close()
+	assert.Nil(t, database.Migrate(t.Context()))
+
+	b := bus.NewTransportBus()
+	go func() {
+		for range b.LogBus {
+		}
+	}()
+
+	script := `echo listening on :8080; echo compiling >&2; echo 'level=error msg=boom' >&2; exit 3`
+	cmd, err := telemetry.StartCommand(t.Context(), []string{"sh", "-c", script}, b, database)
+	assert.Nil(t, err)
+	assert.Equal(t, "sh", cmd.ServiceName())
+	assert.NotNil(t, cmd.Wait())
+
+	logs, err := database.GetLogs(t.Context())
+	assert.Nil(t, err)
+	assert.Len(t, logs, 3)
+
+	slices.SortFunc(logs, func(a, b db.Log) int { return strings.Compare(a.Body, b.Body) })
+	assert.Equal(t, "boom", logs[0].Body)
+	assert.Equal(t, 17, logs[0].SeverityNumber)
+	assert.Equal(t, "stderr", logs[0].Attributes["log.iostream"])
+
+	// stderr isn't only errors
+	assert.Equal(t, "compiling", logs[1].Body)
+	assert.Equal(t, 0, logs[1].SeverityNumber)
+	assert.Equal(t, "stderr", logs[1].Attributes["log.iostream"])
+
+	assert.Equal(t, "listening on :8080", logs[2].Body)
+	assert.Equal(t, 0, logs[2].SeverityNumber)
+	assert.Equal(t, "sh", logs[2].ServiceName)
+	assert.Equal(t, "stdout", logs[2].Attributes["log.iostream"])
+}
+
+func TestCommandWaitWithOutputHeldOpen(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "")
+
+	database, err := db.NewDB(":memory:")
+	assert.Nil(t, err)
+	defer database.Close()
+	assert.Nil(t, database.Migrate(t.Context()))
+
+	b := bus.NewTransportBus()
+	go func() {
+		for range b.LogBus {
+		}
+	}()
+
+	// The sleep keeps stdout open after sh exits
+	cmd, err := telemetry.StartCommand(t.Context(), []string{"sh", "-c", "echo started; sleep 30 &"}, b, database)
+	assert.Nil(t, err)
+
+	waited := make(chan error)
+	go func() { waited <- cmd.Wait() }()
+
+	select {
+	case err := <-waited:
+		assert.Nil(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("still waiting for the output of the sleep")
+	}
+
+	logs, err := database.GetLogs(t.Context())
+	assert.Nil(t, err)
+	assert.Len(t, logs, 1)
+}
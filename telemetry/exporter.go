@@ -12,6 +12,7 @@ import (
 	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/exporter/exporterhelper"
 	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.uber.org/zap"
 )
@@ -34,6 +35,7 @@ func createOtellyExporter(bus *bus.TransportBus, db *db.Database) exporter.Facto
 		},
 		exporter.WithTraces(createTraces, component.StabilityLevelDevelopment),
 		exporter.WithLogs(createLogs, component.StabilityLevelDevelopment),
+		exporter.WithMetrics(createMetrics, component.StabilityLevelDevelopment),
 	)
 }
 
@@ -65,6 +67,19 @@ func createLogs(ctx context.Context, set exporter.Settings, cfg component.Config
 	)
 }
 
+func createMetrics(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Metrics, error) {
+	return exporterhelper.NewMetrics(
+		ctx,
+		set,
+		cfg,
+		func(ctx context.Context, md pmetric.Metrics) error {
+			db := cfg.(*traceConfig).db
+
+			return metricReceiver(ctx, md, db)
+		},
+	)
+}
+
 func traceReceiver(ctx context.Context, td ptrace.Traces, bus *bus.TransportBus, db *db.Database) error {
 	var wg sync.WaitGroup
 
@@ -124,3 +139,24 @@ func logReceiver(ctx context.Context, ld plog.Logs, bus *bus.TransportBus, db *d
 		return errors.New("log receiver timed out after 1 second")
 	}
 }
+
+// metricReceiver stores the metrics. Nothing shows them yet, so they're not
+// sent on the bus.
+func metricReceiver(ctx context.Context, md pmetric.Metrics, db *db.Database) error {
+	var wg sync.WaitGroup
+
+	zap.L().Debug("received metrics", zap.Int("dataPointCount", md.DataPointCount()))
+
+	for _, resourceMetrics := range md.ResourceMetrics().All() {
+		wg.Go(func() {
+			err := db.InsertResourceMetrics(ctx, resourceMetrics)
+			if err != nil {
+				zap.L().Warn("could not insert resource metrics", zap.Error(err))
+			}
+		})
+	}
+
+	wg.Wait()
+
+	return nil
+}
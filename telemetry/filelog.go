@@ -0,0 +1,105 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/receiver"
+)
+
+const fileLogReceiverName = "filelog"
+
+type fileLogConfig struct {
+	// Config has the files to tail as include, and where to start reading
+	// them as start_at, which is the end of files there already
+	fileconsumer.Config `mapstructure:",squash"`
+	// Format is how lines are parsed, as given to ValidateLogFormat
+	Format string `mapstructure:"format"`
+}
+
+func (c *fileLogConfig) Validate() error {
+	if len(c.Include) == 0 {
+		return errors.New("no files to tail")
+	}
+
+	return ValidateLogFormat(c.Format)
+}
+
+// createFileLogReceiver tails plain text and JSON log files, so processes
+// which only log to files show up next to the others.
+func createFileLogReceiver() receiver.Factory {
+	return receiver.NewFactory(
+		component.MustNewType(fileLogReceiverName),
+		func() component.Config {
+			config := fileLogConfig{Config: *fileconsumer.NewConfig()}
+			config.IncludeFilePath = true
+			return &config
+		},
+		receiver.WithLogs(
+			func(ctx context.Context, set receiver.Settings, cfg component.Config, next consumer.Logs) (receiver.Logs, error) {
+				config := cfg.(*fileLogConfig)
+				parser, err := newLogParser(config.Format)
+				if err != nil {
+					return nil, err
+				}
+
+				manager, err := config.Build(set.TelemetrySettings, func(ctx context.Context, tokens [][]byte, attributes map[string]any, _ int64, _ []int64) error {
+					return next.ConsumeLogs(ctx, fileLogs(tokens, attributes, parser, time.Now()))
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				return &fileLogReceiver{manager: manager}, nil
+			},
+			component.StabilityLevelDevelopment,
+		),
+	)
+}
+
+type fileLogReceiver struct {
+	manager *fileconsumer.Manager
+}
+
+func (r *fileLogReceiver) Start(context.Context, component.Host) error {
+	// Where files were read to isn't kept, as otelly starts afresh
+	return r.manager.Start(nil)
+}
+
+func (r *fileLogReceiver) Shutdown(context.Context) error {
+	return r.manager.Stop()
+}
+
+// fileLogs converts lines read from a file to OTLP, with the file as the
+// service named after it, e.g. api for /var/log/api.log.
+func fileLogs(lines [][]byte, attributes map[string]any, parser *logParser, now time.Time) plog.Logs {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+
+	name, _ := attributes[attrs.LogFileName].(string)
+	rl.Resource().Attributes().PutStr("service.name", strings.TrimSuffix(name, filepath.Ext(name)))
+	for key, value := range attributes {
+		if s, ok := value.(string); ok {
+			rl.Resource().Attributes().PutStr(key, s)
+		}
+	}
+
+	records := rl.ScopeLogs().AppendEmpty().LogRecords()
+	for _, line := range lines {
+		record := records.AppendEmpty()
+		record.SetObservedTimestamp(pcommon.NewTimestampFromTime(now))
+		record.SetTimestamp(pcommon.NewTimestampFromTime(now))
+		parser.parse(string(line), record)
+	}
+
+	return logs
+}
@@ -0,0 +1,115 @@
+package telemetry_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/telemetry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLogFormat(t *testing.T) {
+	for _, format := range []string{"", "json", "logfmt", `(?P<level>\w+) (?P<msg>.*)`} {
+		t.Run("accepts "+format, func(t *testing.T) {
+			assert.Nil(t, telemetry.ValidateLogFormat(format))
+		})
+	}
+
+	for _, format := range []string{`(\w+) (.*)`, `(?P<msg>.*`} {
+		t.Run("rejects "+format, func(t *testing.T) {
+			assert.NotNil(t, telemetry.ValidateLogFormat(format))
+		})
+	}
+}
+
+// waitForLogs returns the stored logs once there are n of them.
+func waitForLogs(t *testing.T, database *db.Database, n int) []db.Log {
+	t.Helper()
+
+	var logs []db.Log
+	assert.Eventually(t, func() bool {
+		var err error
+		logs, err = database.GetLogs(t.Context())
+		return err == nil && len(logs) >= n
+	}, 5*time.Second, 50*time.Millisecond)
+
+	return logs
+}
+
+// findLog returns the log with the body.
+func findLog(logs []db.Log, body string) db.Log {
+	for _, l := range logs {
+		if l.Body == body {
+			return l
+		}
+	}
+
+	return db.Log{}
+}
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	for _, line := range lines {
+		_, err := f.WriteString(line + "\n")
+		assert.Nil(t, err)
+	}
+}
+
+func TestFileLog(t *testing.T) {
+	dir := t.TempDir()
+	appendLines(t, filepath.Join(dir, "worker.log"), "before otelly started")
+
+	database, _ := startCollector(t, telemetry.Options{LogFiles: []string{filepath.Join(dir, "*.log")}})
+
+	// Files found the first time the receiver looks are read from where
+	// they end, so new lines are written once it has
+	time.Sleep(time.Second)
+	appendLines(t, filepath.Join(dir, "worker.log"), "after otelly started")
+	appendLines(t, filepath.Join(dir, "api.log"),
+		`{"level":"warn","msg":"disk almost full","time":"2025-01-01T12:00:00Z","disk":"/dev/sda1","used":0.93}`,
+		`level=error msg="could not connect" trace_id=0af7651916cd43dd8448eb211c80319c span_id=b7ad6b7169203331 retries=3`,
+		"listening on :8080",
+	)
+
+	logs := waitForLogs(t, database, 4)
+
+	t.Run("reads files there already from where they end", func(t *testing.T) {
+		assert.Len(t, logs, 4)
+		assert.Equal(t, "worker", findLog(logs, "after otelly started").ServiceName)
+	})
+
+	t.Run("parses JSON lines", func(t *testing.T) {
+		l := findLog(logs, "disk almost full")
+
+		assert.Equal(t, "api", l.ServiceName)
+		assert.Equal(t, 13, l.SeverityNumber)
+		assert.Equal(t, "warn", l.SeverityText)
+		assert.True(t, time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC).Equal(l.Timestamp))
+		assert.Equal(t, map[string]any{"disk": "/dev/sda1", "used": 0.93}, l.Attributes)
+	})
+
+	t.Run("parses logfmt lines", func(t *testing.T) {
+		l := findLog(logs, "could not connect")
+
+		assert.Equal(t, 17, l.SeverityNumber)
+		assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", l.TraceID.String)
+		assert.Equal(t, "b7ad6b7169203331", l.SpanID.String)
+		assert.Equal(t, map[string]any{"retries": "3"}, l.Attributes)
+	})
+
+	t.Run("keeps other lines as they are", func(t *testing.T) {
+		l := findLog(logs, "listening on :8080")
+
+		assert.Equal(t, "api", l.ServiceName)
+		assert.Equal(t, 0, l.SeverityNumber)
+		assert.Empty(t, l.Attributes)
+	})
+}
@@ -0,0 +1,199 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fredrikaugust/otelly/bus"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+const forwardStatusExtensionName = "forwardstatus"
+
+// Forward is an upstream OTLP endpoint, e.g. a shared collector, which
+// everything received is sent on to.
+type Forward struct {
+	// Endpoint is a URL whose scheme is the protocol: grpc for OTLP/gRPC,
+	// http for OTLP/HTTP, and grpcs or https for them over TLS
+	Endpoint string
+	// Headers are sent with each export, e.g. for authentication
+	Headers map[string]string
+
+	// CAFile verifies the endpoint's certificate instead of the system's
+	// certificate authorities
+	CAFile string
+	// CertFile and KeyFile are the client certificate for mutual TLS
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify accepts any certificate from the endpoint
+	InsecureSkipVerify bool
+}
+
+// ParseForward checks that the endpoint is a URL with a known protocol.
+func ParseForward(endpoint string) (Forward, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return Forward{}, fmt.Errorf("%q isn't a URL like https://collector:4318", endpoint)
+	}
+
+	switch u.Scheme {
+	case "grpc", "grpcs", "http", "https":
+		return Forward{Endpoint: endpoint}, nil
+	default:
+		return Forward{}, fmt.Errorf("can't forward to %q, the protocol must be grpc, grpcs, http or https", endpoint)
+	}
+}
+
+// exporter is the ID and config of the otlp or otlphttp exporter which
+// sends to the endpoint, e.g. otlp/1, as it would be written in config.yml.
+// The exporter tells the UI how forwarding goes through the status
+// extension.
+func (f Forward) exporter(name string, status string) (string, map[string]any) {
+	u, _ := url.Parse(f.Endpoint)
+
+	headers := make(map[string]any, len(f.Headers))
+	for k, v := range f.Headers {
+		headers[k] = v
+	}
+	tls := map[string]any{
+		"insecure":             u.Scheme == "grpc" || u.Scheme == "http",
+		"insecure_skip_verify": f.InsecureSkipVerify,
+		"ca_file":              f.CAFile,
+		"cert_file":            f.CertFile,
+		"key_file":             f.KeyFile,
+	}
+	middlewares := []any{map[string]any{"id": status}}
+
+	if u.Scheme == "grpc" || u.Scheme == "grpcs" {
+		return "otlp/" + name, map[string]any{"endpoint": u.Host, "headers": headers, "tls": tls, "middlewares": middlewares}
+	}
+
+	return "otlphttp/" + name, map[string]any{"endpoint": u.String(), "headers": headers, "tls": tls, "middlewares": middlewares}
+}
+
+// statusEndpoint is the endpoint as it's shown in the UI.
+func (f Forward) statusEndpoint() string {
+	u, _ := url.Parse(f.Endpoint)
+	if u.Scheme == "grpc" || u.Scheme == "grpcs" {
+		return u.Host
+	}
+
+	return u.String()
+}
+
+type forwardStatusConfig struct {
+	// Endpoint is what the exporter sends to, as it's shown in the UI
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// createForwardStatusExtension is a middleware of the otlp and otlphttp
+// exporters which forward, so the UI can be told when forwarding fails. It
+// sees every attempt to export, including retries, while the exporters
+// keep their own queue and retries.
+func createForwardStatusExtension(bus *bus.TransportBus) extension.Factory {
+	return extension.NewFactory(
+		component.MustNewType(forwardStatusExtensionName),
+		func() component.Config {
+			return &forwardStatusConfig{}
+		},
+		func(ctx context.Context, set extension.Settings, cfg component.Config) (extension.Extension, error) {
+			return &forwardStatus{endpoint: cfg.(*forwardStatusConfig).Endpoint, bus: bus, failing: make(map[string]bool)}, nil
+		},
+		component.StabilityLevelDevelopment,
+	)
+}
+
+// forwardStatus tells the UI when forwarding each signal to an upstream
+// endpoint starts or stops failing.
+type forwardStatus struct {
+	component.StartFunc
+	component.ShutdownFunc
+
+	endpoint string
+	bus      *bus.TransportBus
+
+	mu sync.Mutex
+	// failing is keyed by traces, logs or metrics
+	failing map[string]bool
+}
+
+func (s *forwardStatus) GetGRPCClientOptions() ([]grpc.DialOption, error) {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			s.report(grpcSignal(method), err)
+
+			return err
+		}),
+	}, nil
+}
+
+func (s *forwardStatus) GetHTTPRoundTripper(base http.RoundTripper) (http.RoundTripper, error) {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := base.RoundTrip(req)
+
+		failure := err
+		if err == nil && resp.StatusCode >= http.StatusMultipleChoices {
+			failure = errors.New(resp.Status)
+		}
+		s.report(path.Base(req.URL.Path), failure)
+
+		return resp, err
+	}), nil
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// grpcSignal is the signal exported by a method of OTLP, e.g.
+// /opentelemetry.proto.collector.trace.v1.TraceService/Export.
+func grpcSignal(method string) string {
+	switch {
+	case strings.Contains(method, ".trace."):
+		return "traces"
+	case strings.Contains(method, ".logs."):
+		return "logs"
+	case strings.Contains(method, ".metrics."):
+		return "metrics"
+	default:
+		return method
+	}
+}
+
+// report tells the UI when forwarding the signal fails, and when it works
+// again.
+func (s *forwardStatus) report(signal string, err error) {
+	s.mu.Lock()
+	changed := s.failing[signal] != (err != nil)
+	s.failing[signal] = err != nil
+	s.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	text := fmt.Sprintf("Forwarding %s to %s again", signal, s.endpoint)
+	if err != nil {
+		zap.L().Warn("could not forward", zap.String("signal", signal), zap.String("endpoint", s.endpoint), zap.Error(err))
+		text = fmt.Sprintf("Could not forward %s to %s: %v", signal, s.endpoint, err)
+	}
+
+	select {
+	case s.bus.StatusBus <- text:
+	case <-time.After(1 * time.Second):
+		zap.L().Warn("forwarding status wasn't shown", zap.String("status", text))
+	}
+}
@@ -0,0 +1,141 @@
+package telemetry_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/telemetry"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParseForward(t *testing.T) {
+	for _, endpoint := range []string{"grpc://collector:4317", "grpcs://collector:4317", "http://localhost:4318", "https://otlp.example.com/otlp"} {
+		t.Run("accepts "+endpoint, func(t *testing.T) {
+			f, err := telemetry.ParseForward(endpoint)
+
+			assert.Nil(t, err)
+			assert.Equal(t, endpoint, f.Endpoint)
+		})
+	}
+
+	for _, endpoint := range []string{"collector:4317", "ftp://collector", "https://"} {
+		t.Run("rejects "+endpoint, func(t *testing.T) {
+			_, err := telemetry.ParseForward(endpoint)
+
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+// traceServer receives traces over gRPC, with the authorization header.
+type traceServer struct {
+	ptraceotlp.UnimplementedGRPCServer
+	received chan string
+}
+
+func (s *traceServer) Export(ctx context.Context, req ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.received <- strings.Join(md.Get("authorization"), "") + " " + req.Traces().ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name()
+
+	return ptraceotlp.NewExportResponse(), nil
+}
+
+func TestForward(t *testing.T) {
+	// Receives over HTTP, and records the paths posted to
+	posted := make(chan string, 10)
+	httpUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted <- r.Header.Get("X-Tenant") + " " + r.URL.Path
+	}))
+	t.Cleanup(httpUpstream.Close)
+
+	// The gRPC upstream is only served once the collector has started, as
+	// the collector replaces grpc's logger, which the server reads
+	listener, err := net.Listen("tcp", "localhost:0")
+	assert.Nil(t, err)
+
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(rejecting.Close)
+
+	toHTTP, err := telemetry.ParseForward(httpUpstream.URL)
+	assert.Nil(t, err)
+	toHTTP.Headers = map[string]string{"X-Tenant": "shop"}
+	toGRPC, err := telemetry.ParseForward("grpc://" + listener.Addr().String())
+	assert.Nil(t, err)
+	toGRPC.Headers = map[string]string{"Authorization": "Bearer secret"}
+	toRejecting, err := telemetry.ParseForward(rejecting.URL)
+	assert.Nil(t, err)
+
+	// Stopped before the upstreams, as cleanups run last to first
+	database, b := startCollector(t, telemetry.Options{Forward: []telemetry.Forward{toHTTP, toGRPC, toRejecting}})
+
+	grpcReceived := make(chan string, 10)
+	grpcUpstream := grpc.NewServer()
+	ptraceotlp.RegisterGRPCServer(grpcUpstream, &traceServer{received: grpcReceived})
+	go grpcUpstream.Serve(listener)
+	t.Cleanup(grpcUpstream.Stop)
+
+	post := func(path, body string) {
+		resp, err := http.Post("http://"+telemetry.HTTPEndpoint+path, "application/json", strings.NewReader(body))
+		assert.Nil(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	post("/v1/traces", `{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174","name":"GET /orders"}]}]}]}`)
+	post("/v1/metrics", `{"resourceMetrics":[{"scopeMetrics":[{"metrics":[{"name":"requests","sum":{"dataPoints":[{"asInt":"1"}]}}]}]}]}`)
+
+	receive := func(c <-chan string) string {
+		select {
+		case s := <-c:
+			return s
+		case <-time.After(5 * time.Second):
+			return "nothing received"
+		}
+	}
+
+	t.Run("forwards over HTTP with headers", func(t *testing.T) {
+		paths := []string{receive(posted), receive(posted)}
+
+		assert.ElementsMatch(t, []string{"shop /v1/traces", "shop /v1/metrics"}, paths)
+	})
+
+	t.Run("forwards over gRPC with headers", func(t *testing.T) {
+		assert.Equal(t, "Bearer secret GET /orders", receive(grpcReceived))
+	})
+
+	t.Run("shows when forwarding fails", func(t *testing.T) {
+		statuses := make([]string, 0)
+		for len(statuses) < 3 {
+			select {
+			case s := <-b.StatusBus:
+				statuses = append(statuses, s)
+			case <-time.After(5 * time.Second):
+				t.Fatal("no status for the failed forward")
+			}
+		}
+
+		assert.ElementsMatch(t, []string{
+			"Could not forward traces to " + rejecting.URL + ": 400 Bad Request",
+			"Could not forward metrics to " + rejecting.URL + ": 400 Bad Request",
+			// The upstream only receives traces
+			"Could not forward metrics to " + listener.Addr().String() + ": rpc error: code = Unimplemented desc = unknown service opentelemetry.proto.collector.metrics.v1.MetricsService",
+		}, statuses)
+	})
+
+	t.Run("stores what it forwards", func(t *testing.T) {
+		spans, err := database.GetSpans(t.Context())
+
+		assert.Nil(t, err)
+		assert.Len(t, spans, 1)
+	})
+}
@@ -0,0 +1,238 @@
+package telemetry
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// ValidateLogFormat checks the format of log lines, which is json, logfmt,
+// a regular expression with named groups, e.g.
+// (?P<level>\w+) (?P<msg>.*), or empty to tell json and logfmt apart by
+// each line.
+func ValidateLogFormat(format string) error {
+	_, err := newLogParser(format)
+	return err
+}
+
+// logParser turns lines of logs from processes which don't speak OTLP into
+// log records.
+type logParser struct {
+	format  string
+	pattern *regexp.Regexp
+}
+
+func newLogParser(format string) (*logParser, error) {
+	switch format {
+	case "", "json", "logfmt":
+		return &logParser{format: format}, nil
+	}
+
+	pattern, err := regexp.Compile(format)
+	if err != nil {
+		return nil, fmt.Errorf("log format %q isn't json, logfmt or a regular expression: %w", format, err)
+	}
+	if pattern.NumSubexp() == 0 || strings.Join(pattern.SubexpNames(), "") == "" {
+		return nil, fmt.Errorf("log format %q has no named groups like (?P<msg>.*)", format)
+	}
+
+	return &logParser{pattern: pattern}, nil
+}
+
+// parse sets the record from the line. The message becomes the body, the
+// level the severity and the time the timestamp, and the other fields
+// become attributes. Lines which aren't in the format are kept as they are.
+func (p *logParser) parse(line string, record plog.LogRecord) {
+	record.Body().SetStr(line)
+
+	for key, value := range p.fields(line) {
+		text, isText := value.(string)
+
+		switch strings.ToLower(key) {
+		case "msg", "message":
+			if isText {
+				record.Body().SetStr(text)
+				continue
+			}
+		case "level", "lvl", "severity", "log.level":
+			if severity := logSeverity(value); severity != plog.SeverityNumberUnspecified {
+				record.SetSeverityNumber(severity)
+				if isText {
+					record.SetSeverityText(text)
+				}
+				continue
+			}
+		case "time", "ts", "timestamp", "@timestamp":
+			if t, ok := logTime(value); ok {
+				record.SetTimestamp(pcommon.NewTimestampFromTime(t))
+				continue
+			}
+		case "trace_id", "traceid":
+			var id pcommon.TraceID
+			if b, err := hex.DecodeString(text); err == nil && len(b) == len(id) {
+				copy(id[:], b)
+				record.SetTraceID(id)
+				continue
+			}
+		case "span_id", "spanid":
+			var id pcommon.SpanID
+			if b, err := hex.DecodeString(text); err == nil && len(b) == len(id) {
+				copy(id[:], b)
+				record.SetSpanID(id)
+				continue
+			}
+		}
+
+		if err := record.Attributes().PutEmpty(key).FromRaw(value); err != nil {
+			record.Attributes().PutStr(key, fmt.Sprint(value))
+		}
+	}
+}
+
+// fields returns the fields of the line, or nil if it isn't in the format.
+func (p *logParser) fields(line string) map[string]any {
+	if p.pattern != nil {
+		match := p.pattern.FindStringSubmatch(line)
+		if match == nil {
+			return nil
+		}
+
+		fields := make(map[string]any)
+		for i, name := range p.pattern.SubexpNames() {
+			if name != "" && match[i] != "" {
+				fields[name] = match[i]
+			}
+		}
+		return fields
+	}
+
+	format := p.format
+	if format == "" {
+		format = "logfmt"
+		if strings.HasPrefix(strings.TrimSpace(line), "{") {
+			format = "json"
+		}
+	}
+
+	if format == "json" {
+		var fields map[string]any
+		if json.Unmarshal([]byte(line), &fields) != nil {
+			return nil
+		}
+		return fields
+	}
+
+	return parseLogfmt(line)
+}
+
+// parseLogfmt returns the key=value pairs of the line, or nil if anything
+// in it isn't one, so plain text isn't taken for logfmt.
+func parseLogfmt(line string) map[string]any {
+	fields := make(map[string]any)
+
+	for rest := strings.TrimSpace(line); rest != ""; rest = strings.TrimLeft(rest, " \t") {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok || key == "" || strings.ContainsAny(key, " \t\"") {
+			return nil
+		}
+
+		rest = value
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		} else {
+			value, rest, _ = strings.Cut(rest, " ")
+		}
+
+		fields[key] = value
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return fields
+}
+
+// logSeverity maps the level of a log line to a severity, including the
+// numbers used by pino and bunyan.
+func logSeverity(level any) plog.SeverityNumber {
+	if number, ok := level.(float64); ok {
+		switch {
+		case number >= 60:
+			return plog.SeverityNumberFatal
+		case number >= 50:
+			return plog.SeverityNumberError
+		case number >= 40:
+			return plog.SeverityNumberWarn
+		case number >= 30:
+			return plog.SeverityNumberInfo
+		case number >= 20:
+			return plog.SeverityNumberDebug
+		case number >= 10:
+			return plog.SeverityNumberTrace
+		}
+		return plog.SeverityNumberUnspecified
+	}
+
+	text, _ := level.(string)
+	switch strings.ToLower(text) {
+	case "trace", "trc":
+		return plog.SeverityNumberTrace
+	case "debug", "dbg":
+		return plog.SeverityNumberDebug
+	case "info", "inf", "information":
+		return plog.SeverityNumberInfo
+	case "notice":
+		return plog.SeverityNumberInfo2
+	case "warn", "warning", "wrn":
+		return plog.SeverityNumberWarn
+	case "error", "err", "eror":
+		return plog.SeverityNumberError
+	case "fatal", "ftl", "panic", "crit", "critical", "alert", "emerg":
+		return plog.SeverityNumberFatal
+	}
+
+	return plog.SeverityNumberUnspecified
+}
+
+// logTime parses the time of a log line, which is either a string like
+// RFC 3339 or a Unix time in seconds, milliseconds, microseconds or
+// nanoseconds.
+func logTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case float64:
+		switch {
+		case v > 1e17:
+			return time.Unix(0, int64(v)), true
+		case v > 1e14:
+			return time.UnixMicro(int64(v)), true
+		case v > 1e11:
+			return time.UnixMilli(int64(v)), true
+		case v > 0:
+			return time.Unix(0, int64(v*float64(time.Second))), true
+		}
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+		if number, err := strconv.ParseFloat(v, 64); err == nil {
+			return logTime(number)
+		}
+	}
+
+	return time.Time{}, false
+}
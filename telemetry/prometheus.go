@@ -0,0 +1,209 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fredrikaugust/otelly/bus"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/prometheusreceiver"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/prometheusremotewritereceiver"
+	"github.com/prometheus/common/model"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/featuregate"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultScrapeInterval is how often scrape targets are scraped unless
+	// told otherwise
+	DefaultScrapeInterval = 10 * time.Second
+	// RemoteWriteEndpoint is where the remote write receiver listens for
+	// requests to /api/v1/write, on all interfaces, when it's on. It's not
+	// Prometheus' port, as that's likely being scraped.
+	RemoteWriteEndpoint = "0.0.0.0:19291"
+
+	prometheusReceiverName  = "prometheus"
+	remoteWriteReceiverName = "prometheusremotewrite"
+)
+
+// ParseScrapeTarget returns the URL to scrape for the target, which can
+// leave out the scheme and path, e.g. localhost:9090 for
+// http://localhost:9090/metrics.
+func ParseScrapeTarget(target string) (string, error) {
+	u, err := parseScrapeTarget(target)
+	if err != nil {
+		return "", err
+	}
+
+	return u.String(), nil
+}
+
+func parseScrapeTarget(target string) (*url.URL, error) {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("can't scrape %s, only http and https are supported", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("%q has no host", target)
+	}
+	if u.Path == "" {
+		u.Path = "/metrics"
+	}
+
+	return u, nil
+}
+
+// scrapeJob is a target scraped as a job of its own, so it's its own
+// service.
+type scrapeJob struct {
+	name   string
+	target *url.URL
+}
+
+// scrapeJobs names a job after the host of each target, or after the host
+// and path when there are several targets on the host.
+func scrapeJobs(targets []string) ([]scrapeJob, error) {
+	jobs := make([]scrapeJob, 0, len(targets))
+	names := make(map[string]bool)
+	for _, target := range targets {
+		u, err := parseScrapeTarget(target)
+		if err != nil {
+			return nil, err
+		}
+
+		name := u.Host
+		if names[name] {
+			name = u.Host + u.Path
+		}
+		names[name] = true
+
+		jobs = append(jobs, scrapeJob{name, u})
+	}
+
+	return jobs, nil
+}
+
+// scrapeConfig is the Prometheus config of the prometheus receiver, which
+// scrapes the jobs every interval.
+func scrapeConfig(jobs []scrapeJob, interval time.Duration) map[string]any {
+	scrapeConfigs := make([]any, 0, len(jobs))
+	for _, job := range jobs {
+		params := make(map[string]any)
+		for key, values := range job.target.Query() {
+			params[key] = values
+		}
+
+		scrapeConfigs = append(scrapeConfigs, map[string]any{
+			"job_name":     job.name,
+			"scheme":       job.target.Scheme,
+			"metrics_path": job.target.Path,
+			"params":       params,
+			// Plenty of endpoints don't say which format they respond with
+			"fallback_scrape_protocol": "PrometheusText0.0.4",
+			"static_configs":           []any{map[string]any{"targets": []any{job.target.Host}}},
+		})
+	}
+
+	return map[string]any{
+		"config": map[string]any{
+			"global": map[string]any{
+				"scrape_interval": model.Duration(interval).String(),
+				"scrape_timeout":  model.Duration(interval).String(),
+			},
+			"scrape_configs": scrapeConfigs,
+		},
+	}
+}
+
+// createPrometheusReceiver scrapes Prometheus endpoints with the receiver
+// of the collector, and tells the UI when scraping a target fails.
+func createPrometheusReceiver(bus *bus.TransportBus) receiver.Factory {
+	// Native histograms are kept as exponential histograms, rather than
+	// left out
+	if err := featuregate.GlobalRegistry().Set("receiver.prometheusreceiver.EnableNativeHistograms", true); err != nil {
+		zap.L().Warn("could not enable native histograms", zap.Error(err))
+	}
+
+	factory := prometheusreceiver.NewFactory()
+	return receiver.NewFactory(
+		factory.Type(),
+		factory.CreateDefaultConfig,
+		receiver.WithMetrics(
+			func(ctx context.Context, set receiver.Settings, cfg component.Config, next consumer.Metrics) (receiver.Metrics, error) {
+				return factory.CreateMetrics(ctx, set, cfg, &scrapeStatus{Metrics: next, bus: bus, failing: make(map[string]bool)})
+			},
+			factory.MetricsStability(),
+		),
+	)
+}
+
+// createRemoteWriteReceiver receives Prometheus remote write 2.0 on
+// /api/v1/write.
+func createRemoteWriteReceiver() receiver.Factory {
+	return prometheusremotewritereceiver.NewFactory()
+}
+
+// scrapeStatus passes on scraped metrics, and tells the UI when scraping a
+// target fails and when it works again by the up metric of each scrape.
+type scrapeStatus struct {
+	consumer.Metrics
+	bus *bus.TransportBus
+
+	mu      sync.Mutex
+	failing map[string]bool
+}
+
+func (s *scrapeStatus) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	for _, rm := range md.ResourceMetrics().All() {
+		job, _ := rm.Resource().Attributes().Get("service.name")
+		for _, sm := range rm.ScopeMetrics().All() {
+			for _, m := range sm.Metrics().All() {
+				if m.Name() != "up" || m.Type() != pmetric.MetricTypeGauge {
+					continue
+				}
+				for _, dp := range m.Gauge().DataPoints().All() {
+					s.report(job.Str(), dp.DoubleValue() == 0)
+				}
+			}
+		}
+	}
+
+	return s.Metrics.ConsumeMetrics(ctx, md)
+}
+
+func (s *scrapeStatus) report(job string, failing bool) {
+	s.mu.Lock()
+	changed := s.failing[job] != failing
+	s.failing[job] = failing
+	s.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	text := fmt.Sprintf("Scraping %s again", job)
+	if failing {
+		zap.L().Warn("could not scrape", zap.String("job", job))
+		text = fmt.Sprintf("Could not scrape %s", job)
+	}
+
+	select {
+	case s.bus.StatusBus <- text:
+	case <-time.After(1 * time.Second):
+		zap.L().Warn("scrape status wasn't shown", zap.String("status", text))
+	}
+}
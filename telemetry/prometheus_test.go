@@ -0,0 +1,223 @@
+package telemetry_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/telemetry"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScrapeTarget(t *testing.T) {
+	for target, expected := range map[string]string{
+		"localhost:9090":                   "http://localhost:9090/metrics",
+		"localhost:9090/metrics":           "http://localhost:9090/metrics",
+		"localhost:8080/actuator/metrics":  "http://localhost:8080/actuator/metrics",
+		"https://shop.example.com/metrics": "https://shop.example.com/metrics",
+	} {
+		t.Run("accepts "+target, func(t *testing.T) {
+			u, err := telemetry.ParseScrapeTarget(target)
+
+			assert.Nil(t, err)
+			assert.Equal(t, expected, u)
+		})
+	}
+
+	for _, target := range []string{"ftp://localhost:21", "http://", "/metrics"} {
+		t.Run("rejects "+target, func(t *testing.T) {
+			_, err := telemetry.ParseScrapeTarget(target)
+
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+// waitForMetrics returns the stored data points once there are n of
+// them.
+func waitForMetrics(t *testing.T, database *db.Database, n int) []db.Metric {
+	t.Helper()
+
+	var metrics []db.Metric
+	assert.Eventually(t, func() bool {
+		var err error
+		metrics, err = database.GetMetrics(t.Context())
+		return err == nil && len(metrics) >= n
+	}, 5*time.Second, 50*time.Millisecond)
+
+	return metrics
+}
+
+// findMetric returns the first data point of the metric with the
+// attribute, if given as name=value.
+func findMetric(metrics []db.Metric, name string, attribute string) db.Metric {
+	key, value, _ := strings.Cut(attribute, "=")
+	for _, m := range metrics {
+		if m.Name == name && (key == "" || m.Attributes[key] == value) {
+			return m
+		}
+	}
+
+	return db.Metric{}
+}
+
+func TestScrape(t *testing.T) {
+	target := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	t.Cleanup(target.Close)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(failing.Close)
+
+	database, b := startCollector(t, telemetry.Options{
+		Scrape:         []string{target.URL + "/metrics.txt", failing.URL},
+		ScrapeInterval: time.Second,
+	})
+	host := strings.TrimPrefix(target.URL, "http://")
+	failingHost := strings.TrimPrefix(failing.URL, "http://")
+
+	// The status is read first, as it isn't shown if nothing reads it
+	t.Run("shows when scraping fails", func(t *testing.T) {
+		select {
+		case s := <-b.StatusBus:
+			assert.Equal(t, "Could not scrape "+failingHost, s)
+		case <-time.After(10 * time.Second):
+			t.Fatal("no status for the failed scrape")
+		}
+	})
+
+	// Once both targets have been scraped and their up metrics stored
+	var metrics []db.Metric
+	up := make(map[string]float64)
+	assert.Eventually(t, func() bool {
+		var err error
+		metrics, err = database.GetMetrics(t.Context())
+		for _, m := range metrics {
+			if m.Name == "up" {
+				up[m.ServiceName] = m.Value.Float64
+			}
+		}
+		return err == nil && findMetric(metrics, "gc_duration_seconds", "").Name != "" && len(up) == 2
+	}, 10*time.Second, 100*time.Millisecond)
+
+	t.Run("stores counters as sums", func(t *testing.T) {
+		m := findMetric(metrics, "http_requests_total", "status=500")
+
+		assert.Equal(t, "Sum", m.Type)
+		assert.Equal(t, 3.0, m.Value.Float64)
+		assert.Equal(t, "Requests handled, by route and status.", m.Description)
+		assert.Equal(t, "/orders", m.Attributes["route"])
+		assert.True(t, m.StartTimestamp.Valid)
+	})
+
+	t.Run("stores gauges and untyped metrics as gauges", func(t *testing.T) {
+		assert.Equal(t, "Gauge", findMetric(metrics, "queue_depth", "").Type)
+		assert.Equal(t, 12.0, findMetric(metrics, "queue_depth", "").Value.Float64)
+		assert.Equal(t, "Gauge", findMetric(metrics, "build_info", "").Type)
+		assert.Equal(t, "1.4.2", findMetric(metrics, "build_info", "").Attributes["version"])
+	})
+
+	t.Run("stores histograms and summaries", func(t *testing.T) {
+		histogram := findMetric(metrics, "request_duration_seconds", "")
+		summary := findMetric(metrics, "gc_duration_seconds", "")
+
+		assert.Equal(t, "Histogram", histogram.Type)
+		assert.Equal(t, int64(100), histogram.Count.Int64)
+		assert.Equal(t, 17.5, histogram.Sum.Float64)
+		assert.Equal(t, "Summary", summary.Type)
+		assert.Equal(t, int64(620), summary.Count.Int64)
+	})
+
+	t.Run("names the service after the target", func(t *testing.T) {
+		assert.Equal(t, host, findMetric(metrics, "queue_depth", "").ServiceName)
+	})
+
+	t.Run("says whether targets are up", func(t *testing.T) {
+		assert.Equal(t, map[string]float64{host: 1, failingHost: 0}, up)
+	})
+}
+
+func TestRemoteWrite(t *testing.T) {
+	database, _ := startCollector(t, telemetry.Options{RemoteWrite: true})
+
+	now := time.Now().Truncate(time.Millisecond)
+	symbols := writev2.NewSymbolTable()
+	series := func(metadata writev2.Metadata, labelPairs ...string) writev2.TimeSeries {
+		return writev2.TimeSeries{
+			LabelsRefs: symbols.SymbolizeLabels(labels.FromStrings(labelPairs...), nil),
+			Metadata:   metadata,
+		}
+	}
+
+	jobs := series(writev2.Metadata{Type: writev2.Metadata_METRIC_TYPE_COUNTER, HelpRef: symbols.Symbolize("Jobs processed.")},
+		"__name__", "jobs_processed_total", "job", "worker", "instance", "worker-1:9100", "queue", "emails")
+	jobs.Samples = []writev2.Sample{{Value: 41, Timestamp: now.UnixMilli()}}
+	memory := series(writev2.Metadata{Type: writev2.Metadata_METRIC_TYPE_GAUGE},
+		"__name__", "memory_used_bytes", "job", "worker", "instance", "worker-1:9100")
+	memory.Samples = []writev2.Sample{{Value: 7, Timestamp: now.UnixMilli()}}
+	latency := series(writev2.Metadata{Type: writev2.Metadata_METRIC_TYPE_HISTOGRAM},
+		"__name__", "job_duration_seconds", "job", "worker", "instance", "worker-1:9100")
+	latency.Histograms = []writev2.Histogram{writev2.FromIntHistogram(now.UnixMilli(), &histogram.Histogram{
+		Count:           5,
+		Sum:             2.5,
+		Schema:          0,
+		PositiveSpans:   []histogram.Span{{Offset: 0, Length: 2}},
+		PositiveBuckets: []int64{2, 1},
+	})}
+
+	request := writev2.Request{Symbols: symbols.Symbols(), Timeseries: []writev2.TimeSeries{jobs, memory, latency}}
+	body, err := request.Marshal()
+	assert.Nil(t, err)
+
+	post := func(contentType string, body []byte) int {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "http://"+telemetry.RemoteWriteEndpoint+"/api/v1/write", bytes.NewReader(snappy.Encode(nil, body)))
+		assert.Nil(t, err)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Content-Encoding", "snappy")
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusNoContent, post("application/x-protobuf;proto=io.prometheus.write.v2.Request", body))
+
+	metrics := waitForMetrics(t, database, 3)
+
+	t.Run("stores counters as sums", func(t *testing.T) {
+		m := findMetric(metrics, "jobs_processed_total", "")
+
+		assert.Equal(t, "Sum", m.Type)
+		assert.Equal(t, 41.0, m.Value.Float64)
+		assert.Equal(t, "Jobs processed.", m.Description)
+		assert.Equal(t, "emails", m.Attributes["queue"])
+		assert.True(t, now.Equal(m.Timestamp))
+	})
+
+	t.Run("stores gauges", func(t *testing.T) {
+		assert.Equal(t, "Gauge", findMetric(metrics, "memory_used_bytes", "").Type)
+	})
+
+	t.Run("keeps native histograms", func(t *testing.T) {
+		m := findMetric(metrics, "job_duration_seconds", "")
+
+		assert.Equal(t, "ExponentialHistogram", m.Type)
+		assert.Equal(t, int64(5), m.Count.Int64)
+		assert.Equal(t, 2.5, m.Sum.Float64)
+	})
+
+	t.Run("names the service after the job", func(t *testing.T) {
+		assert.Equal(t, "worker", findMetric(metrics, "memory_used_bytes", "").ServiceName)
+	})
+
+	t.Run("rejects remote write 1.0", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnsupportedMediaType, post("application/x-protobuf", body))
+	})
+}
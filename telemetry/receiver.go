@@ -5,11 +5,30 @@ package telemetry
 import (
 	"github.com/fredrikaugust/otelly/bus"
 	"github.com/fredrikaugust/otelly/db"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/jaegerreceiver"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/otlpjsonfilereceiver"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/zipkinreceiver"
+	"go.opentelemetry.io/collector/exporter/otlpexporter"
+	"go.opentelemetry.io/collector/exporter/otlphttpexporter"
 	"go.opentelemetry.io/collector/otelcol"
 	"go.opentelemetry.io/collector/receiver/otlpreceiver"
 )
 
+const (
+	// ZipkinEndpoint is where the Zipkin receiver listens for spans POSTed
+	// to /api/v2/spans, on all interfaces like OTLP, when it's on
+	ZipkinEndpoint = "0.0.0.0:9411"
+	// JaegerHTTPEndpoint is where the Jaeger receiver listens for Thrift
+	// batches POSTed to /api/traces, on all interfaces, when it's on
+	JaegerHTTPEndpoint = "0.0.0.0:14268"
+	// JaegerGRPCEndpoint is where the Jaeger receiver listens for the gRPC
+	// API of jaeger-collector, on all interfaces, when it's on
+	JaegerGRPCEndpoint = "0.0.0.0:14250"
+
+	zipkinReceiverName = "zipkin"
+	jaegerReceiverName = "jaeger"
+)
+
 // Builds factories which amount to the components needed
 // to set up a collector.
 func createCollectorFactories(bus *bus.TransportBus, db *db.Database) (otelcol.Factories, error) {
@@ -20,6 +39,12 @@ func createCollectorFactories(bus *bus.TransportBus, db *db.Database) (otelcol.F
 	factories.Receivers, err = otelcol.MakeFactoryMap(
 		otlpreceiver.NewFactory(),
 		otlpjsonfilereceiver.NewFactory(),
+		zipkinreceiver.NewFactory(),
+		jaegerreceiver.NewFactory(),
+		createPrometheusReceiver(bus),
+		createRemoteWriteReceiver(),
+		createFileLogReceiver(),
+		createSyslogReceiver(),
 	)
 	if err != nil {
 		return otelcol.Factories{}, err
@@ -27,6 +52,15 @@ func createCollectorFactories(bus *bus.TransportBus, db *db.Database) (otelcol.F
 
 	factories.Exporters, err = otelcol.MakeFactoryMap(
 		createOtellyExporter(bus, db),
+		otlpexporter.NewFactory(),
+		otlphttpexporter.NewFactory(),
+	)
+	if err != nil {
+		return otelcol.Factories{}, err
+	}
+
+	factories.Extensions, err = otelcol.MakeFactoryMap(
+		createForwardStatusExtension(bus),
 	)
 	if err != nil {
 		return otelcol.Factories{}, err
@@ -0,0 +1,343 @@
+package telemetry_test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/bus"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/telemetry"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// startCollector runs the collector with the options until the test ends.
+func startCollector(t *testing.T, options telemetry.Options) (*db.Database, *bus.TransportBus) {
+	t.Helper()
+
+	database, err := db.NewDB(":memory:")
+	assert.Nil(t, err)
+	t.Cleanup(func() { database.Close() })
+	assert.Nil(t, database.Migrate(t.Context()))
+
+	b := bus.NewTransportBus()
+	go func() {
+		for range b.SpanBus {
+		}
+	}()
+	go func() {
+		for range b.LogBus {
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- telemetry.Start(ctx, b, database, options)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		assert.Nil(t, <-done)
+	})
+	assert.Nil(t, telemetry.WaitForReceiver(t.Context()))
+
+	return database, b
+}
+
+// spanRow is the part of a stored span the tests check.
+type spanRow struct {
+	Name, Service, Kind, Status, StatusMessage string
+	// Parent is the name of the parent span
+	Parent string
+}
+
+// waitForSpans returns the stored spans once there are n of them, sorted
+// by name.
+func waitForSpans(t *testing.T, database *db.Database, n int) ([]spanRow, []db.Span) {
+	t.Helper()
+
+	var spans []db.Span
+	assert.Eventually(t, func() bool {
+		var err error
+		spans, err = database.GetSpans(t.Context())
+		return err == nil && len(spans) >= n
+	}, 5*time.Second, 50*time.Millisecond)
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Name < spans[j].Name })
+	names := make(map[string]string)
+	for _, s := range spans {
+		names[s.ID] = s.Name
+	}
+
+	rows := make([]spanRow, 0, len(spans))
+	for _, s := range spans {
+		rows = append(rows, spanRow{
+			Name:          s.Name,
+			Service:       s.ServiceName,
+			Kind:          s.Kind,
+			Status:        s.StatusCode,
+			StatusMessage: s.StatusMessage.String,
+			Parent:        names[s.ParentSpanID.String],
+		})
+	}
+
+	return rows, spans
+}
+
+func TestZipkin(t *testing.T) {
+	database, _ := startCollector(t, telemetry.Options{Zipkin: true})
+
+	body := `[
+		{
+			"traceId": "463ac35c9f6413ad",
+			"id": "a2fb4a1d1a96d312",
+			"name": "get /orders",
+			"kind": "SERVER",
+			"timestamp": 1760000000000000,
+			"duration": 20000,
+			"localEndpoint": {"serviceName": "legacy-shop", "ipv4": "10.0.0.1"},
+			"tags": {"http.method": "GET"}
+		},
+		{
+			"traceId": "463ac35c9f6413ad",
+			"parentId": "a2fb4a1d1a96d312",
+			"id": "b7ad6b7169203331",
+			"name": "get /stock",
+			"kind": "CLIENT",
+			"timestamp": 1760000000005000,
+			"duration": 10000,
+			"localEndpoint": {"serviceName": "legacy-shop"},
+			"remoteEndpoint": {"serviceName": "stock", "ipv4": "10.0.0.2", "port": 8080},
+			"tags": {"error": "timed out"},
+			"annotations": [{"timestamp": 1760000000006000, "value": "retry"}]
+		}
+	]`
+	resp, err := http.Post("http://"+telemetry.ZipkinEndpoint+"/api/v2/spans", "application/json", strings.NewReader(body))
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	rows, spans := waitForSpans(t, database, 2)
+
+	t.Run("stores the spans", func(t *testing.T) {
+		assert.Equal(t, []spanRow{
+			{Name: "get /orders", Service: "legacy-shop", Kind: "Server", Status: "Unset"},
+			{Name: "get /stock", Service: "legacy-shop", Kind: "Client", Status: "Error", Parent: "get /orders"},
+		}, rows)
+	})
+
+	t.Run("pads 64-bit trace IDs", func(t *testing.T) {
+		assert.Equal(t, "0000000000000000463ac35c9f6413ad", spans[0].TraceID)
+	})
+
+	t.Run("keeps tags and the remote endpoint as attributes", func(t *testing.T) {
+		assert.Equal(t, "GET", spans[0].Attributes["http.method"])
+		assert.Equal(t, "timed out", spans[1].Attributes["error"])
+		assert.Equal(t, "stock", spans[1].Attributes["peer.service"])
+		assert.Equal(t, "10.0.0.2", spans[1].Attributes["net.peer.ip"])
+	})
+
+	t.Run("keeps annotations as events", func(t *testing.T) {
+		assert.Len(t, spans[1].Events, 1)
+		assert.Equal(t, "retry", spans[1].Events[0].Name)
+		assert.True(t, time.UnixMicro(1760000000006000).Equal(spans[1].Events[0].Timestamp))
+	})
+
+	t.Run("rejects what isn't Zipkin JSON", func(t *testing.T) {
+		resp, err := http.Post("http://"+telemetry.ZipkinEndpoint+"/api/v2/spans", "application/json", strings.NewReader(`{"spans": []}`))
+		assert.Nil(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+// thrift builds messages in the Thrift binary protocol.
+type thrift []byte
+
+func (b thrift) field(typ byte, id int16) thrift {
+	return binary.BigEndian.AppendUint16(append(b, typ), uint16(id))
+}
+
+func (b thrift) i32(id int16, v int32) thrift {
+	return binary.BigEndian.AppendUint32(b.field(8, id), uint32(v))
+}
+
+func (b thrift) i64(id int16, v int64) thrift {
+	return binary.BigEndian.AppendUint64(b.field(10, id), uint64(v))
+}
+
+func (b thrift) str(id int16, s string) thrift {
+	return append(binary.BigEndian.AppendUint32(b.field(11, id), uint32(len(s))), s...)
+}
+
+func (b thrift) bool(id int16, v bool) thrift {
+	if v {
+		return append(b.field(2, id), 1)
+	}
+	return append(b.field(2, id), 0)
+}
+
+func (b thrift) strct(id int16, s thrift) thrift {
+	return append(append(b.field(12, id), s...), 0)
+}
+
+func (b thrift) list(id int16, structs ...thrift) thrift {
+	b = binary.BigEndian.AppendUint32(append(b.field(15, id), 12), uint32(len(structs)))
+	for _, s := range structs {
+		b = append(append(b, s...), 0)
+	}
+	return b
+}
+
+func TestJaegerThrift(t *testing.T) {
+	database, _ := startCollector(t, telemetry.Options{Jaeger: true})
+
+	start := time.Date(2025, 10, 9, 12, 0, 0, 0, time.UTC).UnixMicro()
+	process := thrift{}.
+		str(1, "legacy-billing").
+		list(2, thrift{}.str(1, "hostname").i32(2, 0).str(3, "billing-1"))
+	parent := thrift{}.
+		i64(1, 2).i64(2, 1).i64(3, 10).i64(4, 0).
+		str(5, "GET /invoices").
+		i32(7, 1).i64(8, start).i64(9, 30_000).
+		list(10, thrift{}.str(1, "span.kind").i32(2, 0).str(3, "server"))
+	// Newer clients only give the parent as a reference
+	child := thrift{}.
+		i64(1, 2).i64(2, 1).i64(3, 11).i64(4, 0).
+		str(5, "SELECT invoices").
+		list(6, thrift{}.i32(1, 0).i64(2, 2).i64(3, 1).i64(4, 10)).
+		i32(7, 1).i64(8, start+1000).i64(9, 10_000).
+		list(10,
+			thrift{}.str(1, "error").i32(2, 2).bool(5, true),
+			thrift{}.str(1, "db.rows").i32(2, 3).i64(6, 42),
+		).
+		list(11, thrift{}.i64(1, start+2000).list(2, thrift{}.str(1, "event").i32(2, 0).str(3, "slow query")))
+	batch := append(thrift{}.strct(1, process).list(2, parent, child), 0)
+
+	resp, err := http.Post("http://"+telemetry.JaegerHTTPEndpoint+"/api/traces", "application/x-thrift", strings.NewReader(string(batch)))
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	rows, spans := waitForSpans(t, database, 2)
+
+	t.Run("stores the spans", func(t *testing.T) {
+		assert.Equal(t, []spanRow{
+			{Name: "GET /invoices", Service: "legacy-billing", Kind: "Server", Status: "Unset"},
+			{Name: "SELECT invoices", Service: "legacy-billing", Kind: "Unspecified", Status: "Error", Parent: "GET /invoices"},
+		}, rows)
+	})
+
+	t.Run("converts IDs and times", func(t *testing.T) {
+		assert.Equal(t, "00000000000000010000000000000002", spans[0].TraceID)
+		assert.Equal(t, "000000000000000a", spans[0].ID)
+		assert.Equal(t, 30*time.Millisecond, spans[0].Duration)
+	})
+
+	t.Run("keeps tags as attributes", func(t *testing.T) {
+		assert.EqualValues(t, 42, spans[1].Attributes["db.rows"])
+	})
+
+	t.Run("keeps logs as events", func(t *testing.T) {
+		assert.Len(t, spans[1].Events, 1)
+		assert.Equal(t, "slow query", spans[1].Events[0].Name)
+		assert.True(t, time.UnixMicro(start+2000).Equal(spans[1].Events[0].Timestamp))
+	})
+
+	t.Run("rejects truncated batches", func(t *testing.T) {
+		resp, err := http.Post("http://"+telemetry.JaegerHTTPEndpoint+"/api/traces", "application/x-thrift", strings.NewReader(string(batch[:len(batch)/2])))
+		assert.Nil(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+// rawCodec sends and receives messages encoded by the test.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) { return *v.(*[]byte), nil }
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	*v.(*[]byte) = data
+	return nil
+}
+
+func (rawCodec) Name() string { return "proto" }
+
+// message builds a protobuf message of bytes fields.
+func message(fields ...any) []byte {
+	var b []byte
+	for i := 0; i < len(fields); i += 2 {
+		num := protowire.Number(fields[i].(int))
+		switch v := fields[i+1].(type) {
+		case string:
+			b = protowire.AppendBytes(protowire.AppendTag(b, num, protowire.BytesType), []byte(v))
+		case []byte:
+			b = protowire.AppendBytes(protowire.AppendTag(b, num, protowire.BytesType), v)
+		case int:
+			b = protowire.AppendVarint(protowire.AppendTag(b, num, protowire.VarintType), uint64(v))
+		}
+	}
+	return b
+}
+
+func TestJaegerGRPC(t *testing.T) {
+	database, _ := startCollector(t, telemetry.Options{Jaeger: true})
+
+	traceID, _ := hex.DecodeString("5b8efff798038103d269b633813fc60c")
+	parentID, _ := hex.DecodeString("eee19b7ec3c1b174")
+	childID, _ := hex.DecodeString("eee19b7ec3c1b175")
+	start := message(1, 1760000000, 2, 500_000)
+	duration := message(2, 15_000_000)
+
+	parent := message(1, traceID, 2, parentID, 3, "POST /payments", 6, start, 7, duration,
+		8, message(1, "span.kind", 3, "server"))
+	child := message(1, traceID, 2, childID, 3, "charge card", 6, start, 7, duration,
+		4, message(1, traceID, 2, parentID, 3, 0),
+		8, message(1, "otel.status_code", 3, "ERROR"),
+		8, message(1, "otel.status_description", 3, "card declined"),
+		8, message(1, "retries", 2, 2, 5, 3))
+	request := message(1, message(1, parent, 1, child, 2, message(1, "legacy-payments")))
+
+	conn, err := grpc.NewClient(telemetry.JaegerGRPCEndpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	defer conn.Close()
+	var response []byte
+	err = conn.Invoke(t.Context(), "/jaeger.api_v2.CollectorService/PostSpans", &request, &response, grpc.ForceCodec(rawCodec{}))
+	assert.Nil(t, err)
+
+	rows, spans := waitForSpans(t, database, 2)
+
+	t.Run("stores the spans", func(t *testing.T) {
+		assert.Equal(t, []spanRow{
+			{Name: "POST /payments", Service: "legacy-payments", Kind: "Server", Status: "Unset"},
+			{Name: "charge card", Service: "legacy-payments", Kind: "Unspecified", Status: "Error", StatusMessage: "card declined", Parent: "POST /payments"},
+		}, rows)
+	})
+
+	t.Run("converts times", func(t *testing.T) {
+		assert.Equal(t, time.Unix(1760000000, 500_000).UTC(), spans[0].StartTime.UTC())
+		assert.Equal(t, 15*time.Millisecond, spans[0].Duration)
+	})
+
+	t.Run("keeps tags as attributes", func(t *testing.T) {
+		assert.EqualValues(t, 3, spans[1].Attributes["retries"])
+	})
+
+	t.Run("rejects malformed batches", func(t *testing.T) {
+		bad := message(1, message(1, message(1, "too short")))
+		err := conn.Invoke(t.Context(), "/jaeger.api_v2.CollectorService/PostSpans", &bad, &response, grpc.ForceCodec(rawCodec{}))
+
+		assert.ErrorContains(t, err, "invalid length for TraceID")
+	})
+}
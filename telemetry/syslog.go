@@ -0,0 +1,139 @@
+package telemetry
+
+import (
+	"context"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/syslogreceiver"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/receiver"
+)
+
+const (
+	// SyslogEndpoint is where the syslog receivers listen on UDP and TCP,
+	// on all interfaces, when they're on
+	SyslogEndpoint = "0.0.0.0:5514"
+
+	syslogReceiverName = "syslog"
+	// syslogProtocol is what the receivers speak, as the collector only
+	// takes one
+	syslogProtocol = "rfc5424"
+)
+
+type syslogConfig struct {
+	// SysLogConfig has where to listen as tcp or udp, and the protocol
+	syslogreceiver.SysLogConfig `mapstructure:",squash"`
+	// Format is how messages are parsed, as given to ValidateLogFormat
+	Format string `mapstructure:"format"`
+}
+
+func (c *syslogConfig) Unmarshal(conf *confmap.Conf) error {
+	if conf == nil {
+		return nil
+	}
+
+	// The syslog receiver unmarshals itself, and doesn't know the format
+	options := conf.ToStringMap()
+	c.Format, _ = options["format"].(string)
+	delete(options, "format")
+
+	return c.SysLogConfig.Unmarshal(confmap.NewFromStringMap(options))
+}
+
+func (c *syslogConfig) Validate() error {
+	return ValidateLogFormat(c.Format)
+}
+
+// syslogConfigs are the configs of the syslog receivers, one for each of
+// UDP and TCP as each receiver of the collector listens on one.
+func syslogConfigs(format string) map[string]any {
+	configs := make(map[string]any)
+	for _, transport := range []string{"udp", "tcp"} {
+		configs[syslogReceiverName+"/"+transport] = map[string]any{
+			transport:  map[string]any{"listen_address": SyslogEndpoint},
+			"protocol": syslogProtocol,
+			"format":   format,
+		}
+	}
+
+	return configs
+}
+
+// createSyslogReceiver receives syslog messages, as sent by rsyslog,
+// syslog-ng or logger(1), with the receiver of the collector.
+func createSyslogReceiver() receiver.Factory {
+	factory := syslogreceiver.NewFactory()
+	return receiver.NewFactory(
+		factory.Type(),
+		func() component.Config {
+			return &syslogConfig{SysLogConfig: *factory.CreateDefaultConfig().(*syslogreceiver.SysLogConfig)}
+		},
+		receiver.WithLogs(
+			func(ctx context.Context, set receiver.Settings, cfg component.Config, next consumer.Logs) (receiver.Logs, error) {
+				config := cfg.(*syslogConfig)
+				parser, err := newLogParser(config.Format)
+				if err != nil {
+					return nil, err
+				}
+
+				return factory.CreateLogs(ctx, set, &config.SysLogConfig, &syslogLogs{Logs: next, parser: parser})
+			},
+			factory.LogsStability(),
+		),
+	)
+}
+
+// syslogLogs passes on syslog messages with the app name as the service and
+// the hostname as the host. The message itself is parsed like a line of a
+// log file, and its level and time are kept over those of syslog.
+type syslogLogs struct {
+	consumer.Logs
+	parser *logParser
+}
+
+func (s *syslogLogs) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	logs := plog.NewLogs()
+	resources := make(map[[2]string]plog.LogRecordSlice)
+
+	for _, rl := range ld.ResourceLogs().All() {
+		for _, sl := range rl.ScopeLogs().All() {
+			for _, received := range sl.LogRecords().All() {
+				service, host := "syslog", ""
+				if appname, ok := received.Attributes().Get("appname"); ok && appname.Str() != "" {
+					service = appname.Str()
+				}
+				if hostname, ok := received.Attributes().Get("hostname"); ok {
+					host = hostname.Str()
+				}
+
+				records, ok := resources[[2]string{service, host}]
+				if !ok {
+					rl := logs.ResourceLogs().AppendEmpty()
+					rl.Resource().Attributes().PutStr("service.name", service)
+					if host != "" {
+						rl.Resource().Attributes().PutStr("host.name", host)
+					}
+					records = rl.ScopeLogs().AppendEmpty().LogRecords()
+					resources[[2]string{service, host}] = records
+				}
+
+				record := records.AppendEmpty()
+				received.CopyTo(record)
+
+				text := record.Body().AsString()
+				if message, ok := record.Attributes().Get("message"); ok {
+					text = message.Str()
+				}
+				s.parser.parse(text, record)
+
+				for _, key := range []string{"appname", "hostname", "message"} {
+					record.Attributes().Remove(key)
+				}
+			}
+		}
+	}
+
+	return s.Logs.ConsumeLogs(ctx, logs)
+}
@@ -0,0 +1,63 @@
+package telemetry_test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/telemetry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslog(t *testing.T) {
+	database, _ := startCollector(t, telemetry.Options{Syslog: true, LogFormat: `^(?P<level>[a-z]+): (?P<msg>.*)$`})
+
+	udp, err := net.Dial("udp", telemetry.SyslogEndpoint)
+	assert.Nil(t, err)
+	defer udp.Close()
+	_, err = udp.Write([]byte(`<165>1 2025-01-01T12:00:00Z web-1 shop 4242 ID47 [origin@32473 region="eu"] warn: cart is empty`))
+	assert.Nil(t, err)
+
+	tcp, err := net.Dial("tcp", telemetry.SyslogEndpoint)
+	assert.Nil(t, err)
+	defer tcp.Close()
+	_, err = fmt.Fprint(tcp, "<11>1 2025-01-01T12:00:01Z db-1 postgres 99 - - fatal: too many connections\n")
+	assert.Nil(t, err)
+	_, err = fmt.Fprint(tcp, "<12>1 2025-01-01T12:00:02Z db-1 cron 7 - - job finished\n")
+	assert.Nil(t, err)
+
+	logs := waitForLogs(t, database, 3)
+
+	t.Run("receives over UDP", func(t *testing.T) {
+		l := findLog(logs, "cart is empty")
+
+		assert.Equal(t, "shop", l.ServiceName)
+		assert.True(t, time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC).Equal(l.Timestamp))
+		assert.EqualValues(t, 20, l.Attributes["facility"])
+		assert.Equal(t, "4242", l.Attributes["proc_id"])
+		assert.Equal(t, "ID47", l.Attributes["msg_id"])
+		assert.Equal(t, map[string]any{"origin@32473": map[string]any{"region": "eu"}}, l.Attributes["structured_data"])
+		assert.NotContains(t, l.Attributes, "appname")
+	})
+
+	t.Run("receives over TCP", func(t *testing.T) {
+		l := findLog(logs, "too many connections")
+
+		assert.Equal(t, "postgres", l.ServiceName)
+		assert.Equal(t, "99", l.Attributes["proc_id"])
+	})
+
+	t.Run("keeps the level of the message over the syslog severity", func(t *testing.T) {
+		assert.Equal(t, 13, findLog(logs, "cart is empty").SeverityNumber)
+		assert.Equal(t, 21, findLog(logs, "too many connections").SeverityNumber)
+	})
+
+	t.Run("uses the syslog severity otherwise", func(t *testing.T) {
+		l := findLog(logs, "job finished")
+
+		assert.Equal(t, "cron", l.ServiceName)
+		assert.Equal(t, 13, l.SeverityNumber)
+		assert.Equal(t, "warning", l.SeverityText)
+	})
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
+	"go.uber.org/zap"
+)
+
+// osc52Limit is the most text sent to the terminal to copy. Many terminals
+// ignore sequences over 100kB, and the text grows by a third when base64
+// encoded.
+const osc52Limit = 74_000
+
+// Clipboard copies text with the OSC52 escape sequence, which the terminal
+// turns into a copy to the system clipboard. As it goes through the
+// terminal, it also works over SSH. Text is written to a file instead when
+// there's no terminal or the text is too big to send.
+type Clipboard struct {
+	// Out is where the escape sequence is written, and Terminal is set when
+	// it's a terminal
+	Out      io.Writer
+	Terminal bool
+	// Mode wraps the sequence for tmux and screen, which would otherwise
+	// swallow it
+	Mode osc52.Mode
+	// Path is the file written to when the terminal can't be used
+	Path string
+}
+
+// terminalClipboard is used by all models, see SetClipboard.
+var terminalClipboard = DefaultClipboard()
+
+// SetClipboard replaces where copied text goes.
+func SetClipboard(c Clipboard) {
+	terminalClipboard = c
+}
+
+// DefaultClipboard writes to the terminal on stdout, which the UI draws on.
+func DefaultClipboard() Clipboard {
+	mode := osc52.DefaultMode
+	switch {
+	case os.Getenv("TMUX") != "":
+		mode = osc52.TmuxMode
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		mode = osc52.ScreenMode
+	}
+
+	return Clipboard{
+		Out:      os.Stdout,
+		Terminal: isatty.IsTerminal(os.Stdout.Fd()),
+		Mode:     mode,
+		Path:     "otelly-clipboard.txt",
+	}
+}
+
+// Copy copies the text, returning the path of the file it was written to
+// when it couldn't go through the terminal.
+func (c Clipboard) Copy(text string) (string, error) {
+	if c.Terminal && len(text) <= osc52Limit {
+		_, err := osc52.New(text).Mode(c.Mode).WriteTo(c.Out)
+		if err == nil {
+			return "", nil
+		}
+		zap.L().Warn("could not write to terminal clipboard", zap.Error(err))
+	}
+
+	if err := os.WriteFile(c.Path, []byte(text), 0o644); err != nil {
+		return "", err
+	}
+
+	return c.Path, nil
+}
+
+// copyText copies the text, saying what was copied and where in the status.
+// The sequence is written to the UI's output next to the renderer, in one
+// write like each frame, so it never ends up in the middle of one and the
+// UI isn't suspended to copy.
+func copyText(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := terminalClipboard.Copy(text)
+		if err != nil {
+			zap.L().Warn("could not copy", zap.String("what", what), zap.Error(err))
+			return MsgStatus{fmt.Sprintf("Could not copy %s: %v", what, err)}
+		}
+
+		if path != "" {
+			return MsgStatus{fmt.Sprintf("Wrote %s to %s", what, path)}
+		}
+
+		return MsgStatus{"Copied " + what}
+	}
+}
+
+// clipboardText formats a value to be pasted, with strings as they are
+// and everything else as JSON.
+func clipboardText(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
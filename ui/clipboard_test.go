@@ -0,0 +1,49 @@
+package ui_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaugust/otelly/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClipboard(t *testing.T) {
+	t.Run("copies through the terminal", func(t *testing.T) {
+		var out bytes.Buffer
+		c := ui.Clipboard{Out: &out, Terminal: true, Path: filepath.Join(t.TempDir(), "clipboard.txt")}
+
+		path, err := c.Copy("0af7651916cd43dd8448eb211c80319c")
+		assert.Nil(t, err)
+		assert.Empty(t, path)
+		assert.Equal(t, "\x1b]52;c;"+base64.StdEncoding.EncodeToString([]byte("0af7651916cd43dd8448eb211c80319c"))+"\x07", out.String())
+	})
+
+	t.Run("writes to a file without a terminal", func(t *testing.T) {
+		var out bytes.Buffer
+		c := ui.Clipboard{Out: &out, Path: filepath.Join(t.TempDir(), "clipboard.txt")}
+
+		path, err := c.Copy("hello")
+		assert.Nil(t, err)
+		assert.Equal(t, c.Path, path)
+		assert.Empty(t, out.String())
+
+		b, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, "hello", string(b))
+	})
+
+	t.Run("writes big text to a file", func(t *testing.T) {
+		var out bytes.Buffer
+		c := ui.Clipboard{Out: &out, Terminal: true, Path: filepath.Join(t.TempDir(), "clipboard.txt")}
+
+		path, err := c.Copy(strings.Repeat("x", 1_000_000))
+		assert.Nil(t, err)
+		assert.Equal(t, c.Path, path)
+		assert.Empty(t, out.String())
+	})
+}
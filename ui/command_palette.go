@@ -0,0 +1,179 @@
+package ui
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+)
+
+// Command is something which can be run from the command palette, either
+// an action or something to jump to like a trace or a service.
+type Command struct {
+	// Group is the kind of command, e.g. Page or Service
+	Group string
+	Title string
+	Run   tea.Cmd
+}
+
+// commandProvider is implemented by pages with commands of their own,
+// which are added to the command palette.
+type commandProvider interface {
+	Commands() []Command
+}
+
+// paletteRows is the max number of commands listed at once.
+const paletteRows = 12
+
+// CommandPaletteModel lets the user fuzzy find a command and run it.
+type CommandPaletteModel struct {
+	width int
+
+	input    textinput.Model
+	commands []Command
+	// matches are the commands matching the input, best match first
+	matches []Command
+	cursor  int
+	yOffset int
+}
+
+func NewCommandPaletteModel(commands []Command) CommandPaletteModel {
+	input := textinput.New()
+	input.Prompt = ": "
+	input.Placeholder = "go to, run or open…"
+	// A blinking cursor would need its ticks routed to the input
+	input.Cursor.SetMode(cursor.CursorStatic)
+	input.Focus()
+
+	m := CommandPaletteModel{
+		input:    input,
+		commands: commands,
+	}
+	m.updateMatches()
+
+	return m
+}
+
+func (m CommandPaletteModel) Update(msg tea.Msg) (CommandPaletteModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.NextCommand):
+			m.cursor += 1
+		case key.Matches(msg, keys.PreviousCommand):
+			m.cursor -= 1
+		default:
+			value := m.input.Value()
+			m.input, cmd = m.input.Update(msg)
+			if m.input.Value() != value {
+				m.cursor = 0
+				m.updateMatches()
+			}
+		}
+
+		m.clampCursor()
+	}
+
+	return m, cmd
+}
+
+// Selected returns the command under the cursor, if any commands match.
+func (m CommandPaletteModel) Selected() (Command, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.matches) {
+		return Command{}, false
+	}
+
+	return m.matches[m.cursor], true
+}
+
+// updateMatches finds the commands matching the input, keeping the order
+// they were given in for equally good matches.
+func (m *CommandPaletteModel) updateMatches() {
+	type match struct {
+		command Command
+		score   int
+	}
+
+	query := m.input.Value()
+	matches := make([]match, 0)
+	for _, c := range m.commands {
+		if score, ok := helpers.FuzzyMatch(query, c.Group+" "+c.Title); ok {
+			matches = append(matches, match{c, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return b.score - a.score
+	})
+
+	m.matches = make([]Command, len(matches))
+	for i, match := range matches {
+		m.matches[i] = match.command
+	}
+}
+
+func (m *CommandPaletteModel) clampCursor() {
+	m.cursor = max(0, helpers.Clamp(0, m.cursor, len(m.matches)-1))
+
+	if m.cursor >= m.yOffset+paletteRows {
+		m.yOffset = m.cursor - paletteRows + 1
+	} else if m.cursor < m.yOffset {
+		m.yOffset = m.cursor
+	}
+	m.yOffset = max(0, min(m.yOffset, len(m.matches)-paletteRows))
+}
+
+func (m CommandPaletteModel) View() string {
+	groupWidth := 0
+	for _, c := range m.matches {
+		groupWidth = max(groupWidth, lipgloss.Width(c.Group))
+	}
+
+	end := min(len(m.matches), m.yOffset+paletteRows)
+	rows := make([]string, 0, paletteRows)
+	for i := m.yOffset; i < end; i++ {
+		c := m.matches[i]
+
+		group := lipgloss.NewStyle().Faint(true).Width(groupWidth + 2)
+		title := lipgloss.NewStyle().Width(max(0, m.width-groupWidth-2)).MaxWidth(max(0, m.width-groupWidth-2))
+		if i == m.cursor {
+			group = helpers.Highlight(group.Faint(false), helpers.ColorPrimary, helpers.ColorPrimaryForeground)
+			title = helpers.Highlight(title, helpers.ColorPrimary, helpers.ColorPrimaryForeground)
+		}
+
+		rows = append(rows, helpers.HStack(group.Render(c.Group), title.Render(c.Title)))
+	}
+
+	if len(rows) == 0 {
+		rows = append(rows, lipgloss.NewStyle().Faint(true).Render("No matching commands"))
+	}
+
+	return helpers.VStack(
+		m.input.View(),
+		"", // spacer
+		lipgloss.NewStyle().Height(paletteRows).Render(helpers.VStack(rows...)),
+	)
+}
+
+func (m CommandPaletteModel) ShortHelp() []key.Binding {
+	return []key.Binding{
+		keys.PreviousCommand,
+		keys.NextCommand,
+		withHelp(keys.Select, "run"),
+		withHelp(keys.Back, "close"),
+	}
+}
+
+func (m CommandPaletteModel) FullHelp() [][]key.Binding {
+	return [][]key.Binding{m.ShortHelp()}
+}
+
+func (m *CommandPaletteModel) SetWidth(w int) {
+	m.width = w
+	m.input.Width = w - lipgloss.Width(m.input.Prompt) - 1
+}
@@ -0,0 +1,64 @@
+package ui_test
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fredrikaugust/otelly/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandPalette(t *testing.T) {
+	commands := []ui.Command{
+		{Group: "Page", Title: "Go to Spans"},
+		{Group: "Page", Title: "Go to Service map"},
+		{Group: "Service", Title: "checkout"},
+		{Group: "Action", Title: "Clear all spans, logs and metrics"},
+	}
+
+	newPalette := func(query string) ui.CommandPaletteModel {
+		m := ui.NewCommandPaletteModel(commands)
+		m.SetWidth(60)
+		if query != "" {
+			m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(query)})
+		}
+
+		return m
+	}
+
+	t.Run("lists every command without a query", func(t *testing.T) {
+		view := newPalette("").View()
+
+		for _, c := range commands {
+			assert.Contains(t, view, c.Title)
+		}
+	})
+
+	t.Run("fuzzy matches the query", func(t *testing.T) {
+		m := newPalette("srvmap")
+
+		view := m.View()
+		assert.Contains(t, view, "Go to Service map")
+		assert.NotContains(t, view, "checkout")
+
+		selected, ok := m.Selected()
+		assert.True(t, ok)
+		assert.Equal(t, "Go to Service map", selected.Title)
+	})
+
+	t.Run("moves between matches", func(t *testing.T) {
+		m, _ := newPalette("go to").Update(tea.KeyMsg{Type: tea.KeyDown})
+
+		selected, ok := m.Selected()
+		assert.True(t, ok)
+		assert.Equal(t, "Go to Service map", selected.Title)
+	})
+
+	t.Run("has nothing to select without matches", func(t *testing.T) {
+		m := newPalette("xyz")
+
+		_, ok := m.Selected()
+		assert.False(t, ok)
+		assert.Contains(t, m.View(), "No matching commands")
+	})
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"gopkg.in/yaml.v3"
+)
+
+// loadYAML parses the YAML file into v. A missing file isn't an error and
+// leaves v as it is, as all the config files are optional.
+func loadYAML(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("could not parse %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// LoadConfig loads the key bindings from keys.yaml, the theme from
+// theme.yaml and how to open source files from editor.yaml in the config
+// directory, e.g. ~/.config/otelly on Linux, and uses them. Without a
+// config directory the defaults are used.
+func LoadConfig() error {
+	keyMap, theme, editorConfig := DefaultKeyMap(), helpers.ThemeDracula, EditorConfig{}
+
+	if dir, err := os.UserConfigDir(); err == nil {
+		dir = filepath.Join(dir, "otelly")
+
+		if keyMap, err = LoadKeyMap(filepath.Join(dir, "keys.yaml")); err != nil {
+			return err
+		}
+		if theme, err = LoadTheme(filepath.Join(dir, "theme.yaml")); err != nil {
+			return err
+		}
+		if editorConfig, err = LoadEditorConfig(filepath.Join(dir, "editor.yaml")); err != nil {
+			return err
+		}
+	}
+
+	SetKeyMap(keyMap)
+	SetTheme(theme)
+	SetEditorConfig(editorConfig)
+
+	return nil
+}
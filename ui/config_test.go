@@ -0,0 +1,45 @@
+package ui_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredrikaugust/otelly/ui"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Cleanup(func() {
+		ui.SetKeyMap(ui.DefaultKeyMap())
+		helpers.SetTheme(helpers.ThemeDracula)
+	})
+
+	t.Run("uses the defaults without a config directory", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("HOME", "")
+
+		assert.Nil(t, ui.LoadConfig())
+		assert.Equal(t, "dracula", helpers.CurrentTheme().Name)
+	})
+
+	t.Run("uses the config files", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", dir)
+		assert.Nil(t, os.Mkdir(filepath.Join(dir, "otelly"), 0o755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, "otelly", "theme.yaml"), []byte("base: light\n"), 0o644))
+
+		assert.Nil(t, ui.LoadConfig())
+		assert.Equal(t, "light", helpers.CurrentTheme().Name)
+	})
+
+	t.Run("fails on a mistake in a config file", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", dir)
+		assert.Nil(t, os.Mkdir(filepath.Join(dir, "otelly"), 0o755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, "otelly", "keys.yaml"), []byte("sideways: [x]\n"), 0o644))
+
+		assert.ErrorContains(t, ui.LoadConfig(), "sideways")
+	})
+}
@@ -0,0 +1,226 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fredrikaugust/otelly/db"
+	"go.uber.org/zap"
+)
+
+// EditorConfig is how source files referenced by spans and logs are
+// opened.
+type EditorConfig struct {
+	// Command is the editor to run, with {file} and {line} replaced by
+	// where to open, e.g. "idea --line {line} {file}". It defaults to
+	// $VISUAL or $EDITOR, with the line passed the way the editor expects.
+	Command string `yaml:"command"`
+	// Paths maps the start of paths as seen by the instrumented services,
+	// e.g. /app in a container, to where the source is on this machine.
+	Paths map[string]string `yaml:"paths"`
+}
+
+// editorConfig is used by all models, see SetEditorConfig.
+var editorConfig EditorConfig
+
+// SetEditorConfig replaces how source files are opened.
+func SetEditorConfig(c EditorConfig) {
+	editorConfig = c
+}
+
+// LoadEditorConfig reads the editor config from the file, e.g.
+//
+//	command: code --goto {file}:{line}
+//	paths:
+//	  /app: ~/src/shop
+//
+// A missing file isn't an error, as the defaults work for most.
+func LoadEditorConfig(path string) (EditorConfig, error) {
+	var c EditorConfig
+	err := loadYAML(path, &c)
+
+	return c, err
+}
+
+// MapPath replaces the longest matching start of the path from Paths, and
+// expands ~ to the home directory.
+func (c EditorConfig) MapPath(path string) string {
+	from := ""
+	for prefix := range c.Paths {
+		if len(prefix) > len(from) && (path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")) {
+			from = prefix
+		}
+	}
+	if from != "" {
+		path = strings.TrimSuffix(c.Paths[from], "/") + strings.TrimPrefix(path, strings.TrimSuffix(from, "/"))
+	}
+
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+
+	return path
+}
+
+// Args is the command line which opens the file at the line. A line of 0
+// opens the file at the top.
+func (c EditorConfig) Args(file string, line int) []string {
+	// Blank ones are skipped, as there'd be nothing to run
+	command := strings.TrimSpace(c.Command)
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if command == "" {
+			command = strings.TrimSpace(os.Getenv(env))
+		}
+	}
+	if command == "" {
+		command = "vi"
+	}
+
+	args := strings.Fields(command)
+	if strings.Contains(command, "{file}") {
+		for i, arg := range args {
+			args[i] = strings.NewReplacer("{file}", file, "{line}", strconv.Itoa(max(1, line))).Replace(arg)
+		}
+		return args
+	}
+
+	if line <= 0 {
+		return append(args, file)
+	}
+
+	switch filepath.Base(args[0]) {
+	case "code", "code-insiders", "codium", "cursor":
+		return append(args, "--goto", fmt.Sprintf("%s:%d", file, line))
+	case "subl", "hx", "helix", "zed":
+		return append(args, fmt.Sprintf("%s:%d", file, line))
+	default:
+		// vi, vim, nvim, emacs, nano, micro, kak and most others
+		return append(args, fmt.Sprintf("+%d", line), file)
+	}
+}
+
+// CodeLocation is a place in the source of an instrumented service.
+type CodeLocation struct {
+	File     string
+	Line     int
+	Function string
+}
+
+func (l CodeLocation) String() string {
+	s := l.File
+	if l.Line > 0 {
+		s += ":" + strconv.Itoa(l.Line)
+	}
+	if l.Function != "" {
+		s += " (" + l.Function + ")"
+	}
+
+	return s
+}
+
+var (
+	// stackFrame finds e.g. /app/handler.go:42 in Go, Node and Rust stack
+	// traces
+	stackFrame = regexp.MustCompile(`((?:[A-Za-z]:)?[\w.~/\\-]*[/\\][\w.-]+\.\w+):(\d+)`)
+	// pythonStackFrame finds File "/app/handler.py", line 42
+	pythonStackFrame = regexp.MustCompile(`File "([^"]+)", line (\d+)`)
+)
+
+// CodeLocationOf finds the source location in the attributes of a span or
+// log, from the code.* attributes or else the top frame of an exception
+// stack trace. The stack traces of the exception events of a span are
+// looked at last, as that's where most SDKs record exceptions.
+func CodeLocationOf(attributes map[string]any, events ...db.SpanEvent) (CodeLocation, bool) {
+	str := func(keys ...string) string {
+		for _, k := range keys {
+			if s, ok := attributes[k].(string); ok && s != "" {
+				return s
+			}
+		}
+		return ""
+	}
+	number := func(keys ...string) int {
+		for _, k := range keys {
+			switch v := attributes[k].(type) {
+			case float64:
+				return int(v)
+			case int64:
+				return int(v)
+			case string:
+				if i, err := strconv.Atoi(v); err == nil {
+					return i
+				}
+			}
+		}
+		return 0
+	}
+
+	// The newer names are from v1.30 of the semantic conventions
+	if file := str("code.file.path", "code.filepath"); file != "" {
+		function := str("code.function.name", "code.function")
+		if namespace := str("code.namespace"); namespace != "" && function != "" {
+			function = namespace + "." + function
+		}
+
+		return CodeLocation{file, number("code.line.number", "code.lineno"), function}, true
+	}
+
+	stacktrace := str("exception.stacktrace")
+	for _, re := range []*regexp.Regexp{pythonStackFrame, stackFrame} {
+		// Python lists the innermost frame last
+		matches := re.FindAllStringSubmatch(stacktrace, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		match := matches[0]
+		if re == pythonStackFrame {
+			match = matches[len(matches)-1]
+		}
+		line, _ := strconv.Atoi(match[2])
+
+		return CodeLocation{File: match[1], Line: line}, true
+	}
+
+	for _, event := range events {
+		if event.Name != "exception" {
+			continue
+		}
+		if loc, ok := CodeLocationOf(map[string]any{"exception.stacktrace": event.Attributes["exception.stacktrace"]}); ok {
+			return loc, true
+		}
+	}
+
+	return CodeLocation{}, false
+}
+
+// openInEditor suspends the UI while the editor is open on the location.
+func openInEditor(loc CodeLocation) tea.Cmd {
+	file := editorConfig.MapPath(loc.File)
+	if _, err := os.Stat(file); err != nil {
+		zap.L().Warn("could not find source file", zap.String("file", file), zap.Error(err))
+		return func() tea.Msg {
+			return MsgStatus{fmt.Sprintf("Could not find %s, map its path in editor.yaml", file)}
+		}
+	}
+
+	args := editorConfig.Args(file, loc.Line)
+	cmd := exec.Command(args[0], args[1:]...)
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			zap.L().Warn("editor failed", zap.Strings("args", args), zap.Error(err))
+			return MsgStatus{fmt.Sprintf("Could not open editor: %v", err)}
+		}
+
+		return nil
+	})
+}
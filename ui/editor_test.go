@@ -0,0 +1,139 @@
+package ui_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadEditorConfig(t *testing.T) {
+	t.Run("uses the defaults without a file", func(t *testing.T) {
+		c, err := ui.LoadEditorConfig(filepath.Join(t.TempDir(), "editor.yaml"))
+
+		assert.Nil(t, err)
+		assert.Equal(t, ui.EditorConfig{}, c)
+	})
+
+	t.Run("reads the command and paths", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "editor.yaml")
+		assert.Nil(t, os.WriteFile(path, []byte("command: idea --line {line} {file}\npaths:\n  /app: /home/me/shop\n"), 0o644))
+
+		c, err := ui.LoadEditorConfig(path)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "idea --line {line} {file}", c.Command)
+		assert.Equal(t, map[string]string{"/app": "/home/me/shop"}, c.Paths)
+	})
+}
+
+func TestEditorConfig(t *testing.T) {
+	t.Run("maps the longest matching path", func(t *testing.T) {
+		c := ui.EditorConfig{Paths: map[string]string{
+			"/app":        "/src/shop",
+			"/app/vendor": "/src/vendor/",
+		}}
+
+		assert.Equal(t, "/src/shop/cmd/main.go", c.MapPath("/app/cmd/main.go"))
+		assert.Equal(t, "/src/vendor/lib/db.go", c.MapPath("/app/vendor/lib/db.go"))
+		assert.Equal(t, "/application/main.go", c.MapPath("/application/main.go"))
+	})
+
+	for _, tc := range []struct {
+		command  string
+		expected []string
+	}{
+		{"nvim", []string{"nvim", "+42", "/src/main.go"}},
+		{"emacs -nw", []string{"emacs", "-nw", "+42", "/src/main.go"}},
+		{"code --wait", []string{"code", "--wait", "--goto", "/src/main.go:42"}},
+		{"/usr/bin/hx", []string{"/usr/bin/hx", "/src/main.go:42"}},
+		{"idea --line {line} {file}", []string{"idea", "--line", "42", "/src/main.go"}},
+	} {
+		t.Run("passes the line to "+tc.command, func(t *testing.T) {
+			c := ui.EditorConfig{Command: tc.command}
+
+			assert.Equal(t, tc.expected, c.Args("/src/main.go", 42))
+		})
+	}
+
+	t.Run("falls back to $EDITOR", func(t *testing.T) {
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", "nano")
+
+		assert.Equal(t, []string{"nano", "/src/main.go"}, ui.EditorConfig{}.Args("/src/main.go", 0))
+	})
+
+	t.Run("skips blank commands", func(t *testing.T) {
+		t.Setenv("VISUAL", "  ")
+		t.Setenv("EDITOR", "")
+
+		assert.Equal(t, []string{"vi", "/src/main.go"}, ui.EditorConfig{Command: " "}.Args("/src/main.go", 0))
+	})
+}
+
+func TestCodeLocationOf(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		attributes map[string]any
+		events     []db.SpanEvent
+		expected   ui.CodeLocation
+		ok         bool
+	}{
+		{
+			"code attributes",
+			map[string]any{"code.filepath": "/app/handler.go", "code.lineno": 42.0, "code.function": "ServeHTTP", "code.namespace": "api.Handler"},
+			nil,
+			ui.CodeLocation{"/app/handler.go", 42, "api.Handler.ServeHTTP"},
+			true,
+		},
+		{
+			"newer code attributes",
+			map[string]any{"code.file.path": "/app/handler.py", "code.line.number": 7.0, "code.function.name": "handle"},
+			nil,
+			ui.CodeLocation{"/app/handler.py", 7, "handle"},
+			true,
+		},
+		{
+			"go stack trace",
+			map[string]any{"exception.stacktrace": "goroutine 1 [running]:\nmain.handle()\n\t/app/handler.go:42 +0x1d\nmain.main()\n\t/app/main.go:10 +0x25"},
+			nil,
+			ui.CodeLocation{File: "/app/handler.go", Line: 42},
+			true,
+		},
+		{
+			"python stack trace",
+			map[string]any{"exception.stacktrace": "Traceback (most recent call last):\n  File \"/app/main.py\", line 10, in <module>\n  File \"/app/handler.py\", line 42, in handle\nValueError"},
+			nil,
+			ui.CodeLocation{File: "/app/handler.py", Line: 42},
+			true,
+		},
+		{
+			"exception events",
+			map[string]any{"http.method": "GET"},
+			[]db.SpanEvent{
+				{Name: "retry", Attributes: map[string]any{"exception.stacktrace": "at /app/retry.go:3"}},
+				{Name: "exception", Attributes: map[string]any{"exception.type": "*errors.errorString"}},
+				{Name: "exception", Attributes: map[string]any{"exception.stacktrace": "main.handle()\n\t/app/handler.go:42 +0x1d"}},
+			},
+			ui.CodeLocation{File: "/app/handler.go", Line: 42},
+			true,
+		},
+		{
+			"no location",
+			map[string]any{"http.method": "GET"},
+			nil,
+			ui.CodeLocation{},
+			false,
+		},
+	} {
+		t.Run("finds the location in "+tc.name, func(t *testing.T) {
+			loc, ok := ui.CodeLocationOf(tc.attributes, tc.events...)
+
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, loc)
+		})
+	}
+}
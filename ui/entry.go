@@ -2,13 +2,19 @@
 package ui
 
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"slices"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/fredrikaugust/otelly/bus"
 	"github.com/fredrikaugust/otelly/db"
 	"github.com/fredrikaugust/otelly/ui/helpers"
+	"github.com/muesli/termenv"
 	"go.uber.org/zap"
 )
 
@@ -16,6 +22,10 @@ type Page uint
 
 const (
 	PageSpans Page = iota
+	PageFlamegraph
+	PageServiceMap
+	PageServices
+	PageLogs
 )
 
 type EntryModel struct {
@@ -27,9 +37,34 @@ type EntryModel struct {
 	spans []db.Span
 	logs  []db.Log
 
-	spansPageModel SpansPageModel
+	spansPageModel      SpansPageModel
+	flamegraphPageModel FlamegraphPageModel
+	serviceMapPageModel ServiceMapPageModel
+	servicesPageModel   ServicesPageModel
+	logsPageModel       LogsPageModel
+
+	// showHelp is set while the help overlay with every key binding of
+	// the current page is shown
+	showHelp  bool
+	helpModel help.Model
+
+	// showPalette is set while the command palette is open
+	showPalette  bool
+	paletteModel CommandPaletteModel
+
+	// showTracePrompt is set while asking for a trace ID to open
+	showTracePrompt  bool
+	tracePromptModel TracePromptModel
+	// pendingTraceID is a trace which was asked for before it arrived, to
+	// open once it does
+	pendingTraceID string
+
+	// status is a message about something that happened, e.g. a failed
+	// command, shown until the next key press
+	status string
 
 	bus *bus.TransportBus
+	db  *db.Database
 }
 
 func NewEntryModel(spans []db.Span, logs []db.Log, bus *bus.TransportBus, database *db.Database) tea.Model {
@@ -38,23 +73,33 @@ func NewEntryModel(spans []db.Span, logs []db.Log, bus *bus.TransportBus, databa
 		spans:       spans,
 		logs:        logs,
 
-		spansPageModel: NewSpansPageModel(db.FilterRootSpans(spans), database),
-		bus:            bus,
+		spansPageModel:      NewSpansPageModel(db.FilterRootSpans(spans), database),
+		flamegraphPageModel: NewFlamegraphPageModel(database),
+		serviceMapPageModel: NewServiceMapPageModel(spans),
+		servicesPageModel:   NewServicesPageModel(database),
+		logsPageModel:       NewLogsPageModel(database),
+		helpModel:           newHelpModel(),
+		bus:                 bus,
+		db:                  database,
 	}
 }
 
 func (m EntryModel) Init() tea.Cmd {
 	return tea.Batch(
 		m.spansPageModel.Init(),
+		m.flamegraphPageModel.Init(),
+		m.serviceMapPageModel.Init(),
+		m.servicesPageModel.Init(),
+		m.logsPageModel.Init(),
 		m.listenForLogs(),
 		m.listenForSpans(),
+		m.listenForStatus(),
 	)
 }
 
 func (m EntryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	zap.L().Debug("received tea.Msg", zap.String("type", reflect.TypeOf(msg).Name()))
 
-	var cmd tea.Cmd
 	cmds := make([]tea.Cmd, 0)
 
 	switch msg := msg.(type) {
@@ -62,28 +107,355 @@ func (m EntryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		m.width = msg.Width
 
-		m.spansPageModel.SetHeight(msg.Height - 3) // - header
+		m.helpModel.Width = msg.Width
+		m.paletteModel.SetWidth(m.paletteWidth())
+		m.tracePromptModel.SetWidth(m.paletteWidth())
+
+		m.spansPageModel.SetHeight(m.pageHeight())
 		m.spansPageModel.SetWidth(msg.Width)
+		m.flamegraphPageModel.SetHeight(m.pageHeight())
+		m.flamegraphPageModel.SetWidth(msg.Width)
+		m.serviceMapPageModel.SetHeight(m.pageHeight())
+		m.serviceMapPageModel.SetWidth(msg.Width)
+		m.servicesPageModel.SetHeight(m.pageHeight())
+		m.servicesPageModel.SetWidth(msg.Width)
+		m.logsPageModel.SetHeight(m.pageHeight())
+		m.logsPageModel.SetWidth(msg.Width)
 	case tea.KeyMsg:
-		switch msg.String() {
-		case tea.KeyCtrlC.String(), "q":
+		m.status = ""
+
+		if m.showPalette {
+			return m.updatePalette(msg)
+		}
+
+		if m.showTracePrompt {
+			return m.updateTracePrompt(msg)
+		}
+
+		// Typing in the page may take any other key, but ctrl+c always
+		// quits, as in the palette and the trace prompt
+		if msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+
+		if m.capturesKeys() {
+			break
+		}
+
+		switch {
+		case key.Matches(msg, keys.Quit):
 			cmds = append(cmds, tea.Quit)
+		case key.Matches(msg, keys.Help):
+			m.showHelp = !m.showHelp
+			return m, nil
+		case m.showHelp:
+			// Keys shouldn't act on the page hidden behind the help
+			if key.Matches(msg, keys.Back) {
+				m.showHelp = false
+			}
+			return m, nil
+		case key.Matches(msg, keys.Palette):
+			m.showPalette = true
+			m.paletteModel = NewCommandPaletteModel(m.commands())
+			m.paletteModel.SetWidth(m.paletteWidth())
+			return m, nil
+		case key.Matches(msg, keys.OpenTrace):
+			return m.openTracePrompt()
+		case key.Matches(msg, keys.SpansPage):
+			m.currentPage = PageSpans
+			return m, nil
+		case key.Matches(msg, keys.FlamegraphPage):
+			m.currentPage = PageFlamegraph
+			return m, nil
+		case key.Matches(msg, keys.ServiceMapPage):
+			m.currentPage = PageServiceMap
+			return m, nil
+		case key.Matches(msg, keys.ServicesPage):
+			m.currentPage = PageServices
+			return m, nil
+		case key.Matches(msg, keys.LogsPage):
+			m.currentPage = PageLogs
+			return m, nil
+		}
+	case tea.MouseMsg:
+		// The overlays don't take the mouse, and it shouldn't act on what's
+		// hidden behind them
+		if m.showHelp || m.showPalette || m.showTracePrompt {
+			return m, nil
 		}
+
+		if msg.Y < headerHeight {
+			if page, ok := m.pageAt(msg.X); ok && msg.Y == 1 && msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+				m.currentPage = page
+			}
+			return m, nil
+		}
+
+		return m, tea.Batch(m.updatePages(translateMouse(msg, 0, headerHeight))...)
 	case MsgNewSpans:
 		cmds = append(cmds, m.listenForSpans())
 		m.updateSpans(msg.spans)
+
+		if spans := traceSpans(m.spans, m.pendingTraceID); m.pendingTraceID != "" && len(spans) > 0 {
+			cmds = append(cmds, helpers.Cmdize(MsgOpenTrace{traceID: m.pendingTraceID, spans: spans}))
+			m.status = "Opened trace " + m.pendingTraceID
+			m.pendingTraceID = ""
+		}
 	case MsgNewLogs:
 		cmds = append(cmds, m.listenForLogs())
 		m.updateLogs(msg.logs)
+	case MsgNewStatus:
+		m.status = msg.text
+		return m, m.listenForStatus()
+	case MsgOpenTrace:
+		spans := msg.spans
+		if spans == nil {
+			spans = traceSpans(m.spans, msg.traceID)
+		}
+
+		if m.spansPageModel.SelectTrace(msg.traceID, spans) {
+			m.currentPage = PageSpans
+		} else {
+			m.waitForTrace(msg.traceID)
+		}
+	case MsgPromptTrace:
+		return m.openTracePrompt()
+	case MsgClipboardRead:
+		m.tracePromptModel, _ = m.tracePromptModel.Update(msg)
+		return m, nil
+	case MsgTraceNotFound:
+		m.waitForTrace(msg.traceID)
+		return m, nil
+	case MsgStopWaitingForTrace:
+		m.pendingTraceID = ""
+		return m, nil
+	case MsgOpenService:
+		m.currentPage = PageServices
+	case MsgOpenRootSpan:
+		m.currentPage = PageFlamegraph
+	case MsgShowServiceLogs, MsgSearchLogs:
+		m.currentPage = PageLogs
+	case MsgSwitchPage:
+		m.currentPage = msg.page
+		return m, nil
+	case MsgStatus:
+		m.status = msg.text
+		return m, nil
+	case MsgSetTheme:
+		SetTheme(msg.theme)
+		// The help styles are made with the colors of the theme
+		m.helpModel = newHelpModel()
+		m.helpModel.Width = m.width
+		return m, nil
+	case MsgClearData:
+		return m, m.clearData()
+	case MsgDataCleared:
+		m.updateSpans(nil)
+		m.updateLogs(nil)
+		m.status = "Cleared all spans, logs and metrics"
+
+		// The pages reload what they show when told about new data
+		cmds = append(cmds, m.updatePages(MsgNewSpans{})...)
+		cmds = append(cmds, m.updatePages(MsgNewLogs{})...)
+		return m, tea.Batch(cmds...)
 	}
 
-	switch m.currentPage {
-	case PageSpans:
+	cmds = append(cmds, m.updatePages(msg)...)
+
+	return m, tea.Batch(cmds...)
+}
+
+// updatePages passes the message on to the pages.
+func (m *EntryModel) updatePages(msg tea.Msg) []tea.Cmd {
+	var cmd tea.Cmd
+	cmds := make([]tea.Cmd, 0)
+
+	// Key presses and the mouse only go to the page being shown,
+	// everything else goes to all pages so they're up to date when
+	// switching.
+	toCurrent := false
+	switch msg.(type) {
+	case tea.KeyMsg, tea.MouseMsg:
+		toCurrent = true
+	}
+
+	if !toCurrent || m.currentPage == PageSpans {
 		m.spansPageModel, cmd = m.spansPageModel.Update(msg)
 		cmds = append(cmds, cmd)
 	}
+	if !toCurrent || m.currentPage == PageFlamegraph {
+		m.flamegraphPageModel, cmd = m.flamegraphPageModel.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	if !toCurrent || m.currentPage == PageServiceMap {
+		m.serviceMapPageModel, cmd = m.serviceMapPageModel.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	if !toCurrent || m.currentPage == PageServices {
+		m.servicesPageModel, cmd = m.servicesPageModel.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	if !toCurrent || m.currentPage == PageLogs {
+		m.logsPageModel, cmd = m.logsPageModel.Update(msg)
+		cmds = append(cmds, cmd)
+	}
 
-	return m, tea.Batch(cmds...)
+	return cmds
+}
+
+func (m EntryModel) openTracePrompt() (tea.Model, tea.Cmd) {
+	m.showTracePrompt = true
+	m.tracePromptModel = NewTracePromptModel()
+	m.tracePromptModel.SetWidth(m.paletteWidth())
+
+	return m, m.tracePromptModel.Init()
+}
+
+func (m EntryModel) updateTracePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case key.Matches(msg, keys.Back):
+		m.showTracePrompt = false
+		return m, nil
+	case key.Matches(msg, keys.Select):
+		traceID, err := m.tracePromptModel.TraceID()
+		if err != nil {
+			return m, nil
+		}
+
+		m.showTracePrompt = false
+		return m, m.findTrace(traceID)
+	}
+
+	m.tracePromptModel, cmd = m.tracePromptModel.Update(msg)
+
+	return m, cmd
+}
+
+// findTrace opens the trace if it has arrived, and otherwise waits for it.
+func (m EntryModel) findTrace(traceID string) tea.Cmd {
+	return func() tea.Msg {
+		spans, err := m.db.GetSpansForTrace(context.Background(), traceID)
+		if err != nil {
+			zap.L().Warn("could not get spans for trace", zap.String("traceID", traceID), zap.Error(err))
+			return MsgStatus{fmt.Sprintf("Could not look up trace: %v", err)}
+		}
+
+		if len(spans) == 0 {
+			return MsgTraceNotFound{traceID}
+		}
+
+		return MsgOpenTrace{traceID, spans}
+	}
+}
+
+// traceSpans are those of the spans which are in the trace.
+func traceSpans(spans []db.Span, traceID string) []db.Span {
+	return slices.DeleteFunc(slices.Clone(spans), func(span db.Span) bool { return span.TraceID != traceID })
+}
+
+func (m *EntryModel) waitForTrace(traceID string) {
+	zap.L().Info("waiting for trace", zap.String("traceID", traceID))
+
+	m.pendingTraceID = traceID
+	m.status = fmt.Sprintf("Trace %s hasn't arrived yet, it will be opened when it does", traceID)
+}
+
+func (m EntryModel) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case key.Matches(msg, keys.Back):
+		m.showPalette = false
+		return m, nil
+	case key.Matches(msg, keys.Select):
+		m.showPalette = false
+		if c, ok := m.paletteModel.Selected(); ok {
+			return m, c.Run
+		}
+		return m, nil
+	}
+
+	m.paletteModel, cmd = m.paletteModel.Update(msg)
+
+	return m, cmd
+}
+
+// headerHeight is the height of the header with its border.
+const headerHeight = 3
+
+// pageTitles are the names of the pages in the order of Page.
+var pageTitles = []string{"Spans", "Flamegraph", "Service map", "Services", "Logs"}
+
+// commands lists everything that can be done from the command palette,
+// starting with the commands of the current page.
+func (m EntryModel) commands() []Command {
+	providers := []commandProvider{
+		m.spansPageModel,
+		m.flamegraphPageModel,
+		m.serviceMapPageModel,
+		m.servicesPageModel,
+		m.logsPageModel,
+	}
+
+	commands := slices.Clone(providers[m.currentPage].Commands())
+
+	for i, title := range pageTitles {
+		commands = append(commands, Command{"Page", "Go to " + title, helpers.Cmdize(MsgSwitchPage{Page(i)})})
+	}
+	commands = append(commands,
+		Command{"Action", "Open trace by ID", helpers.Cmdize(MsgPromptTrace{})},
+		Command{"Action", "Clear all spans, logs and metrics", helpers.Cmdize(MsgClearData{})},
+	)
+	if m.pendingTraceID != "" {
+		commands = append(commands, Command{"Action", "Stop waiting for trace " + m.pendingTraceID, helpers.Cmdize(MsgStopWaitingForTrace{})})
+	}
+
+	// With NO_COLOR set there's only the theme without colors
+	if !termenv.EnvNoColor() {
+		themes := helpers.Themes
+		if _, ok := builtinTheme(helpers.CurrentTheme().Name); !ok {
+			// A theme from the theme file
+			themes = append([]helpers.Theme{helpers.CurrentTheme()}, themes...)
+		}
+		for _, t := range themes {
+			commands = append(commands, Command{"Theme", "Use " + t.Name + " theme", helpers.Cmdize(MsgSetTheme{t})})
+		}
+	}
+
+	for i, p := range providers {
+		if Page(i) != m.currentPage {
+			commands = append(commands, p.Commands()...)
+		}
+	}
+
+	return commands
+}
+
+func (m EntryModel) clearData() tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+
+		if err := m.db.ClearSpans(ctx); err != nil {
+			zap.L().Warn("could not clear spans", zap.Error(err))
+			return MsgStatus{fmt.Sprintf("Could not clear spans: %v", err)}
+		}
+		if err := m.db.ClearLogs(ctx); err != nil {
+			zap.L().Warn("could not clear logs", zap.Error(err))
+			return MsgStatus{fmt.Sprintf("Could not clear logs: %v", err)}
+		}
+		if err := m.db.ClearMetrics(ctx); err != nil {
+			zap.L().Warn("could not clear metrics", zap.Error(err))
+			return MsgStatus{fmt.Sprintf("Could not clear metrics: %v", err)}
+		}
+
+		return MsgDataCleared{}
+	}
 }
 
 func (m EntryModel) View() string {
@@ -92,6 +464,26 @@ func (m EntryModel) View() string {
 	switch m.currentPage {
 	case PageSpans:
 		page = m.spansPageModel.View()
+	case PageFlamegraph:
+		page = m.flamegraphPageModel.View()
+	case PageServiceMap:
+		page = m.serviceMapPageModel.View()
+	case PageServices:
+		page = m.servicesPageModel.View()
+	case PageLogs:
+		page = m.logsPageModel.View()
+	}
+
+	if m.showHelp {
+		page = m.helpView()
+	}
+
+	if m.showPalette {
+		page = m.overlayView(m.paletteModel.View())
+	}
+
+	if m.showTracePrompt {
+		page = m.overlayView(m.tracePromptModel.View())
 	}
 
 	return lipgloss.NewStyle().
@@ -103,6 +495,7 @@ func (m EntryModel) View() string {
 			helpers.VStack(
 				m.HeaderView(),
 				page,
+				m.footerView(),
 			),
 		)
 }
@@ -116,18 +509,170 @@ func (m EntryModel) HeaderView() string {
 		Padding(0, 1).
 		Height(1)
 
-	spans := helpers.NavigationPillBaseStyle
+	pills := helpers.HStack(m.pillViews()...)
+
+	statusWidth := max(0, m.width-4-lipgloss.Width(pills))
+	status := lipgloss.NewStyle().
+		Width(statusWidth).
+		MaxWidth(statusWidth).
+		Align(lipgloss.Right).
+		Foreground(helpers.ColorSecondary).
+		Render(m.statusText())
+
+	return container.Render(helpers.HStack(pills, status))
+}
+
+// pillViews are the navigation pills of the pages in the header.
+func (m EntryModel) pillViews() []string {
+	pills := make([]string, len(pageTitles))
+	for i, title := range pageTitles {
+		style := helpers.NavigationPillBaseStyle
+		if Page(i) == m.currentPage {
+			style = helpers.Highlight(style, helpers.ColorSecondary, helpers.ColorSecondaryForeground)
+		}
+		pills[i] = style.Render(fmt.Sprintf("%d %s", i+1, title))
+	}
+
+	return pills
+}
+
+// pageAt returns the page whose pill is at x in the header, if any.
+func (m EntryModel) pageAt(x int) (Page, bool) {
+	// Inside the border and padding
+	right := 2
+	for i, pill := range m.pillViews() {
+		right += lipgloss.Width(pill)
+		if x >= 2 && x < right {
+			return Page(i), true
+		}
+	}
+
+	return 0, false
+}
+
+// statusText is the status, or the trace being waited for when there's
+// nothing else to say.
+func (m EntryModel) statusText() string {
+	if m.status == "" && m.pendingTraceID != "" {
+		return "Waiting for trace " + m.pendingTraceID
+	}
 
+	return m.status
+}
+
+// pageHeight is the height left for pages below the header and above the
+// footer.
+func (m EntryModel) pageHeight() int {
+	return m.height - headerHeight - 1
+}
+
+func newHelpModel() help.Model {
+	h := help.New()
+
+	keyStyle := lipgloss.NewStyle().Foreground(helpers.ColorPrimary)
+	descStyle := lipgloss.NewStyle().Foreground(helpers.ColorMutedForeground)
+	sepStyle := lipgloss.NewStyle().Foreground(helpers.ColorMuted)
+
+	h.Styles = help.Styles{
+		ShortKey:       keyStyle,
+		ShortDesc:      descStyle,
+		ShortSeparator: sepStyle,
+		Ellipsis:       sepStyle,
+		FullKey:        keyStyle,
+		FullDesc:       descStyle,
+		FullSeparator:  sepStyle,
+	}
+
+	return h
+}
+
+// currentHelp lists the key bindings of the current page.
+func (m EntryModel) currentHelp() help.KeyMap {
 	switch m.currentPage {
-	case PageSpans:
-		spans = spans.Background(helpers.ColorSecondary).Foreground(helpers.ColorSecondaryForeground)
+	case PageFlamegraph:
+		return m.flamegraphPageModel
+	case PageServiceMap:
+		return m.serviceMapPageModel
+	case PageServices:
+		return m.servicesPageModel
+	case PageLogs:
+		return m.logsPageModel
 	}
 
-	return container.Render(
-		helpers.HStack(
-			spans.Render("Spans"),
-		),
+	return m.spansPageModel
+}
+
+// footerView hints at the most useful keys of the current page.
+func (m EntryModel) footerView() string {
+	if m.showPalette {
+		return lipgloss.NewStyle().Padding(0, 1).Render(m.helpModel.ShortHelpView(m.paletteModel.ShortHelp()))
+	}
+
+	if m.showTracePrompt {
+		return lipgloss.NewStyle().Padding(0, 1).Render(m.helpModel.ShortHelpView(m.tracePromptModel.ShortHelp()))
+	}
+
+	bindings := m.currentHelp().ShortHelp()
+	if !m.capturesKeys() {
+		bindings = append(bindings, keys.Palette, keys.Help, keys.Quit)
+	}
+
+	return lipgloss.NewStyle().Padding(0, 1).Render(m.helpModel.ShortHelpView(bindings))
+}
+
+// helpView lists every key binding of the current page along with the
+// global ones.
+func (m EntryModel) helpView() string {
+	columns := append(
+		m.currentHelp().FullHelp(),
+		[]key.Binding{
+			keys.SpansPage,
+			keys.FlamegraphPage,
+			keys.ServiceMapPage,
+			keys.ServicesPage,
+			keys.LogsPage,
+			keys.OpenTrace,
+			keys.Palette,
+			keys.Help,
+			keys.Quit,
+		},
 	)
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(helpers.ColorPrimary).
+		Padding(1, 2).
+		Render(
+			helpers.VStack(
+				lipgloss.NewStyle().Bold(true).Render("Keys"),
+				"", // spacer
+				m.helpModel.FullHelpView(columns),
+			),
+		)
+
+	return lipgloss.Place(m.width, m.pageHeight(), lipgloss.Center, lipgloss.Center, box)
+}
+
+func (m EntryModel) paletteWidth() int {
+	return min(80, m.width-8)
+}
+
+// overlayView shows the palette or a prompt near the top, where the eyes
+// are after pressing a key to open it.
+func (m EntryModel) overlayView(content string) string {
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(helpers.ColorPrimary).
+		Padding(0, 1).
+		Render(content)
+
+	return lipgloss.Place(m.width, m.pageHeight(), lipgloss.Center, lipgloss.Top, lipgloss.NewStyle().MarginTop(1).Render(box))
+}
+
+// capturesKeys is true when the current page is taking text input, in
+// which case keys like q shouldn't do what they usually do.
+func (m EntryModel) capturesKeys() bool {
+	return m.currentPage == PageLogs && m.logsPageModel.CapturesKeys()
 }
 
 func (m EntryModel) listenForSpans() tea.Cmd {
@@ -142,6 +687,12 @@ func (m EntryModel) listenForLogs() tea.Cmd {
 	}
 }
 
+func (m EntryModel) listenForStatus() tea.Cmd {
+	return func() tea.Msg {
+		return MsgNewStatus{<-m.bus.StatusBus}
+	}
+}
+
 func (m *EntryModel) updateSpans(spans []db.Span) {
 	m.spans = spans
 	m.spansPageModel.SetSpans(db.FilterRootSpans(spans))
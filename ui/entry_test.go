@@ -0,0 +1,215 @@
+package ui_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/bus"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestEntryKeys(t *testing.T) {
+	database, err := db.NewDB(":memory:")
+	assert.Nil(t, err)
+	defer database.Close()
+	assert.Nil(t, database.Migrate(t.Context()))
+
+	newEntry := func() tea.Model {
+		m := ui.NewEntryModel(nil, nil, bus.NewTransportBus(), database)
+		m, _ = m.Update(tea.WindowSizeMsg{Width: 140, Height: 30})
+
+		return m
+	}
+
+	press := func(m tea.Model, r rune) tea.Model {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+
+		return m
+	}
+
+	t.Run("hints at keys of the current page", func(t *testing.T) {
+		m := newEntry()
+		assert.Contains(t, m.View(), "mark trace")
+
+		m = press(m, '5')
+		assert.Contains(t, m.View(), "min severity")
+		assert.NotContains(t, m.View(), "mark trace")
+	})
+
+	t.Run("toggles the help overlay", func(t *testing.T) {
+		m := press(newEntry(), '?')
+		assert.Contains(t, m.View(), "pgdown/ctrl+d")
+
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.NotContains(t, m.View(), "pgdown/ctrl+d")
+	})
+
+	t.Run("uses remapped keys", func(t *testing.T) {
+		km := ui.DefaultKeyMap()
+		km.LogsPage = key.NewBinding(key.WithKeys("L"), key.WithHelp("L", "logs"))
+		ui.SetKeyMap(km)
+		t.Cleanup(func() { ui.SetKeyMap(ui.DefaultKeyMap()) })
+
+		m := press(newEntry(), '5')
+		assert.Contains(t, m.View(), "mark trace")
+
+		m = press(m, 'L')
+		assert.Contains(t, m.View(), "min severity")
+	})
+
+	t.Run("quits with ctrl+c while searching logs", func(t *testing.T) {
+		m := press(press(newEntry(), '5'), '/')
+		assert.Contains(t, m.View(), "text or field=value")
+
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+
+		assert.NotNil(t, cmd)
+		assert.Equal(t, tea.QuitMsg{}, cmd())
+	})
+
+	t.Run("runs commands from the palette", func(t *testing.T) {
+		m := press(newEntry(), ':')
+		assert.Contains(t, m.View(), "Go to Service map")
+
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("search logs")})
+		m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NotNil(t, cmd)
+
+		m, _ = m.Update(cmd())
+		view := m.View()
+		assert.NotContains(t, view, "Go to Service map")
+		assert.Contains(t, view, "text or field=value")
+	})
+
+	t.Run("changes theme from the palette", func(t *testing.T) {
+		t.Cleanup(func() { helpers.SetTheme(helpers.ThemeDracula) })
+
+		m := press(newEntry(), ':')
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("light theme")})
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m.Update(cmd())
+
+		assert.Equal(t, "light", helpers.CurrentTheme().Name)
+	})
+
+	t.Run("keeps colors off with NO_COLOR", func(t *testing.T) {
+		profile := lipgloss.ColorProfile()
+		t.Cleanup(func() {
+			lipgloss.SetColorProfile(profile)
+			helpers.SetTheme(helpers.ThemeDracula)
+		})
+
+		m := press(newEntry(), ':')
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("light theme")})
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		setTheme := cmd()
+
+		t.Setenv("NO_COLOR", "1")
+		ui.SetTheme(helpers.ThemeDracula)
+
+		m.Update(setTheme)
+		assert.Equal(t, helpers.ThemeNoColor.Name, helpers.CurrentTheme().Name)
+
+		m = press(newEntry(), ':')
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("theme")})
+		assert.NotContains(t, m.View(), "Use light theme")
+	})
+
+	t.Run("switches page when clicking its pill", func(t *testing.T) {
+		m := newEntry()
+		header := strings.Split(m.View(), "\n")[1]
+		x := lipgloss.Width(header[:strings.Index(header, "5 Logs")])
+
+		m, _ = m.Update(tea.MouseMsg{X: x, Y: 1, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+		assert.Contains(t, m.View(), "min severity")
+	})
+
+	t.Run("closes the palette", func(t *testing.T) {
+		m := press(newEntry(), ':')
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.NotContains(t, m.View(), "Go to Service map")
+		assert.Contains(t, m.View(), "mark trace")
+	})
+}
+
+func TestOpenTraceByID(t *testing.T) {
+	database, err := db.NewDB(":memory:")
+	assert.Nil(t, err)
+	defer database.Close()
+	assert.Nil(t, database.Migrate(t.Context()))
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	rs := ptrace.NewResourceSpans()
+	for i, name := range []string{"GET /older", "GET /newer"} {
+		span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetTraceID(pcommon.TraceID{byte(i + 1)})
+		span.SetSpanID(pcommon.SpanID{byte(i + 1)})
+		span.SetName(name)
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Duration(i) * time.Second)))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Duration(i)*time.Second + time.Millisecond)))
+	}
+	// Its parent is in a service which doesn't send here
+	child := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	child.SetTraceID(pcommon.TraceID{4})
+	child.SetSpanID(pcommon.SpanID{4})
+	child.SetParentSpanID(pcommon.SpanID{5})
+	child.SetName("GET /stock")
+	child.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	child.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(2 * time.Millisecond)))
+	assert.Nil(t, database.InsertResourceSpans(t.Context(), rs))
+
+	spans, err := database.GetSpans(t.Context())
+	assert.Nil(t, err)
+
+	openTrace := func(input string) tea.Model {
+		var m tea.Model = ui.NewEntryModel(spans, nil, bus.NewTransportBus(), database)
+		m, _ = m.Update(tea.WindowSizeMsg{Width: 140, Height: 30})
+		m, _ = m.Update(ui.MsgSpanPageUpdateTable{})
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(input)})
+
+		m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if cmd != nil {
+			m, _ = m.Update(cmd())
+		}
+
+		return m
+	}
+
+	t.Run("opens a trace by its traceparent", func(t *testing.T) {
+		m := openTrace("00-" + pcommon.TraceID{1}.String() + "-0100000000000000-01")
+
+		// The detail panel shows the selected trace, which isn't the newest
+		assert.Contains(t, m.View(), "GET /older • 1ms")
+	})
+
+	t.Run("opens a trace without its root span", func(t *testing.T) {
+		m := openTrace(pcommon.TraceID{4}.String())
+
+		assert.NotContains(t, m.View(), "hasn't arrived yet")
+		assert.Contains(t, m.View(), "GET /stock • 2ms")
+	})
+
+	t.Run("says when the trace hasn't arrived", func(t *testing.T) {
+		m := openTrace(pcommon.TraceID{3}.String())
+
+		assert.Contains(t, m.View(), "hasn't arrived yet")
+	})
+
+	t.Run("keeps the prompt open for invalid IDs", func(t *testing.T) {
+		m := openTrace("not a trace")
+
+		assert.Contains(t, m.View(), "expected a trace ID")
+	})
+}
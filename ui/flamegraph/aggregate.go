@@ -0,0 +1,150 @@
+package flamegraph
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Frame is a node in a flamegraph aggregated across many traces. Spans are
+// merged into the same frame when the names on the path from the root down
+// to them are the same.
+type Frame struct {
+	Name string
+	// Total is the sum of the duration of the merged spans
+	Total time.Duration
+	// Self is the sum of the self time of the merged spans
+	Self time.Duration
+	// InclusiveSelf is the sum of the self time of the frame and all of its
+	// descendants. Unlike Total it never double counts concurrent children.
+	InclusiveSelf time.Duration
+	// Count is the number of spans merged into the frame
+	Count    int
+	Children []Frame
+}
+
+const AggregateRootName = "all"
+
+// Aggregate merges the trees of all the forests by span name path under
+// a single root frame. Children are sorted by name, as is customary for
+// flamegraphs, so the same stack always ends up in the same place.
+func Aggregate(forests ...Forest) Frame {
+	root := Frame{Name: AggregateRootName}
+
+	for _, forest := range forests {
+		for i := range forest {
+			root.merge(&forest[i])
+			root.Total += forest[i].Duration
+		}
+	}
+
+	root.finish()
+
+	return root
+}
+
+func (f *Frame) merge(n *Node) {
+	i := slices.IndexFunc(f.Children, func(c Frame) bool { return c.Name == n.Name })
+	if i == -1 {
+		f.Children = append(f.Children, Frame{Name: n.Name})
+		i = len(f.Children) - 1
+	}
+
+	child := &f.Children[i]
+	child.Total += n.Duration
+	child.Self += n.SelfTime
+	child.Count++
+
+	for j := range n.Children {
+		child.merge(&n.Children[j])
+	}
+}
+
+// finish sorts the children and sums up the inclusive self time.
+func (f *Frame) finish() {
+	slices.SortFunc(f.Children, func(a, b Frame) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	f.InclusiveSelf = f.Self
+	for i := range f.Children {
+		f.Children[i].finish()
+		f.InclusiveSelf += f.Children[i].InclusiveSelf
+	}
+}
+
+// Value is the quantity the width of the frame is proportional to.
+func (f *Frame) Value(bySelf bool) time.Duration {
+	if bySelf {
+		return f.InclusiveSelf
+	}
+
+	return f.Total
+}
+
+// FrameCell is a frame placed on a row of an icicle graph.
+type FrameCell struct {
+	Frame *Frame
+	// Path is the index of the frame in each of its ancestors' children,
+	// starting below the root the layout was made from.
+	Path  []int
+	X     int
+	Width int
+}
+
+// Layout places the frame and its descendants in rows, one per depth, with
+// the root frame on top taking up the whole width. Frames narrower than a
+// single cell are left out. Children are scaled down to fit within their
+// parent when their values add up to more than the parent's, which happens
+// for concurrent spans when using total time.
+func Layout(root *Frame, width int, bySelf bool) [][]FrameCell {
+	rows := make([][]FrameCell, 0)
+	layout(root, nil, 0, 0, width, bySelf, &rows)
+
+	return rows
+}
+
+func layout(f *Frame, path []int, depth, x, width int, bySelf bool, rows *[][]FrameCell) {
+	if width < 1 {
+		return
+	}
+
+	if len(*rows) <= depth {
+		*rows = append(*rows, make([]FrameCell, 0))
+	}
+	(*rows)[depth] = append((*rows)[depth], FrameCell{Frame: f, Path: path, X: x, Width: width})
+
+	var childrenValue time.Duration
+	for i := range f.Children {
+		childrenValue += f.Children[i].Value(bySelf)
+	}
+
+	scale := max(f.Value(bySelf), childrenValue)
+	if scale == 0 {
+		return
+	}
+
+	var cumulative time.Duration
+	for i := range f.Children {
+		// Round the edges rather than the widths so that the cells don't
+		// drift away from their neighbours.
+		start := x + int(float64(width)*float64(cumulative)/float64(scale))
+		cumulative += f.Children[i].Value(bySelf)
+		end := x + int(float64(width)*float64(cumulative)/float64(scale))
+
+		layout(&f.Children[i], append(slices.Clone(path), i), depth+1, start, end-start, bySelf, rows)
+	}
+}
+
+// Find returns the frame at the path below f, or nil if there isn't one.
+func (f *Frame) Find(path []int) *Frame {
+	current := f
+	for _, i := range path {
+		if i < 0 || i >= len(current.Children) {
+			return nil
+		}
+		current = &current.Children[i]
+	}
+
+	return current
+}
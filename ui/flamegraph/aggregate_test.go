@@ -0,0 +1,109 @@
+package flamegraph_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/ui/flamegraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlamegraph_Aggregate(t *testing.T) {
+	identity := func(ni flamegraph.NodeInput) flamegraph.NodeInput { return ni }
+
+	trace1, _ := flamegraph.Build([]flamegraph.NodeInput{
+		{ID: "1", Name: "GET /", StartTime: now, Duration: time.Second},
+		{ID: "2", Name: "db", ParentID: "1", StartTime: now, Duration: 200 * time.Millisecond},
+		{ID: "3", Name: "cache", ParentID: "1", StartTime: now.Add(500 * time.Millisecond), Duration: 100 * time.Millisecond},
+	}, identity)
+	trace2, _ := flamegraph.Build([]flamegraph.NodeInput{
+		{ID: "1", Name: "GET /", StartTime: now, Duration: 2 * time.Second},
+		{ID: "2", Name: "db", ParentID: "1", StartTime: now, Duration: time.Second},
+		{ID: "3", Name: "db", ParentID: "1", StartTime: now.Add(time.Second), Duration: 500 * time.Millisecond},
+	}, identity)
+
+	t.Run("merges by name path", func(t *testing.T) {
+		root := flamegraph.Aggregate(trace1, trace2)
+
+		assert.Equal(t, flamegraph.AggregateRootName, root.Name)
+		assert.Equal(t, 3*time.Second, root.Total)
+		assert.Len(t, root.Children, 1)
+
+		endpoint := root.Children[0]
+		assert.Equal(t, "GET /", endpoint.Name)
+		assert.Equal(t, 2, endpoint.Count)
+		assert.Equal(t, 3*time.Second, endpoint.Total)
+		assert.Equal(t, 700*time.Millisecond+500*time.Millisecond, endpoint.Self)
+
+		assert.Len(t, endpoint.Children, 2)
+		assert.Equal(t, "cache", endpoint.Children[0].Name)
+		assert.Equal(t, "db", endpoint.Children[1].Name)
+		assert.Equal(t, 3, endpoint.Children[1].Count)
+		assert.Equal(t, 1700*time.Millisecond, endpoint.Children[1].Total)
+
+		assert.Equal(t, 3*time.Second, endpoint.InclusiveSelf)
+	})
+
+	t.Run("finds frames by path", func(t *testing.T) {
+		root := flamegraph.Aggregate(trace1, trace2)
+
+		assert.Equal(t, "db", root.Find([]int{0, 1}).Name)
+		assert.Equal(t, &root, root.Find(nil))
+		assert.Nil(t, root.Find([]int{0, 2}))
+	})
+}
+
+func TestFlamegraph_Layout(t *testing.T) {
+	t.Run("lays out frames proportionally", func(t *testing.T) {
+		root := flamegraph.Frame{
+			Name:          "root",
+			Total:         time.Second,
+			InclusiveSelf: time.Second,
+			Children: []flamegraph.Frame{
+				{Name: "a", Total: 250 * time.Millisecond, InclusiveSelf: 250 * time.Millisecond},
+				{Name: "b", Total: 500 * time.Millisecond, InclusiveSelf: 100 * time.Millisecond},
+			},
+		}
+
+		rows := flamegraph.Layout(&root, 100, false)
+
+		assert.Len(t, rows, 2)
+		assert.Equal(t, 100, rows[0][0].Width)
+		assert.Equal(t, []int{0, 25}, []int{rows[1][0].X, rows[1][0].Width})
+		assert.Equal(t, []int{25, 50}, []int{rows[1][1].X, rows[1][1].Width})
+		assert.Equal(t, []int{1}, rows[1][1].Path)
+
+		rows = flamegraph.Layout(&root, 100, true)
+		assert.Equal(t, []int{25, 10}, []int{rows[1][1].X, rows[1][1].Width})
+	})
+
+	t.Run("scales concurrent children to fit", func(t *testing.T) {
+		root := flamegraph.Frame{
+			Name:  "root",
+			Total: time.Second,
+			Children: []flamegraph.Frame{
+				{Name: "a", Total: time.Second},
+				{Name: "b", Total: time.Second},
+			},
+		}
+
+		rows := flamegraph.Layout(&root, 10, false)
+
+		assert.Equal(t, []int{0, 5}, []int{rows[1][0].X, rows[1][0].Width})
+		assert.Equal(t, []int{5, 5}, []int{rows[1][1].X, rows[1][1].Width})
+	})
+
+	t.Run("skips frames narrower than a cell", func(t *testing.T) {
+		root := flamegraph.Frame{
+			Name:  "root",
+			Total: time.Second,
+			Children: []flamegraph.Frame{
+				{Name: "tiny", Total: time.Millisecond},
+			},
+		}
+
+		rows := flamegraph.Layout(&root, 10, false)
+
+		assert.Len(t, rows, 1)
+	})
+}
@@ -0,0 +1,167 @@
+package flamegraph
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"time"
+)
+
+type DiffStatus int
+
+const (
+	// DiffMatched is a span found in both traces
+	DiffMatched DiffStatus = iota
+	// DiffAdded is a span only found in the target trace
+	DiffAdded
+	// DiffMissing is a span only found in the base trace
+	DiffMissing
+)
+
+// DiffNode is a span aligned between a base and a target trace.
+type DiffNode struct {
+	Name    string
+	Service string
+	Status  DiffStatus
+	// Base and Target are the durations in each trace, 0 when the span
+	// isn't found in that trace.
+	Base     time.Duration
+	Target   time.Duration
+	Children []DiffNode
+}
+
+// Delta is how much slower the span is in the target trace, negative when
+// it got faster.
+func (d *DiffNode) Delta() time.Duration {
+	return d.Target - d.Base
+}
+
+func (d *DiffNode) All() iter.Seq2[int, *DiffNode] {
+	return func(yield func(int, *DiffNode) bool) {
+		if yield(0, d) == false {
+			return
+		}
+		for i := range d.Children {
+			for depth, c := range d.Children[i].All() {
+				if yield(depth+1, c) == false {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Diff is the result of aligning two forests.
+type Diff []DiffNode
+
+// All iterates over every node in every tree, depth first, yielding the
+// depth of the node within its tree.
+func (d Diff) All() iter.Seq2[int, *DiffNode] {
+	return func(yield func(int, *DiffNode) bool) {
+		for i := range d {
+			for depth, n := range d[i].All() {
+				if yield(depth, n) == false {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Regressions returns up to n of the nodes which got the most slower, the
+// largest first. Only spans found in both traces can get slower, so added
+// spans are left out along with the nodes which didn't get slower.
+func (d Diff) Regressions(n int) []*DiffNode {
+	regressions := make([]*DiffNode, 0)
+	for _, node := range d.All() {
+		if node.Status == DiffMatched && node.Delta() > 0 {
+			regressions = append(regressions, node)
+		}
+	}
+
+	slices.SortStableFunc(regressions, func(a, b *DiffNode) int {
+		return cmp.Compare(b.Delta(), a.Delta())
+	})
+
+	return regressions[:min(n, len(regressions))]
+}
+
+// diffKey identifies a node among its siblings. Siblings with the same name
+// are told apart by the order they started in, so the third "SELECT" in one
+// trace is compared to the third "SELECT" in the other.
+type diffKey struct {
+	name       string
+	occurrence int
+}
+
+func diffKeys(nodes []Node) []diffKey {
+	seen := make(map[string]int)
+	keys := make([]diffKey, len(nodes))
+	for i, n := range nodes {
+		keys[i] = diffKey{n.Name, seen[n.Name]}
+		seen[n.Name]++
+	}
+
+	return keys
+}
+
+// DiffForests aligns the spans of two traces by the names on the path from
+// the root down to them. The nodes are in the order of the target trace,
+// with the nodes only found in the base trace after them.
+func DiffForests(base, target Forest) Diff {
+	return diffNodes(base, target)
+}
+
+func diffNodes(base, target []Node) []DiffNode {
+	baseKeys := diffKeys(base)
+	targetKeys := diffKeys(target)
+
+	nodes := make([]DiffNode, 0, max(len(base), len(target)))
+
+	for i := range target {
+		j := slices.Index(baseKeys, targetKeys[i])
+		if j == -1 {
+			nodes = append(nodes, oneSided(&target[i], DiffAdded))
+			continue
+		}
+
+		nodes = append(nodes, DiffNode{
+			Name:     target[i].Name,
+			Service:  target[i].Service,
+			Status:   DiffMatched,
+			Base:     base[j].Duration,
+			Target:   target[i].Duration,
+			Children: diffNodes(base[j].Children, target[i].Children),
+		})
+	}
+
+	for j := range base {
+		if !slices.Contains(targetKeys, baseKeys[j]) {
+			nodes = append(nodes, oneSided(&base[j], DiffMissing))
+		}
+	}
+
+	return nodes
+}
+
+// oneSided turns the node and its descendants into diff nodes only found in
+// one of the traces.
+func oneSided(n *Node, status DiffStatus) DiffNode {
+	d := DiffNode{
+		Name:     n.Name,
+		Service:  n.Service,
+		Status:   status,
+		Children: make([]DiffNode, len(n.Children)),
+	}
+	if status == DiffAdded {
+		d.Target = n.Duration
+	} else {
+		d.Base = n.Duration
+	}
+
+	for i := range n.Children {
+		d.Children[i] = oneSided(&n.Children[i], status)
+	}
+
+	return d
+}
@@ -0,0 +1,86 @@
+package flamegraph_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/ui/flamegraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlamegraph_DiffForests(t *testing.T) {
+	identity := func(ni flamegraph.NodeInput) flamegraph.NodeInput { return ni }
+
+	fast, _ := flamegraph.Build([]flamegraph.NodeInput{
+		{ID: "1", Name: "GET /", StartTime: now, Duration: 40 * time.Millisecond},
+		{ID: "2", Name: "SELECT", ParentID: "1", StartTime: now, Duration: 10 * time.Millisecond},
+		{ID: "3", Name: "SELECT", ParentID: "1", StartTime: now.Add(10 * time.Millisecond), Duration: 10 * time.Millisecond},
+		{ID: "4", Name: "cache", ParentID: "1", StartTime: now.Add(20 * time.Millisecond), Duration: 5 * time.Millisecond},
+	}, identity)
+	slow, _ := flamegraph.Build([]flamegraph.NodeInput{
+		{ID: "1", Name: "GET /", StartTime: now, Duration: 900 * time.Millisecond},
+		{ID: "2", Name: "SELECT", ParentID: "1", StartTime: now, Duration: 10 * time.Millisecond},
+		{ID: "3", Name: "SELECT", ParentID: "1", StartTime: now.Add(10 * time.Millisecond), Duration: 800 * time.Millisecond},
+		{ID: "4", Name: "retry", ParentID: "3", StartTime: now.Add(10 * time.Millisecond), Duration: 700 * time.Millisecond},
+	}, identity)
+
+	t.Run("aligns by name path", func(t *testing.T) {
+		diff := flamegraph.DiffForests(fast, slow)
+
+		assert.Len(t, diff, 1)
+		root := diff[0]
+		assert.Equal(t, flamegraph.DiffMatched, root.Status)
+		assert.Equal(t, 860*time.Millisecond, root.Delta())
+
+		assert.Len(t, root.Children, 3)
+		assert.Equal(t, "SELECT", root.Children[0].Name)
+		assert.Equal(t, time.Duration(0), root.Children[0].Delta())
+		assert.Equal(t, "SELECT", root.Children[1].Name)
+		assert.Equal(t, 790*time.Millisecond, root.Children[1].Delta())
+
+		assert.Equal(t, flamegraph.DiffAdded, root.Children[1].Children[0].Status)
+		assert.Equal(t, 700*time.Millisecond, root.Children[1].Children[0].Target)
+
+		assert.Equal(t, "cache", root.Children[2].Name)
+		assert.Equal(t, flamegraph.DiffMissing, root.Children[2].Status)
+		assert.Equal(t, -5*time.Millisecond, root.Children[2].Delta())
+	})
+
+	t.Run("finds the largest regressions", func(t *testing.T) {
+		diff := flamegraph.DiffForests(fast, slow)
+
+		regressions := diff.Regressions(2)
+
+		assert.Len(t, regressions, 2)
+		assert.Equal(t, "GET /", regressions[0].Name)
+		assert.Equal(t, "SELECT", regressions[1].Name)
+	})
+
+	t.Run("leaves added spans out of the regressions", func(t *testing.T) {
+		base, _ := flamegraph.Build([]flamegraph.NodeInput{
+			{ID: "1", Name: "GET /", StartTime: now, Duration: 100 * time.Millisecond},
+			{ID: "2", Name: "SELECT", ParentID: "1", StartTime: now, Duration: 50 * time.Millisecond},
+		}, identity)
+		target, _ := flamegraph.Build([]flamegraph.NodeInput{
+			{ID: "1", Name: "GET /", StartTime: now, Duration: 700 * time.Millisecond},
+			{ID: "2", Name: "SELECT", ParentID: "1", StartTime: now, Duration: 150 * time.Millisecond},
+			{ID: "3", Name: "fraud check", ParentID: "1", StartTime: now.Add(150 * time.Millisecond), Duration: 500 * time.Millisecond},
+		}, identity)
+
+		regressions := flamegraph.DiffForests(base, target).Regressions(5)
+
+		assert.Len(t, regressions, 2)
+		assert.Equal(t, "GET /", regressions[0].Name)
+		assert.Equal(t, "SELECT", regressions[1].Name)
+		assert.Equal(t, 100*time.Millisecond, regressions[1].Delta())
+	})
+
+	t.Run("has no regressions when comparing a trace with itself", func(t *testing.T) {
+		diff := flamegraph.DiffForests(slow, slow)
+
+		assert.Empty(t, diff.Regressions(5))
+		for _, n := range diff.All() {
+			assert.Equal(t, flamegraph.DiffMatched, n.Status)
+		}
+	})
+}
@@ -3,114 +3,297 @@
 package flamegraph
 
 import (
+	"cmp"
 	"errors"
-	"fmt"
 	"iter"
 	"slices"
 	"time"
 )
 
 type Node struct {
+	ID        string
 	Name      string
+	Service   string
 	StartTime time.Time
 	Duration  time.Duration
+	// SelfTime is the part of Duration not covered by any of the children,
+	// i.e. the time the node spent doing work itself rather than waiting.
+	SelfTime time.Duration
 	// WidthPct is a number 0 to 1 which is the width of the total time frame it should take up
 	WidthPct float64
 	// OffsetPct is a number 0 to 1 which is how far into the viewport it should begin
 	OffsetPct float64
-	Children  []Node
+	// Missing is set on placeholder nodes standing in for a parent span we
+	// haven't received (yet). It spans the time of its children.
+	Missing bool
+	// ClockSkewed is set when the node starts before its parent, which
+	// usually means the clocks of the hosts involved don't agree.
+	ClockSkewed bool
+	// Cycle is set when the node's ancestry loops back on itself. The node
+	// is promoted to a root to break the cycle.
+	Cycle bool
+	// SkewAdjustment is how much StartTime has been shifted from the stored
+	// value to compensate for clock skew between services.
+	SkewAdjustment time.Duration
+	Children       []Node
 }
 
 type NodeInput struct {
-	ID        string
-	Name      string
+	ID      string
+	Name    string
+	Service string
+	// Instance tells replicas of the service apart, e.g. by host, so their
+	// clocks are told apart too. It's empty when the span doesn't say.
+	Instance string
+	// Kind is the span kind as formatted by ptrace.SpanKind, and is used
+	// to detect clock skew.
+	Kind      string
 	Duration  time.Duration
 	ParentID  string
 	StartTime time.Time
 }
 
-func Build[T any](items []T, retriever func(T) NodeInput) (Node, error) {
+// Forest is the result of building a trace. Well formed traces have a
+// single root, but partial traces and async workloads can have several.
+type Forest []Node
+
+const MissingSpanName = "missing span"
+
+type BuildOption func(*buildOptions)
+
+type buildOptions struct {
+	adjustClockSkew bool
+}
+
+// WithClockSkewAdjustment shifts the spans of each service to line up with
+// their callers, see DetectClockSkew.
+func WithClockSkewAdjustment() BuildOption {
+	return func(o *buildOptions) {
+		o.adjustClockSkew = true
+	}
+}
+
+// Build creates the span trees from the items. It never fails for non-empty
+// input: spans whose parent is absent are attached to a placeholder node,
+// spans without a parent become roots, and cycles are broken by promoting
+// the earliest span in the cycle to a root.
+func Build[T any](items []T, retriever func(T) NodeInput, opts ...BuildOption) (Forest, error) {
 	if len(items) == 0 {
-		return Node{}, errors.New("can't build flamegraph from empty items")
+		return nil, errors.New("can't build flamegraph from empty items")
 	}
 
-	var start, end time.Time
+	var options buildOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
 
 	nis := make([]NodeInput, len(items))
 	for i, item := range items {
 		nis[i] = retriever(item)
+	}
 
-		niStart, niEnd := nis[i].StartTime, nis[i].StartTime.Add(nis[i].Duration)
-
-		if start.IsZero() {
-			start, end = niStart, niEnd
-		} else {
-			if niStart.Before(start) {
-				start = niStart
+	adjustments := make(map[string]time.Duration)
+	if options.adjustClockSkew {
+		for i, adjustment := range adjustClockSkew(nis) {
+			if adjustment != 0 {
+				adjustments[nis[i].ID] = adjustment
 			}
+		}
+	}
 
-			if niEnd.After(end) {
-				end = niEnd
-			}
+	slices.SortStableFunc(nis, func(a, b NodeInput) int {
+		return a.StartTime.Compare(b.StartTime)
+	})
+
+	b := builder{
+		inputs:      nis,
+		ids:         make(map[string]int, len(nis)),
+		children:    make(map[string][]int),
+		visited:     make([]bool, len(nis)),
+		adjustments: adjustments,
+	}
+
+	for i, ni := range nis {
+		if _, ok := b.ids[ni.ID]; !ok {
+			b.ids[ni.ID] = i
+		}
+		if ni.ParentID != "" {
+			b.children[ni.ParentID] = append(b.children[ni.ParentID], i)
+		}
+	}
+
+	roots := make(Forest, 0)
+
+	for i, ni := range nis {
+		if ni.ParentID == "" {
+			roots = append(roots, b.build(i))
 		}
 	}
 
-	slices.SortFunc(nis, func(a, b NodeInput) int {
+	// Orphans are grouped under one placeholder per missing parent
+	missingParents := make([]string, 0)
+	for _, ni := range nis {
+		if _, ok := b.ids[ni.ParentID]; ni.ParentID != "" && !ok && !slices.Contains(missingParents, ni.ParentID) {
+			missingParents = append(missingParents, ni.ParentID)
+		}
+	}
+	for _, parentID := range missingParents {
+		roots = append(roots, b.buildMissing(parentID))
+	}
+
+	// Anything not visited by now is part of (or hangs off) a cycle
+	for i := range nis {
+		if !b.visited[i] {
+			root := b.build(i)
+			root.Cycle = true
+			roots = append(roots, root)
+		}
+	}
+
+	slices.SortStableFunc(roots, func(a, b Node) int {
 		return a.StartTime.Compare(b.StartTime)
 	})
 
-	roots := findNodesBelongingToParent(nil, nis, start, end)
+	start, end := roots.bounds()
+	for i := range roots {
+		setPct(&roots[i], start, end)
+	}
+
+	return roots, nil
+}
+
+type builder struct {
+	inputs []NodeInput
+	// ids maps from span ID to index in inputs
+	ids map[string]int
+	// children maps from parent ID to indices in inputs
+	children map[string][]int
+	visited  []bool
+	// adjustments maps from span ID to clock skew adjustment
+	adjustments map[string]time.Duration
+}
+
+func (b *builder) build(i int) Node {
+	b.visited[i] = true
+	input := b.inputs[i]
+
+	children := b.buildChildren(input.ID)
+	for j := range children {
+		children[j].ClockSkewed = children[j].StartTime.Before(input.StartTime)
+	}
+
+	node := newNode(input, children)
+	node.SkewAdjustment = b.adjustments[input.ID]
+
+	return node
+}
+
+func (b *builder) buildChildren(parentID string) []Node {
+	children := make([]Node, 0)
+	for _, c := range b.children[parentID] {
+		if b.visited[c] {
+			continue
+		}
+		children = append(children, b.build(c))
+	}
+
+	return children
+}
+
+func (b *builder) buildMissing(parentID string) Node {
+	children := b.buildChildren(parentID)
 
-	if len(roots) != 1 {
-		return Node{}, fmt.Errorf("when building node either 0 or more than 1 root was found: len(roots)=%v", len(roots))
+	var start, end time.Time
+	for i, c := range children {
+		if i == 0 || c.StartTime.Before(start) {
+			start = c.StartTime
+		}
+		if cEnd := c.StartTime.Add(c.Duration); i == 0 || cEnd.After(end) {
+			end = cEnd
+		}
 	}
 
-	return roots[0], nil
+	return Node{
+		ID:        parentID,
+		Name:      MissingSpanName,
+		StartTime: start,
+		Duration:  end.Sub(start),
+		Missing:   true,
+		Children:  children,
+	}
 }
 
-// findNodesBelongingToParent takes in a reference to the parent you want
-// to find the children for, and all the nodes you want to look through, and
-// returns a slice of the parent's children.
-func findNodesBelongingToParent(parent *NodeInput, inputs []NodeInput, start, end time.Time) []Node {
-	results := make([]Node, 0)
+func newNode(input NodeInput, children []Node) Node {
+	return Node{
+		ID:        input.ID,
+		Name:      input.Name,
+		Service:   input.Service,
+		Duration:  input.Duration,
+		StartTime: input.StartTime,
+		SelfTime:  selfTime(input.StartTime, input.Duration, children),
+		Children:  children,
+	}
+}
 
+// setPct sets the width and offset of the node and its children relative
+// to the time frame between start and end.
+func setPct(n *Node, start, end time.Time) {
 	traceDuration := end.Sub(start)
+	if traceDuration > 0 {
+		n.WidthPct = float64(n.Duration) / float64(traceDuration)
+		n.OffsetPct = float64(n.StartTime.Sub(start)) / float64(traceDuration)
+	}
 
-	for _, input := range inputs {
-		widthPct := float64(input.Duration) / float64(end.Sub(start))
-		offsetPct := float64(input.StartTime.Sub(start)) / float64(traceDuration)
-
-		if parent == nil {
-			// This means we're looking for the root node
-			if input.ParentID == "" {
-				return []Node{
-					{
-						Name:      input.Name,
-						Duration:  input.Duration,
-						StartTime: input.StartTime,
-						WidthPct:  widthPct,
-						OffsetPct: offsetPct,
-						Children:  findNodesBelongingToParent(&input, inputs, start, end),
-					},
-				}
-			} else {
-				continue
-			}
+	for i := range n.Children {
+		setPct(&n.Children[i], start, end)
+	}
+}
+
+// selfTime returns the duration minus the union of the children's intervals.
+// Children are clipped to the parent's interval so a child running past the
+// end of its parent doesn't make the self time negative.
+func selfTime(start time.Time, duration time.Duration, children []Node) time.Duration {
+	end := start.Add(duration)
+
+	type interval struct{ start, end time.Time }
+	intervals := make([]interval, 0, len(children))
+	for _, c := range children {
+		cStart, cEnd := c.StartTime, c.StartTime.Add(c.Duration)
+		if cStart.Before(start) {
+			cStart = start
 		}
+		if cEnd.After(end) {
+			cEnd = end
+		}
+		if !cEnd.After(cStart) {
+			continue
+		}
+		intervals = append(intervals, interval{cStart, cEnd})
+	}
 
-		if input.ParentID == parent.ID {
-			results = append(results, Node{
-				Name:      input.Name,
-				Duration:  input.Duration,
-				StartTime: input.StartTime,
-				WidthPct:  widthPct,
-				OffsetPct: offsetPct,
-				Children:  findNodesBelongingToParent(&input, inputs, start, end),
-			})
+	slices.SortFunc(intervals, func(a, b interval) int {
+		return a.start.Compare(b.start)
+	})
+
+	var covered time.Duration
+	var current *interval
+	for _, i := range intervals {
+		if current == nil || i.start.After(current.end) {
+			if current != nil {
+				covered += current.end.Sub(current.start)
+			}
+			current = &i
+			continue
+		}
+		if i.end.After(current.end) {
+			current.end = i.end
 		}
 	}
+	if current != nil {
+		covered += current.end.Sub(current.start)
+	}
 
-	return results
+	return duration - covered
 }
 
 func (n *Node) All() iter.Seq2[int, *Node] {
@@ -118,8 +301,8 @@ func (n *Node) All() iter.Seq2[int, *Node] {
 		if yield(0, n) == false {
 			return
 		}
-		for _, c := range n.Children {
-			for d, cn := range c.All() {
+		for i := range n.Children {
+			for d, cn := range n.Children[i].All() {
 				if yield(d+1, cn) == false {
 					return
 				}
@@ -127,3 +310,79 @@ func (n *Node) All() iter.Seq2[int, *Node] {
 		}
 	}
 }
+
+// All iterates over every node in every tree, depth first, yielding the
+// depth of the node within its tree.
+func (f Forest) All() iter.Seq2[int, *Node] {
+	return func(yield func(int, *Node) bool) {
+		for i := range f {
+			for d, n := range f[i].All() {
+				if yield(d, n) == false {
+					return
+				}
+			}
+		}
+	}
+}
+
+// bounds returns the earliest start and latest end of all the nodes.
+func (f Forest) bounds() (time.Time, time.Time) {
+	var start, end time.Time
+	for _, n := range f.All() {
+		nEnd := n.StartTime.Add(n.Duration)
+		if start.IsZero() || n.StartTime.Before(start) {
+			start = n.StartTime
+		}
+		if end.IsZero() || nEnd.After(end) {
+			end = nEnd
+		}
+	}
+
+	return start, end
+}
+
+// Duration is the time between the earliest start and the latest end in
+// the forest.
+func (f Forest) Duration() time.Duration {
+	start, end := f.bounds()
+	return end.Sub(start)
+}
+
+type SelfTimeEntry struct {
+	Key      string
+	SelfTime time.Duration
+	Count    int
+}
+
+// SelfTimeBy aggregates the self time of every node in the forest by the key
+// returned from keyFn, e.g. span name or service. The entries are sorted
+// with the largest self time first. Placeholders for missing spans are
+// skipped.
+func (f Forest) SelfTimeBy(keyFn func(*Node) string) []SelfTimeEntry {
+	entries := make([]SelfTimeEntry, 0)
+	indices := make(map[string]int)
+
+	for _, node := range f.All() {
+		if node.Missing {
+			continue
+		}
+
+		key := keyFn(node)
+
+		i, ok := indices[key]
+		if !ok {
+			i = len(entries)
+			indices[key] = i
+			entries = append(entries, SelfTimeEntry{Key: key})
+		}
+
+		entries[i].SelfTime += node.SelfTime
+		entries[i].Count++
+	}
+
+	slices.SortStableFunc(entries, func(a, b SelfTimeEntry) int {
+		return cmp.Compare(b.SelfTime, a.SelfTime)
+	})
+
+	return entries
+}
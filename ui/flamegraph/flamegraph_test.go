@@ -2,6 +2,7 @@ package flamegraph_test
 
 import (
 	"iter"
+	"strings"
 	"testing"
 	"time"
 
@@ -32,7 +33,7 @@ var testItemsComplex = []testItem{
 
 func TestFlamegraph_Build(t *testing.T) {
 	t.Run("builds a flamegraph", func(t *testing.T) {
-		root, err := flamegraph.Build(testItemsSkinny, func(t testItem) flamegraph.NodeInput {
+		roots, err := flamegraph.Build(testItemsSkinny, func(t testItem) flamegraph.NodeInput {
 			return flamegraph.NodeInput{
 				ID:        t.name,
 				Name:      t.name,
@@ -42,7 +43,7 @@ func TestFlamegraph_Build(t *testing.T) {
 			}
 		})
 
-		assert.NotNil(t, root)
+		assert.Len(t, roots, 1)
 		assert.Nil(t, err)
 	})
 
@@ -54,13 +55,13 @@ func TestFlamegraph_Build(t *testing.T) {
 		assert.ErrorContains(t, err, "can't build flamegraph from empty items")
 	})
 
-	t.Run("fails to build graph with no root", func(t *testing.T) {
-		_, err := flamegraph.Build([]testItem{
+	t.Run("attaches orphans to a missing span placeholder", func(t *testing.T) {
+		roots, err := flamegraph.Build([]testItem{
 			{
 				name:      "test",
 				duration:  10 * time.Second,
 				parentID:  "dog",
-				startTime: time.Now(),
+				startTime: now,
 			},
 		}, func(t testItem) flamegraph.NodeInput {
 			return flamegraph.NodeInput{
@@ -72,11 +73,17 @@ func TestFlamegraph_Build(t *testing.T) {
 			}
 		})
 
-		assert.ErrorContains(t, err, "len(roots)=0")
+		assert.Nil(t, err)
+		assert.Len(t, roots, 1)
+		assert.True(t, roots[0].Missing)
+		assert.Equal(t, "dog", roots[0].ID)
+		assert.Equal(t, flamegraph.MissingSpanName, roots[0].Name)
+		assert.Equal(t, 10*time.Second, roots[0].Duration)
+		assert.Equal(t, "test", roots[0].Children[0].Name)
 	})
 
 	t.Run("builds skinny tree and sets offset pct", func(t *testing.T) {
-		root, _ := flamegraph.Build(testItemsSkinny, func(t testItem) flamegraph.NodeInput {
+		roots, _ := flamegraph.Build(testItemsSkinny, func(t testItem) flamegraph.NodeInput {
 			return flamegraph.NodeInput{
 				ID:        t.name,
 				Name:      t.name,
@@ -85,6 +92,7 @@ func TestFlamegraph_Build(t *testing.T) {
 				ParentID:  t.parentID,
 			}
 		})
+		root := roots[0]
 
 		assert.InDelta(t, 0, root.OffsetPct, 0.01)
 		assert.InDelta(t, 0.25, root.Children[0].OffsetPct, 0.01)
@@ -92,7 +100,7 @@ func TestFlamegraph_Build(t *testing.T) {
 	})
 
 	t.Run("builds skinny tree and sets correct pct", func(t *testing.T) {
-		root, err := flamegraph.Build(testItemsSkinny, func(t testItem) flamegraph.NodeInput {
+		roots, err := flamegraph.Build(testItemsSkinny, func(t testItem) flamegraph.NodeInput {
 			return flamegraph.NodeInput{
 				ID:        t.name,
 				Name:      t.name,
@@ -101,6 +109,7 @@ func TestFlamegraph_Build(t *testing.T) {
 				ParentID:  t.parentID,
 			}
 		})
+		root := roots[0]
 
 		assert.Nil(t, err)
 
@@ -114,7 +123,7 @@ func TestFlamegraph_Build(t *testing.T) {
 	})
 
 	t.Run("sorts the entries correctly", func(t *testing.T) {
-		root, err := flamegraph.Build(testItemsComplex, func(t testItem) flamegraph.NodeInput {
+		roots, err := flamegraph.Build(testItemsComplex, func(t testItem) flamegraph.NodeInput {
 			return flamegraph.NodeInput{
 				ID:        t.name,
 				Name:      t.name,
@@ -123,6 +132,7 @@ func TestFlamegraph_Build(t *testing.T) {
 				ParentID:  t.parentID,
 			}
 		})
+		root := roots[0]
 
 		assert.Nil(t, err)
 
@@ -131,7 +141,7 @@ func TestFlamegraph_Build(t *testing.T) {
 	})
 
 	t.Run("iterate", func(t *testing.T) {
-		root, _ := flamegraph.Build(testItemsComplex, func(t testItem) flamegraph.NodeInput {
+		roots, _ := flamegraph.Build(testItemsComplex, func(t testItem) flamegraph.NodeInput {
 			return flamegraph.NodeInput{
 				ID:        t.name,
 				Name:      t.name,
@@ -140,6 +150,7 @@ func TestFlamegraph_Build(t *testing.T) {
 				ParentID:  t.parentID,
 			}
 		})
+		root := roots[0]
 
 		next, stop := iter.Pull2(root.All())
 		defer stop()
@@ -163,3 +174,195 @@ func TestFlamegraph_Build(t *testing.T) {
 		assert.False(t, valid)
 	})
 }
+
+func TestFlamegraph_SelfTime(t *testing.T) {
+	retriever := func(t testItem) flamegraph.NodeInput {
+		return flamegraph.NodeInput{
+			ID:        t.name,
+			Name:      t.name,
+			Duration:  t.duration,
+			StartTime: t.startTime,
+			ParentID:  t.parentID,
+		}
+	}
+
+	t.Run("subtracts children from self time", func(t *testing.T) {
+		roots, err := flamegraph.Build(testItemsSkinny, retriever)
+		root := roots[0]
+
+		assert.Nil(t, err)
+		assert.Equal(t, 500*time.Millisecond, root.SelfTime)
+		assert.Equal(t, 250*time.Millisecond, root.Children[0].SelfTime)
+		assert.Equal(t, 250*time.Millisecond, root.Children[0].Children[0].SelfTime)
+	})
+
+	t.Run("uses the union of overlapping children", func(t *testing.T) {
+		roots, err := flamegraph.Build([]testItem{
+			{"root", time.Second, "", now},
+			{"a", 400 * time.Millisecond, "root", now.Add(100 * time.Millisecond)},
+			{"b", 400 * time.Millisecond, "root", now.Add(300 * time.Millisecond)},
+			{"c", 100 * time.Millisecond, "root", now.Add(900 * time.Millisecond)},
+		}, retriever)
+		root := roots[0]
+
+		assert.Nil(t, err)
+		// a and b cover 100ms-700ms, c covers 900ms-1000ms
+		assert.Equal(t, 300*time.Millisecond, root.SelfTime)
+	})
+
+	t.Run("clips children outside the parent", func(t *testing.T) {
+		roots, err := flamegraph.Build([]testItem{
+			{"root", time.Second, "", now},
+			{"a", time.Second, "root", now.Add(500 * time.Millisecond)},
+		}, retriever)
+		root := roots[0]
+
+		assert.Nil(t, err)
+		assert.Equal(t, 500*time.Millisecond, root.SelfTime)
+	})
+
+	t.Run("aggregates self time", func(t *testing.T) {
+		roots, err := flamegraph.Build([]testItem{
+			{"root", time.Second, "", now},
+			{"db1", 200 * time.Millisecond, "root", now},
+			{"db2", 400 * time.Millisecond, "root", now.Add(500 * time.Millisecond)},
+		}, retriever)
+
+		assert.Nil(t, err)
+
+		entries := roots.SelfTimeBy(func(n *flamegraph.Node) string { return strings.TrimRight(n.Name, "0123456789") })
+		assert.Equal(t, []flamegraph.SelfTimeEntry{
+			{Key: "db", SelfTime: 600 * time.Millisecond, Count: 2},
+			{Key: "root", SelfTime: 400 * time.Millisecond, Count: 1},
+		}, entries)
+	})
+
+	t.Run("aggregates self time by service", func(t *testing.T) {
+		type serviceItem struct {
+			testItem
+			service string
+		}
+
+		roots, err := flamegraph.Build([]serviceItem{
+			{testItem{"checkout", time.Second, "", now}, "api"},
+			{testItem{"charge", 600 * time.Millisecond, "checkout", now.Add(100 * time.Millisecond)}, "payments"},
+			{testItem{"insert", 200 * time.Millisecond, "charge", now.Add(200 * time.Millisecond)}, "postgres"},
+			{testItem{"select", 100 * time.Millisecond, "checkout", now.Add(800 * time.Millisecond)}, "postgres"},
+		}, func(i serviceItem) flamegraph.NodeInput {
+			input := retriever(i.testItem)
+			input.Service = i.service
+			return input
+		})
+
+		assert.Nil(t, err)
+
+		entries := roots.SelfTimeBy(func(n *flamegraph.Node) string { return n.Service })
+		assert.Equal(t, []flamegraph.SelfTimeEntry{
+			{Key: "payments", SelfTime: 400 * time.Millisecond, Count: 1},
+			{Key: "api", SelfTime: 300 * time.Millisecond, Count: 1},
+			{Key: "postgres", SelfTime: 300 * time.Millisecond, Count: 2},
+		}, entries)
+	})
+}
+
+func TestFlamegraph_BuildMalformed(t *testing.T) {
+	retriever := func(t testItem) flamegraph.NodeInput {
+		return flamegraph.NodeInput{
+			ID:        t.name,
+			Name:      t.name,
+			Duration:  t.duration,
+			StartTime: t.startTime,
+			ParentID:  t.parentID,
+		}
+	}
+
+	t.Run("builds a forest from multiple roots", func(t *testing.T) {
+		roots, err := flamegraph.Build([]testItem{
+			{"consumer", time.Second, "", now.Add(2 * time.Second)},
+			{"producer", time.Second, "", now},
+			{"child", 100 * time.Millisecond, "consumer", now.Add(2 * time.Second)},
+		}, retriever)
+
+		assert.Nil(t, err)
+		assert.Len(t, roots, 2)
+		assert.Equal(t, "producer", roots[0].Name)
+		assert.Equal(t, "consumer", roots[1].Name)
+		assert.Equal(t, "child", roots[1].Children[0].Name)
+		assert.InDelta(t, 1.0/3.0, roots[0].WidthPct, 0.01)
+		assert.InDelta(t, 2.0/3.0, roots[1].OffsetPct, 0.01)
+		assert.Equal(t, 3*time.Second, roots.Duration())
+	})
+
+	t.Run("groups orphans by their missing parent", func(t *testing.T) {
+		roots, err := flamegraph.Build([]testItem{
+			{"root", time.Second, "", now},
+			{"a", 100 * time.Millisecond, "gone", now.Add(200 * time.Millisecond)},
+			{"b", 100 * time.Millisecond, "gone", now.Add(400 * time.Millisecond)},
+			{"c", 100 * time.Millisecond, "also gone", now.Add(600 * time.Millisecond)},
+		}, retriever)
+
+		assert.Nil(t, err)
+		assert.Len(t, roots, 3)
+
+		assert.Equal(t, "root", roots[0].Name)
+
+		assert.True(t, roots[1].Missing)
+		assert.Equal(t, "gone", roots[1].ID)
+		assert.Len(t, roots[1].Children, 2)
+		assert.Equal(t, now.Add(200*time.Millisecond), roots[1].StartTime)
+		assert.Equal(t, 300*time.Millisecond, roots[1].Duration)
+
+		assert.True(t, roots[2].Missing)
+		assert.Equal(t, "also gone", roots[2].ID)
+		assert.Len(t, roots[2].Children, 1)
+	})
+
+	t.Run("skips missing spans when aggregating self time", func(t *testing.T) {
+		roots, err := flamegraph.Build([]testItem{
+			{"a", 100 * time.Millisecond, "gone", now},
+		}, retriever)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []flamegraph.SelfTimeEntry{
+			{Key: "a", SelfTime: 100 * time.Millisecond, Count: 1},
+		}, roots.SelfTimeBy(func(n *flamegraph.Node) string { return n.Name }))
+	})
+
+	t.Run("breaks cycles", func(t *testing.T) {
+		roots, err := flamegraph.Build([]testItem{
+			{"a", time.Second, "c", now},
+			{"b", time.Second, "a", now.Add(time.Millisecond)},
+			{"c", time.Second, "b", now.Add(2 * time.Millisecond)},
+			{"self", time.Second, "self", now.Add(3 * time.Millisecond)},
+		}, retriever)
+
+		assert.Nil(t, err)
+		assert.Len(t, roots, 2)
+
+		assert.True(t, roots[0].Cycle)
+		assert.Equal(t, "a", roots[0].Name)
+		assert.Equal(t, "b", roots[0].Children[0].Name)
+		assert.Equal(t, "c", roots[0].Children[0].Children[0].Name)
+		assert.Empty(t, roots[0].Children[0].Children[0].Children)
+
+		assert.True(t, roots[1].Cycle)
+		assert.Equal(t, "self", roots[1].Name)
+		assert.Empty(t, roots[1].Children)
+	})
+
+	t.Run("marks children starting before their parent", func(t *testing.T) {
+		roots, err := flamegraph.Build([]testItem{
+			{"root", time.Second, "", now},
+			{"early", 100 * time.Millisecond, "root", now.Add(-50 * time.Millisecond)},
+			{"fine", 100 * time.Millisecond, "root", now.Add(50 * time.Millisecond)},
+		}, retriever)
+
+		assert.Nil(t, err)
+		assert.Len(t, roots, 1)
+		assert.False(t, roots[0].ClockSkewed)
+		assert.True(t, roots[0].Children[0].ClockSkewed)
+		assert.Equal(t, "early", roots[0].Children[0].Name)
+		assert.False(t, roots[0].Children[1].ClockSkewed)
+		assert.InDelta(t, 0, roots[0].Children[0].OffsetPct, 0.01)
+	})
+}
@@ -0,0 +1,171 @@
+package flamegraph
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// ClockSkew is the estimated difference between the clocks of two
+// resources calling each other. A resource is a service, or an instance of
+// it when there are several, as each instance can have its own clock.
+type ClockSkew struct {
+	ClientService  string
+	ClientInstance string
+	ServerService  string
+	ServerInstance string
+	// Offset is how much the server's timestamps have to be shifted to line
+	// up with the client's.
+	Offset time.Duration
+	// Samples is the number of client/server span pairs the offset is
+	// estimated from.
+	Samples int
+}
+
+// resource is what has its own clock.
+type resource struct {
+	service, instance string
+}
+
+func resourceOf(ni NodeInput) resource {
+	return resource{ni.Service, ni.Instance}
+}
+
+type resourcePair struct {
+	client, server resource
+}
+
+// DetectClockSkew estimates the clock skew between every pair of resources
+// with a CLIENT/SERVER or PRODUCER/CONSUMER parent/child span relationship.
+//
+// A server span is expected to sit inside its client span, with the network
+// latency split evenly on both sides. A consumer span is only expected to
+// start after its producer, as messages can sit in a queue for a long time.
+// The offset for a pair is the median of the shifts needed for each sample,
+// so the occasional slow network call doesn't skew the whole trace.
+func DetectClockSkew(inputs []NodeInput) []ClockSkew {
+	byID := make(map[string]NodeInput, len(inputs))
+	for _, ni := range inputs {
+		byID[ni.ID] = ni
+	}
+
+	pairs := make([]resourcePair, 0)
+	samples := make(map[resourcePair][]time.Duration)
+
+	for _, child := range inputs {
+		parent, ok := byID[child.ParentID]
+		if !ok || resourceOf(parent) == resourceOf(child) {
+			continue
+		}
+
+		var shift time.Duration
+		switch {
+		case parent.Kind == ptrace.SpanKindClient.String() && child.Kind == ptrace.SpanKindServer.String():
+			shift = serverShift(parent, child)
+		case parent.Kind == ptrace.SpanKindProducer.String() && child.Kind == ptrace.SpanKindConsumer.String():
+			shift = max(0, parent.StartTime.Sub(child.StartTime))
+		default:
+			continue
+		}
+
+		pair := resourcePair{resourceOf(parent), resourceOf(child)}
+		if _, ok := samples[pair]; !ok {
+			pairs = append(pairs, pair)
+		}
+		samples[pair] = append(samples[pair], shift)
+	}
+
+	skews := make([]ClockSkew, len(pairs))
+	for i, pair := range pairs {
+		skews[i] = ClockSkew{
+			ClientService:  pair.client.service,
+			ClientInstance: pair.client.instance,
+			ServerService:  pair.server.service,
+			ServerInstance: pair.server.instance,
+			Offset:         median(samples[pair]),
+			Samples:        len(samples[pair]),
+		}
+	}
+
+	return skews
+}
+
+// serverShift returns how much the server span has to move to fit inside
+// the client span, or 0 if it already does.
+func serverShift(client, server NodeInput) time.Duration {
+	clientEnd := client.StartTime.Add(client.Duration)
+	serverEnd := server.StartTime.Add(server.Duration)
+
+	if !server.StartTime.Before(client.StartTime) && !serverEnd.After(clientEnd) {
+		return 0
+	}
+
+	if server.Duration > client.Duration {
+		// It won't fit no matter what, so just line up the starts
+		return client.StartTime.Sub(server.StartTime)
+	}
+
+	latency := (client.Duration - server.Duration) / 2
+	return client.StartTime.Add(latency).Sub(server.StartTime)
+}
+
+func median(ds []time.Duration) time.Duration {
+	sorted := slices.Clone(ds)
+	slices.Sort(sorted)
+
+	return sorted[len(sorted)/2]
+}
+
+// resourceOffsets resolves the pairwise skews into an offset per resource,
+// relative to the resources owning root spans. Resources which can't be
+// reached from a root through the skews aren't adjusted.
+func resourceOffsets(inputs []NodeInput, skews []ClockSkew) map[resource]time.Duration {
+	offsets := make(map[resource]time.Duration)
+	for _, ni := range inputs {
+		if ni.ParentID == "" {
+			offsets[resourceOf(ni)] = 0
+		}
+	}
+
+	// Prefer the skews we have the most samples for when a resource can be
+	// reached from several clients.
+	skews = slices.Clone(skews)
+	slices.SortStableFunc(skews, func(a, b ClockSkew) int {
+		return cmp.Compare(b.Samples, a.Samples)
+	})
+
+	for changed := true; changed; {
+		changed = false
+		for _, skew := range skews {
+			clientOffset, ok := offsets[resource{skew.ClientService, skew.ClientInstance}]
+			if !ok {
+				continue
+			}
+			server := resource{skew.ServerService, skew.ServerInstance}
+			if _, ok := offsets[server]; ok {
+				continue
+			}
+
+			offsets[server] = clientOffset + skew.Offset
+			changed = true
+		}
+	}
+
+	return offsets
+}
+
+// adjustClockSkew shifts the start time of the inputs according to the
+// detected skew, returning the shift applied to each input.
+func adjustClockSkew(inputs []NodeInput) []time.Duration {
+	offsets := resourceOffsets(inputs, DetectClockSkew(inputs))
+
+	adjustments := make([]time.Duration, len(inputs))
+	for i := range inputs {
+		adjustments[i] = offsets[resourceOf(inputs[i])]
+		inputs[i].StartTime = inputs[i].StartTime.Add(adjustments[i])
+	}
+
+	return adjustments
+}
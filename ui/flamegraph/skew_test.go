@@ -0,0 +1,136 @@
+package flamegraph_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/ui/flamegraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectClockSkew(t *testing.T) {
+	t.Run("centers a server span outside its client", func(t *testing.T) {
+		skews := flamegraph.DetectClockSkew([]flamegraph.NodeInput{
+			{ID: "c", Service: "web", Kind: "Client", StartTime: now, Duration: 100 * time.Millisecond},
+			{ID: "s", Service: "api", Kind: "Server", ParentID: "c", StartTime: now.Add(-time.Second), Duration: 60 * time.Millisecond},
+		})
+
+		assert.Equal(t, []flamegraph.ClockSkew{
+			{ClientService: "web", ServerService: "api", Offset: time.Second + 20*time.Millisecond, Samples: 1},
+		}, skews)
+	})
+
+	t.Run("leaves server spans which fit", func(t *testing.T) {
+		skews := flamegraph.DetectClockSkew([]flamegraph.NodeInput{
+			{ID: "c", Service: "web", Kind: "Client", StartTime: now, Duration: 100 * time.Millisecond},
+			{ID: "s", Service: "api", Kind: "Server", ParentID: "c", StartTime: now.Add(time.Millisecond), Duration: 60 * time.Millisecond},
+		})
+
+		assert.Len(t, skews, 1)
+		assert.Zero(t, skews[0].Offset)
+	})
+
+	t.Run("only requires consumers to start after producers", func(t *testing.T) {
+		skews := flamegraph.DetectClockSkew([]flamegraph.NodeInput{
+			{ID: "p1", Service: "web", Kind: "Producer", StartTime: now, Duration: time.Millisecond},
+			{ID: "c1", Service: "worker", Kind: "Consumer", ParentID: "p1", StartTime: now.Add(time.Minute), Duration: time.Second},
+			{ID: "p2", Service: "web", Kind: "Producer", StartTime: now, Duration: time.Millisecond},
+			{ID: "c2", Service: "worker", Kind: "Consumer", ParentID: "p2", StartTime: now.Add(-time.Second), Duration: time.Second},
+			{ID: "p3", Service: "web", Kind: "Producer", StartTime: now, Duration: time.Millisecond},
+			{ID: "c3", Service: "worker", Kind: "Consumer", ParentID: "p3", StartTime: now.Add(-2 * time.Second), Duration: time.Second},
+		})
+
+		assert.Equal(t, []flamegraph.ClockSkew{
+			{ClientService: "web", ServerService: "worker", Offset: time.Second, Samples: 3},
+		}, skews)
+	})
+
+	t.Run("tells instances of a service apart", func(t *testing.T) {
+		skews := flamegraph.DetectClockSkew([]flamegraph.NodeInput{
+			{ID: "c1", Service: "web", Kind: "Client", StartTime: now, Duration: 100 * time.Millisecond},
+			{ID: "s1", Service: "api", Instance: "api-1", Kind: "Server", ParentID: "c1", StartTime: now.Add(-time.Second), Duration: 60 * time.Millisecond},
+			{ID: "c2", Service: "web", Kind: "Client", StartTime: now, Duration: 100 * time.Millisecond},
+			{ID: "s2", Service: "api", Instance: "api-2", Kind: "Server", ParentID: "c2", StartTime: now.Add(time.Second), Duration: 60 * time.Millisecond},
+		})
+
+		assert.Equal(t, []flamegraph.ClockSkew{
+			{ClientService: "web", ServerService: "api", ServerInstance: "api-1", Offset: time.Second + 20*time.Millisecond, Samples: 1},
+			{ClientService: "web", ServerService: "api", ServerInstance: "api-2", Offset: -time.Second + 20*time.Millisecond, Samples: 1},
+		}, skews)
+	})
+
+	t.Run("ignores spans within the same service and other kinds", func(t *testing.T) {
+		skews := flamegraph.DetectClockSkew([]flamegraph.NodeInput{
+			{ID: "c", Service: "web", Kind: "Client", StartTime: now, Duration: time.Millisecond},
+			{ID: "s", Service: "web", Kind: "Server", ParentID: "c", StartTime: now.Add(-time.Second), Duration: time.Millisecond},
+			{ID: "i", Service: "api", Kind: "Internal", ParentID: "c", StartTime: now.Add(-time.Second), Duration: time.Millisecond},
+		})
+
+		assert.Empty(t, skews)
+	})
+}
+
+func TestFlamegraph_BuildWithClockSkewAdjustment(t *testing.T) {
+	inputs := []flamegraph.NodeInput{
+		{ID: "root", Service: "web", Kind: "Server", StartTime: now, Duration: time.Second},
+		{ID: "call", Service: "web", Kind: "Client", ParentID: "root", StartTime: now, Duration: 500 * time.Millisecond},
+		{ID: "handle", Service: "api", Kind: "Server", ParentID: "call", StartTime: now.Add(-2 * time.Second), Duration: 300 * time.Millisecond},
+		{ID: "query", Service: "api", Kind: "Client", ParentID: "handle", StartTime: now.Add(-2 * time.Second), Duration: 100 * time.Millisecond},
+		{ID: "db", Service: "db", Kind: "Server", ParentID: "query", StartTime: now.Add(-2 * time.Second), Duration: 100 * time.Millisecond},
+	}
+	identity := func(ni flamegraph.NodeInput) flamegraph.NodeInput { return ni }
+
+	t.Run("keeps raw timestamps by default", func(t *testing.T) {
+		roots, err := flamegraph.Build(inputs, identity)
+
+		assert.Nil(t, err)
+		handle := roots[0].Children[0].Children[0]
+		assert.True(t, handle.ClockSkewed)
+		assert.Zero(t, handle.SkewAdjustment)
+		assert.Equal(t, now.Add(-2*time.Second), handle.StartTime)
+	})
+
+	t.Run("shifts services and their callees", func(t *testing.T) {
+		roots, err := flamegraph.Build(inputs, identity, flamegraph.WithClockSkewAdjustment())
+
+		assert.Nil(t, err)
+		assert.Zero(t, roots[0].SkewAdjustment)
+
+		handle := roots[0].Children[0].Children[0]
+		assert.False(t, handle.ClockSkewed)
+		assert.Equal(t, 2*time.Second+100*time.Millisecond, handle.SkewAdjustment)
+		assert.Equal(t, now.Add(100*time.Millisecond), handle.StartTime)
+
+		query := handle.Children[0]
+		assert.Equal(t, handle.SkewAdjustment, query.SkewAdjustment)
+
+		// The db was in sync with the api, so it moves along with it
+		db := query.Children[0]
+		assert.Equal(t, handle.SkewAdjustment, db.SkewAdjustment)
+		assert.Equal(t, query.StartTime, db.StartTime)
+
+		assert.InDelta(t, 0.1, handle.OffsetPct, 0.01)
+	})
+
+	t.Run("shifts each instance of a service by its own skew", func(t *testing.T) {
+		roots, err := flamegraph.Build([]flamegraph.NodeInput{
+			{ID: "root", Service: "web", Kind: "Server", StartTime: now, Duration: time.Second},
+			{ID: "call1", Service: "web", Kind: "Client", ParentID: "root", StartTime: now, Duration: 400 * time.Millisecond},
+			{ID: "handle1", Service: "api", Instance: "api-1", Kind: "Server", ParentID: "call1", StartTime: now.Add(-2 * time.Second), Duration: 200 * time.Millisecond},
+			{ID: "call2", Service: "web", Kind: "Client", ParentID: "root", StartTime: now.Add(500 * time.Millisecond), Duration: 400 * time.Millisecond},
+			{ID: "handle2", Service: "api", Instance: "api-2", Kind: "Server", ParentID: "call2", StartTime: now.Add(3 * time.Second), Duration: 200 * time.Millisecond},
+		}, identity, flamegraph.WithClockSkewAdjustment())
+
+		assert.Nil(t, err)
+
+		handle1 := roots[0].Children[0].Children[0]
+		assert.Equal(t, "handle1", handle1.ID)
+		assert.Equal(t, 2*time.Second+100*time.Millisecond, handle1.SkewAdjustment)
+		assert.Equal(t, now.Add(100*time.Millisecond), handle1.StartTime)
+
+		handle2 := roots[0].Children[1].Children[0]
+		assert.Equal(t, "handle2", handle2.ID)
+		assert.Equal(t, -2*time.Second-400*time.Millisecond, handle2.SkewAdjustment)
+		assert.Equal(t, now.Add(600*time.Millisecond), handle2.StartTime)
+	})
+}
@@ -0,0 +1,411 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui/flamegraph"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"go.uber.org/zap"
+)
+
+// aggregateTraceLimit is the max number of traces merged into the
+// flamegraph, to keep it responsive when a lot of data has been collected.
+const aggregateTraceLimit = 500
+
+// FlamegraphPageModel shows a flamegraph aggregated across all the traces
+// with the same root span name, to answer where time goes for an endpoint
+// overall rather than for a single request.
+type FlamegraphPageModel struct {
+	width  int
+	height int
+
+	tableModel TableModel
+
+	// focusGraph is set when key presses go to the graph rather than the
+	// table of root span names.
+	focusGraph bool
+
+	rootSpanName string
+	root         flamegraph.Frame
+	// zoom is the path to the frame the graph is drilled down into
+	zoom []int
+	// selected is the path to the selected frame, always below zoom
+	selected []int
+	// bySelf makes the width of the frames proportional to self time rather
+	// than total time.
+	bySelf bool
+
+	db *db.Database
+}
+
+func NewFlamegraphPageModel(db *db.Database) FlamegraphPageModel {
+	tm := NewTableModel()
+	tm.SetColumnDefinitions([]ColumnDefinition{
+		{4, "Root span"},
+		{1, "Traces"},
+	})
+
+	return FlamegraphPageModel{
+		tableModel: tm,
+		db:         db,
+	}
+}
+
+func (m FlamegraphPageModel) Init() tea.Cmd {
+	return tea.Batch(
+		m.loadRootSpanNames(),
+		m.tableModel.Init(),
+	)
+}
+
+func (m FlamegraphPageModel) Update(msg tea.Msg) (FlamegraphPageModel, tea.Cmd) {
+	var cmd tea.Cmd
+	cmds := make([]tea.Cmd, 0)
+
+	switch msg := msg.(type) {
+	case MsgNewSpans:
+		cmds = append(cmds, m.loadRootSpanNames())
+		if m.rootSpanName != "" {
+			cmds = append(cmds, m.loadAggregate(m.rootSpanName))
+		}
+	case MsgRootSpanNamesUpdated:
+		items := make([]TableItemDelegate, len(msg.names))
+		for i, name := range msg.names {
+			items[i] = &spanNameTableItemDelegate{name: name}
+		}
+		m.tableModel.SetItems(items)
+	case MsgOpenRootSpan:
+		m.focusGraph = false
+		m.selectRootSpan(msg.name)
+	case MsgAggregateUpdated:
+		if msg.rootSpanName == m.rootSpanName {
+			m.root = msg.root
+			m.clampSelection()
+		}
+	case tea.KeyMsg:
+		if m.focusGraph {
+			m.updateGraph(msg)
+			return m, nil
+		}
+
+		switch {
+		case key.Matches(msg, keys.Select):
+			m.focusGraph = true
+			return m, nil
+		}
+	}
+
+	m.tableModel, cmd = m.tableModel.Update(translateMouse(msg, 1, 1))
+	cmds = append(cmds, cmd)
+
+	if item, ok := m.tableModel.SelectedItem().(*spanNameTableItemDelegate); ok && item.name.Name != m.rootSpanName {
+		m.rootSpanName = item.name.Name
+		m.root = flamegraph.Frame{}
+		m.zoom = nil
+		m.selected = nil
+		cmds = append(cmds, m.loadAggregate(m.rootSpanName))
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// Commands open the flamegraph of each root span.
+func (m FlamegraphPageModel) Commands() []Command {
+	commands := make([]Command, 0)
+	for _, item := range m.tableModel.items {
+		if d, ok := item.(*spanNameTableItemDelegate); ok {
+			commands = append(commands, Command{"Root span", d.name.Name, helpers.Cmdize(MsgOpenRootSpan{d.name.Name})})
+		}
+	}
+
+	return commands
+}
+
+func (m *FlamegraphPageModel) selectRootSpan(name string) {
+	for i, item := range m.tableModel.items {
+		if d, ok := item.(*spanNameTableItemDelegate); ok && d.name.Name == name {
+			m.tableModel.SetCursorRow(i)
+			return
+		}
+	}
+}
+
+func (m *FlamegraphPageModel) updateGraph(msg tea.KeyMsg) {
+	switch {
+	case key.Matches(msg, keys.Back):
+		m.focusGraph = false
+	case key.Matches(msg, keys.Down):
+		if f := m.root.Find(m.selected); f != nil && len(f.Children) > 0 {
+			m.selected = append(slices.Clone(m.selected), 0)
+		}
+	case key.Matches(msg, keys.Up):
+		if len(m.selected) > len(m.zoom) {
+			m.selected = m.selected[:len(m.selected)-1]
+		}
+	case key.Matches(msg, keys.Left):
+		m.moveSibling(-1)
+	case key.Matches(msg, keys.Right):
+		m.moveSibling(1)
+	case key.Matches(msg, keys.Select):
+		m.zoom = slices.Clone(m.selected)
+	case key.Matches(msg, keys.ZoomOut):
+		if len(m.zoom) > 0 {
+			m.zoom = m.zoom[:len(m.zoom)-1]
+		}
+	case key.Matches(msg, keys.SelfTime):
+		m.bySelf = !m.bySelf
+	}
+}
+
+func (m FlamegraphPageModel) ShortHelp() []key.Binding {
+	if m.focusGraph {
+		return []key.Binding{
+			withHelp(keys.Select, "zoom in"),
+			keys.ZoomOut,
+			keys.SelfTime,
+			keys.Back,
+		}
+	}
+
+	return []key.Binding{withHelp(keys.Select, "browse flamegraph")}
+}
+
+func (m FlamegraphPageModel) FullHelp() [][]key.Binding {
+	if m.focusGraph {
+		return [][]key.Binding{
+			{
+				withHelp(keys.Up, "parent"),
+				withHelp(keys.Down, "first child"),
+				withHelp(keys.Left, "previous sibling"),
+				withHelp(keys.Right, "next sibling"),
+			},
+			m.ShortHelp(),
+		}
+	}
+
+	return [][]key.Binding{navigationBindings(), m.ShortHelp()}
+}
+
+func (m *FlamegraphPageModel) moveSibling(delta int) {
+	if len(m.selected) <= len(m.zoom) {
+		return
+	}
+
+	parent := m.root.Find(m.selected[:len(m.selected)-1])
+	if parent == nil {
+		return
+	}
+
+	i := len(m.selected) - 1
+	m.selected[i] = helpers.Clamp(0, m.selected[i]+delta, len(parent.Children)-1)
+}
+
+// clampSelection makes sure the zoom and selection still point at frames
+// after the graph has been rebuilt.
+func (m *FlamegraphPageModel) clampSelection() {
+	for m.root.Find(m.zoom) == nil {
+		m.zoom = m.zoom[:len(m.zoom)-1]
+	}
+	if len(m.selected) < len(m.zoom) || !slices.Equal(m.selected[:len(m.zoom)], m.zoom) {
+		m.selected = slices.Clone(m.zoom)
+	}
+	for m.root.Find(m.selected) == nil {
+		m.selected = m.selected[:len(m.selected)-1]
+	}
+}
+
+func (m FlamegraphPageModel) View() string {
+	return helpers.HStack(m.tableView(), m.graphView())
+}
+
+func (m FlamegraphPageModel) tableView() string {
+	container := lipgloss.
+		NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(helpers.ColorBorder).
+		BorderBackground(helpers.ColorBackground).
+		Background(helpers.ColorBackground)
+
+	if !m.focusGraph {
+		container = container.BorderForeground(helpers.ColorRing)
+	}
+
+	return container.Render(m.tableModel.View())
+}
+
+func (m FlamegraphPageModel) graphWidth() int {
+	return int(math.Ceil(float64(m.width)*(2.0/3.0))) - 2
+}
+
+func (m FlamegraphPageModel) graphView() string {
+	width := m.graphWidth()
+	height := m.height - 2
+
+	container := lipgloss.
+		NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(helpers.ColorBorder).
+		Width(width).
+		MaxWidth(width + 2).
+		Height(height).
+		MaxHeight(height + 2)
+
+	if m.focusGraph {
+		container = container.BorderForeground(helpers.ColorRing)
+	}
+
+	zoomed := m.root.Find(m.zoom)
+	if zoomed == nil || zoomed.Total == 0 {
+		return container.Align(lipgloss.Center, lipgloss.Center).Render("Select a root span to see its flamegraph")
+	}
+
+	rows := flamegraph.Layout(zoomed, width, m.bySelf)
+
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		var line strings.Builder
+		x := 0
+		for i, cell := range row {
+			line.WriteString(strings.Repeat(" ", cell.X-x))
+			line.WriteString(m.cellStyle(cell, i).Render(cell.Frame.Name))
+			x = cell.X + cell.Width
+		}
+		lines = append(lines, line.String())
+	}
+
+	return container.Render(
+		helpers.VStack(
+			m.selectedFrameView(zoomed),
+			"", // spacer
+			helpers.VStack(lines...),
+		),
+	)
+}
+
+func (m FlamegraphPageModel) cellStyle(cell flamegraph.FrameCell, i int) lipgloss.Style {
+	style := lipgloss.
+		NewStyle().
+		Width(cell.Width).
+		MaxWidth(cell.Width).
+		Inline(true)
+
+	path := append(slices.Clone(m.zoom), cell.Path...)
+	selected := slices.Equal(path, m.selected)
+
+	if !helpers.HasColor() {
+		// Every other frame is reversed to tell neighbours apart
+		return style.Reverse(i%2 == 0).Bold(selected).Underline(selected)
+	}
+
+	switch {
+	case selected:
+		return style.Background(helpers.ColorSecondary).Foreground(helpers.ColorSecondaryForeground)
+	case i%2 == 0:
+		return style.Background(helpers.ColorPrimary).Foreground(helpers.ColorPrimaryForeground)
+	default:
+		return style.Background(helpers.ColorAccent).Foreground(helpers.ColorAccentForeground)
+	}
+}
+
+func (m FlamegraphPageModel) selectedFrameView(zoomed *flamegraph.Frame) string {
+	selected := m.root.Find(m.selected)
+	if selected == nil {
+		return ""
+	}
+
+	pct := 0.0
+	if v := zoomed.Value(m.bySelf); v > 0 {
+		pct = float64(selected.Value(m.bySelf)) / float64(v) * 100
+	}
+
+	mode := "total"
+	if m.bySelf {
+		mode = "self"
+	}
+
+	return lipgloss.NewStyle().Render(
+		selected.Name,
+		"•",
+		"total", selected.Total.Round(time.Microsecond).String(),
+		"•",
+		"self", selected.Self.Round(time.Microsecond).String(),
+		"•",
+		strconv.Itoa(selected.Count), "spans",
+		"•",
+		fmt.Sprintf("%.1f%% of %s", pct, mode),
+	)
+}
+
+func (m FlamegraphPageModel) loadRootSpanNames() tea.Cmd {
+	return func() tea.Msg {
+		names, err := m.db.GetRootSpanNames(context.Background())
+		if err != nil {
+			zap.L().Warn("could not get root span names", zap.Error(err))
+			return nil
+		}
+
+		return MsgRootSpanNamesUpdated{names: names}
+	}
+}
+
+func (m FlamegraphPageModel) loadAggregate(rootSpanName string) tea.Cmd {
+	return func() tea.Msg {
+		spans, err := m.db.GetSpansForRootSpanName(context.Background(), rootSpanName, aggregateTraceLimit)
+		if err != nil {
+			zap.L().Warn("could not get spans for root span name", zap.String("rootSpanName", rootSpanName), zap.Error(err))
+			return nil
+		}
+
+		traceIDs := make([]string, 0)
+		traces := make(map[string][]db.Span)
+		for _, span := range spans {
+			if _, ok := traces[span.TraceID]; !ok {
+				traceIDs = append(traceIDs, span.TraceID)
+			}
+			traces[span.TraceID] = append(traces[span.TraceID], span)
+		}
+
+		forests := make([]flamegraph.Forest, 0, len(traceIDs))
+		for _, traceID := range traceIDs {
+			roots, err := flamegraph.Build(traces[traceID], spanNodeInput)
+			if err != nil {
+				zap.L().Warn("could not create flamegraph for trace", zap.String("traceID", traceID), zap.Error(err))
+				continue
+			}
+			forests = append(forests, roots)
+		}
+
+		return MsgAggregateUpdated{rootSpanName: rootSpanName, root: flamegraph.Aggregate(forests...)}
+	}
+}
+
+func (m *FlamegraphPageModel) SetWidth(w int) {
+	m.width = w
+	m.tableModel.SetWidth(int(math.Floor(float64(w)*(1.0/3.0))) - 2)
+}
+
+func (m *FlamegraphPageModel) SetHeight(h int) {
+	m.height = h
+	m.tableModel.SetHeight(h - 2)
+}
+
+type spanNameTableItemDelegate struct {
+	name db.SpanNameCount
+}
+
+func (d spanNameTableItemDelegate) Content() []string {
+	return []string{
+		d.name.Name,
+		strconv.Itoa(d.name.Count),
+	}
+}
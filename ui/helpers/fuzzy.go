@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"strings"
+	"unicode"
+)
+
+// FuzzyMatch reports whether the characters of the pattern appear in the
+// text in order, ignoring case, along with a score of how good the match
+// is. Consecutive characters, characters at the start of words and matches
+// early in the text score higher.
+func FuzzyMatch(pattern, text string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	// The first match isn't necessarily the best, e.g. "sp" in "users
+	// span" should match the start of "span" rather than the s in "users".
+	best, found := 0, false
+	for start := range t {
+		if t[start] != p[0] {
+			continue
+		}
+
+		if score, ok := fuzzyScore(p, t, start); ok && (!found || score > best) {
+			best, found = score, true
+		}
+	}
+
+	return best, found
+}
+
+func fuzzyScore(p, t []rune, start int) (int, bool) {
+	score, matched, previous := 0, 0, -2
+	for i := start; i < len(t) && matched < len(p); i++ {
+		if t[i] != p[matched] {
+			continue
+		}
+
+		score += 1
+		if i == previous+1 {
+			score += 5
+		}
+		if i == 0 || isWordSeparator(t[i-1]) {
+			score += 3
+		}
+
+		previous = i
+		matched++
+	}
+
+	if matched < len(p) {
+		return 0, false
+	}
+
+	return score - start, true
+}
+
+func isWordSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("./-_:", r)
+}
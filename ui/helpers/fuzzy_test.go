@@ -0,0 +1,48 @@
+package helpers_test
+
+import (
+	"testing"
+
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	t.Run("matches characters in order", func(t *testing.T) {
+		for _, tc := range []struct {
+			pattern  string
+			text     string
+			expected bool
+		}{
+			{"", "anything", true},
+			{"lgs", "Go to logs", true},
+			{"LOGS", "Go to logs", true},
+			{"sgol", "Go to logs", false},
+			{"logsx", "Go to logs", false},
+		} {
+			_, ok := helpers.FuzzyMatch(tc.pattern, tc.text)
+			assert.Equal(t, tc.expected, ok, tc)
+		}
+	})
+
+	t.Run("prefers consecutive characters", func(t *testing.T) {
+		consecutive, _ := helpers.FuzzyMatch("span", "span name")
+		spread, _ := helpers.FuzzyMatch("span", "s p a n")
+
+		assert.Greater(t, consecutive, spread)
+	})
+
+	t.Run("prefers the start of words", func(t *testing.T) {
+		start, _ := helpers.FuzzyMatch("sm", "service map")
+		middle, _ := helpers.FuzzyMatch("sm", "osmosis")
+
+		assert.Greater(t, start, middle)
+	})
+
+	t.Run("finds the best match rather than the first", func(t *testing.T) {
+		score, _ := helpers.FuzzyMatch("sp", "users span")
+		direct, _ := helpers.FuzzyMatch("sp", "span")
+
+		assert.Equal(t, direct-len("users "), score)
+	})
+}
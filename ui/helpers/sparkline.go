@@ -0,0 +1,28 @@
+package helpers
+
+import "strings"
+
+var sparklineBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the values as a single line of block characters, scaled
+// so that the largest value is a full block. Zero values are left blank to
+// tell them apart from small ones.
+func Sparkline(values []float64) string {
+	var largest float64
+	for _, v := range values {
+		largest = max(largest, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		if v <= 0 || largest == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+
+		i := int(v / largest * float64(len(sparklineBlocks)-1))
+		b.WriteRune(sparklineBlocks[Clamp(0, i, len(sparklineBlocks)-1)])
+	}
+
+	return b.String()
+}
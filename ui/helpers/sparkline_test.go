@@ -0,0 +1,26 @@
+package helpers_test
+
+import (
+	"testing"
+
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparkline(t *testing.T) {
+	tc := []struct {
+		values   []float64
+		expected string
+	}{
+		{[]float64{}, ""},
+		{[]float64{0, 0}, "  "},
+		{[]float64{1, 2, 4, 8}, "▁▂▄█"},
+		{[]float64{0, 7, 0.5}, " █▁"},
+	}
+
+	for _, c := range tc {
+		t.Run("renders sparkline", func(t *testing.T) {
+			assert.Equal(t, c.expected, helpers.Sparkline(c.values))
+		})
+	}
+}
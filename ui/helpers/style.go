@@ -2,35 +2,35 @@ package helpers
 
 import "github.com/charmbracelet/lipgloss"
 
-// Based on Dracula
+// The colors of the current theme, see SetTheme.
 var (
-	ColorPrimary           = lipgloss.Color("#bd93f9")
-	ColorPrimaryForeground = lipgloss.Color("#282a36")
+	ColorPrimary           lipgloss.TerminalColor
+	ColorPrimaryForeground lipgloss.TerminalColor
 
-	ColorSecondary           = lipgloss.Color("#ff79c6")
-	ColorSecondaryForeground = lipgloss.Color("#282a36")
+	ColorSecondary           lipgloss.TerminalColor
+	ColorSecondaryForeground lipgloss.TerminalColor
 
-	ColorAccent           = lipgloss.Color("#50fa7b")
-	ColorAccentForeground = lipgloss.Color("#282a36")
+	ColorAccent           lipgloss.TerminalColor
+	ColorAccentForeground lipgloss.TerminalColor
 
-	ColorBackground = lipgloss.Color("#282a36")
-	ColorForeground = lipgloss.Color("#f8f8f2")
+	ColorBackground lipgloss.TerminalColor
+	ColorForeground lipgloss.TerminalColor
 
-	ColorMuted           = lipgloss.Color("#44475a")
-	ColorMutedForeground = lipgloss.Color("#6272a4")
+	ColorMuted           lipgloss.TerminalColor
+	ColorMutedForeground lipgloss.TerminalColor
 
-	ColorCard           = lipgloss.Color("#44475a")
-	ColorCardForeground = lipgloss.Color("#f8f8f2")
+	ColorCard           lipgloss.TerminalColor
+	ColorCardForeground lipgloss.TerminalColor
 
-	ColorPopover           = lipgloss.Color("#282a36")
-	ColorPopoverForeground = lipgloss.Color("#f8f8f2")
+	ColorPopover           lipgloss.TerminalColor
+	ColorPopoverForeground lipgloss.TerminalColor
 
-	ColorBorder = lipgloss.Color("#6272a4")
-	ColorInput  = lipgloss.Color("#44475a")
-	ColorRing   = lipgloss.Color("#bd93f9")
+	ColorBorder lipgloss.TerminalColor
+	ColorInput  lipgloss.TerminalColor
+	ColorRing   lipgloss.TerminalColor
 
-	ColorDestructive           = lipgloss.Color("#ff5555")
-	ColorDestructiveForeground = lipgloss.Color("#f8f8f2")
+	ColorDestructive           lipgloss.TerminalColor
+	ColorDestructiveForeground lipgloss.TerminalColor
 
 	// Legacy/utility colors
 	ColorBlack = lipgloss.Color("#000000")
@@ -38,4 +38,8 @@ var (
 	ColorGray  = lipgloss.Color("#aaaaaa")
 )
 
-var NavigationPillBaseStyle = lipgloss.NewStyle().Background(ColorBackground).Padding(0, 1)
+var NavigationPillBaseStyle lipgloss.Style
+
+func init() {
+	SetTheme(ThemeDracula)
+}
@@ -0,0 +1,266 @@
+package helpers
+
+import "github.com/charmbracelet/lipgloss"
+
+// Theme is the colors of the UI. The built-in themes pick colors for each
+// color profile, so they still look right on 256 and 16 color terminals.
+type Theme struct {
+	Name string
+	// NoColor themes leave all colors to the terminal, and highlight by
+	// reversing instead.
+	NoColor bool
+
+	Primary           lipgloss.TerminalColor
+	PrimaryForeground lipgloss.TerminalColor
+
+	Secondary           lipgloss.TerminalColor
+	SecondaryForeground lipgloss.TerminalColor
+
+	Accent           lipgloss.TerminalColor
+	AccentForeground lipgloss.TerminalColor
+
+	Background lipgloss.TerminalColor
+	Foreground lipgloss.TerminalColor
+
+	Muted           lipgloss.TerminalColor
+	MutedForeground lipgloss.TerminalColor
+
+	Card           lipgloss.TerminalColor
+	CardForeground lipgloss.TerminalColor
+
+	Popover           lipgloss.TerminalColor
+	PopoverForeground lipgloss.TerminalColor
+
+	Border lipgloss.TerminalColor
+	Input  lipgloss.TerminalColor
+	Ring   lipgloss.TerminalColor
+
+	Destructive           lipgloss.TerminalColor
+	DestructiveForeground lipgloss.TerminalColor
+}
+
+// color is a true color along with the closest looking colors on 256 and
+// 16 color terminals. Automatic degradation picks the nearest color, which
+// on 16 colors often makes text and background the same.
+func color(trueColor, ansi256, ansi string) lipgloss.TerminalColor {
+	return lipgloss.CompleteColor{TrueColor: trueColor, ANSI256: ansi256, ANSI: ansi}
+}
+
+// ThemeDracula is the default theme, for dark terminals.
+var ThemeDracula = Theme{
+	Name: "dracula",
+
+	Primary:           color("#bd93f9", "141", "12"),
+	PrimaryForeground: color("#282a36", "236", "0"),
+
+	Secondary:           color("#ff79c6", "212", "13"),
+	SecondaryForeground: color("#282a36", "236", "0"),
+
+	Accent:           color("#50fa7b", "84", "10"),
+	AccentForeground: color("#282a36", "236", "0"),
+
+	Background: color("#282a36", "236", "0"),
+	Foreground: color("#f8f8f2", "255", "15"),
+
+	Muted:           color("#44475a", "238", "8"),
+	MutedForeground: color("#6272a4", "61", "7"),
+
+	Card:           color("#44475a", "238", "8"),
+	CardForeground: color("#f8f8f2", "255", "15"),
+
+	Popover:           color("#282a36", "236", "0"),
+	PopoverForeground: color("#f8f8f2", "255", "15"),
+
+	Border: color("#6272a4", "61", "4"),
+	Input:  color("#44475a", "238", "8"),
+	Ring:   color("#bd93f9", "141", "12"),
+
+	Destructive:           color("#ff5555", "203", "9"),
+	DestructiveForeground: color("#f8f8f2", "255", "15"),
+}
+
+// ThemeLight is Dracula's light variant, for light terminals.
+var ThemeLight = Theme{
+	Name: "light",
+
+	Primary:           color("#644ac9", "62", "5"),
+	PrimaryForeground: color("#fffbeb", "231", "15"),
+
+	Secondary:           color("#a3144d", "125", "1"),
+	SecondaryForeground: color("#fffbeb", "231", "15"),
+
+	Accent:           color("#14710a", "28", "2"),
+	AccentForeground: color("#fffbeb", "231", "15"),
+
+	Background: color("#fffbeb", "231", "15"),
+	Foreground: color("#1f1f1f", "234", "0"),
+
+	Muted:           color("#cfcfde", "253", "7"),
+	MutedForeground: color("#635d97", "60", "8"),
+
+	Card:           color("#cfcfde", "253", "7"),
+	CardForeground: color("#1f1f1f", "234", "0"),
+
+	Popover:           color("#fffbeb", "231", "15"),
+	PopoverForeground: color("#1f1f1f", "234", "0"),
+
+	Border: color("#635d97", "60", "8"),
+	Input:  color("#cfcfde", "253", "7"),
+	Ring:   color("#644ac9", "62", "5"),
+
+	Destructive:           color("#cb3a2a", "160", "1"),
+	DestructiveForeground: color("#fffbeb", "231", "15"),
+}
+
+// ThemeANSI only uses the 16 colors of the terminal and keeps its
+// background, so it follows whatever colors the terminal is set up with.
+var ThemeANSI = Theme{
+	Name: "ansi",
+
+	Primary:           lipgloss.ANSIColor(4),
+	PrimaryForeground: lipgloss.ANSIColor(15),
+
+	Secondary:           lipgloss.ANSIColor(5),
+	SecondaryForeground: lipgloss.ANSIColor(15),
+
+	Accent:           lipgloss.ANSIColor(2),
+	AccentForeground: lipgloss.ANSIColor(0),
+
+	Background: lipgloss.NoColor{},
+	Foreground: lipgloss.NoColor{},
+
+	Muted:           lipgloss.ANSIColor(8),
+	MutedForeground: lipgloss.ANSIColor(8),
+
+	Card:           lipgloss.ANSIColor(8),
+	CardForeground: lipgloss.NoColor{},
+
+	Popover:           lipgloss.NoColor{},
+	PopoverForeground: lipgloss.NoColor{},
+
+	Border: lipgloss.ANSIColor(8),
+	Input:  lipgloss.ANSIColor(8),
+	Ring:   lipgloss.ANSIColor(4),
+
+	Destructive:           lipgloss.ANSIColor(1),
+	DestructiveForeground: lipgloss.ANSIColor(15),
+}
+
+// ThemeMono is shades of gray on the terminal's own background.
+var ThemeMono = Theme{
+	Name: "mono",
+
+	Primary:           lipgloss.ANSIColor(7),
+	PrimaryForeground: lipgloss.ANSIColor(0),
+
+	Secondary:           lipgloss.ANSIColor(15),
+	SecondaryForeground: lipgloss.ANSIColor(0),
+
+	Accent:           lipgloss.ANSIColor(8),
+	AccentForeground: lipgloss.ANSIColor(15),
+
+	Background: lipgloss.NoColor{},
+	Foreground: lipgloss.NoColor{},
+
+	Muted:           lipgloss.ANSIColor(8),
+	MutedForeground: lipgloss.ANSIColor(8),
+
+	Card:           lipgloss.ANSIColor(8),
+	CardForeground: lipgloss.NoColor{},
+
+	Popover:           lipgloss.NoColor{},
+	PopoverForeground: lipgloss.NoColor{},
+
+	Border: lipgloss.ANSIColor(8),
+	Input:  lipgloss.ANSIColor(8),
+	Ring:   lipgloss.ANSIColor(15),
+
+	Destructive:           lipgloss.ANSIColor(15),
+	DestructiveForeground: lipgloss.ANSIColor(0),
+}
+
+// ThemeNoColor doesn't color anything, for when NO_COLOR is set.
+var ThemeNoColor = Theme{
+	Name:    "none",
+	NoColor: true,
+
+	Primary:           lipgloss.NoColor{},
+	PrimaryForeground: lipgloss.NoColor{},
+
+	Secondary:           lipgloss.NoColor{},
+	SecondaryForeground: lipgloss.NoColor{},
+
+	Accent:           lipgloss.NoColor{},
+	AccentForeground: lipgloss.NoColor{},
+
+	Background: lipgloss.NoColor{},
+	Foreground: lipgloss.NoColor{},
+
+	Muted:           lipgloss.NoColor{},
+	MutedForeground: lipgloss.NoColor{},
+
+	Card:           lipgloss.NoColor{},
+	CardForeground: lipgloss.NoColor{},
+
+	Popover:           lipgloss.NoColor{},
+	PopoverForeground: lipgloss.NoColor{},
+
+	Border: lipgloss.NoColor{},
+	Input:  lipgloss.NoColor{},
+	Ring:   lipgloss.NoColor{},
+
+	Destructive:           lipgloss.NoColor{},
+	DestructiveForeground: lipgloss.NoColor{},
+}
+
+// Themes are the built-in themes.
+var Themes = []Theme{ThemeDracula, ThemeLight, ThemeANSI, ThemeMono, ThemeNoColor}
+
+var currentTheme Theme
+
+// SetTheme changes the colors of the UI, taking effect on the next render.
+func SetTheme(t Theme) {
+	currentTheme = t
+
+	ColorPrimary = t.Primary
+	ColorPrimaryForeground = t.PrimaryForeground
+	ColorSecondary = t.Secondary
+	ColorSecondaryForeground = t.SecondaryForeground
+	ColorAccent = t.Accent
+	ColorAccentForeground = t.AccentForeground
+	ColorBackground = t.Background
+	ColorForeground = t.Foreground
+	ColorMuted = t.Muted
+	ColorMutedForeground = t.MutedForeground
+	ColorCard = t.Card
+	ColorCardForeground = t.CardForeground
+	ColorPopover = t.Popover
+	ColorPopoverForeground = t.PopoverForeground
+	ColorBorder = t.Border
+	ColorInput = t.Input
+	ColorRing = t.Ring
+	ColorDestructive = t.Destructive
+	ColorDestructiveForeground = t.DestructiveForeground
+
+	NavigationPillBaseStyle = lipgloss.NewStyle().Background(ColorBackground).Padding(0, 1)
+}
+
+// CurrentTheme is the theme last passed to SetTheme.
+func CurrentTheme() Theme {
+	return currentTheme
+}
+
+// HasColor is false when the theme doesn't color anything.
+func HasColor() bool {
+	return !currentTheme.NoColor
+}
+
+// Highlight colors the style to stand out, e.g. for the selected row. It's
+// reversed instead without colors, as they'd otherwise look like the rest.
+func Highlight(style lipgloss.Style, background, foreground lipgloss.TerminalColor) lipgloss.Style {
+	if !HasColor() {
+		return style.Reverse(true)
+	}
+
+	return style.Background(background).Foreground(foreground)
+}
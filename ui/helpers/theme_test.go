@@ -0,0 +1,37 @@
+package helpers_test
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	t.Cleanup(func() {
+		lipgloss.SetColorProfile(profile)
+		helpers.SetTheme(helpers.ThemeDracula)
+	})
+
+	render := func(p termenv.Profile) string {
+		lipgloss.SetColorProfile(p)
+
+		return helpers.Highlight(lipgloss.NewStyle(), helpers.ThemeDracula.Primary, helpers.ThemeDracula.PrimaryForeground).Render("x")
+	}
+
+	t.Run("uses the colors picked for the color profile", func(t *testing.T) {
+		assert.Contains(t, render(termenv.TrueColor), "48;2;189;147;249")
+		assert.Contains(t, render(termenv.ANSI256), "48;5;141")
+		// Bright blue background
+		assert.Contains(t, render(termenv.ANSI), "104")
+	})
+
+	t.Run("reverses without colors", func(t *testing.T) {
+		helpers.SetTheme(helpers.ThemeNoColor)
+
+		assert.Equal(t, "\x1b[7mx\x1b[0m", render(termenv.ANSI))
+	})
+}
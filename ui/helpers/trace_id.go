@@ -0,0 +1,46 @@
+package helpers
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidTraceID = errors.New("expected a trace ID of 32 hex characters or a traceparent header")
+
+// ParseTraceID returns the trace ID in lower case hex, as it's stored, from
+// either a trace ID or a W3C traceparent header value such as
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01. The header name
+// may be included, as it's often copied along with the value.
+func ParseTraceID(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if name, value, ok := strings.Cut(s, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "traceparent") {
+		s = strings.TrimSpace(value)
+	}
+	s = strings.ToLower(s)
+
+	traceID := s
+	if strings.Contains(s, "-") {
+		parts := strings.Split(s, "-")
+		if len(parts) < 4 || !isHex(parts[0], 2) || parts[0] == "ff" || !isHex(parts[2], 16) || !isHex(parts[3], 2) {
+			return "", ErrInvalidTraceID
+		}
+		traceID = parts[1]
+	}
+
+	if !isHex(traceID, 32) || traceID == strings.Repeat("0", 32) {
+		return "", ErrInvalidTraceID
+	}
+
+	return traceID, nil
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+
+	_, err := hex.DecodeString(s)
+
+	return err == nil
+}
@@ -0,0 +1,42 @@
+package helpers_test
+
+import (
+	"testing"
+
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceID(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	t.Run("parses trace IDs and traceparents", func(t *testing.T) {
+		for _, s := range []string{
+			traceID,
+			"  4BF92F3577B34DA6A3CE929D0E0E4736\n",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"traceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"Traceparent:00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+		} {
+			parsed, err := helpers.ParseTraceID(s)
+			assert.Nil(t, err, s)
+			assert.Equal(t, traceID, parsed, s)
+		}
+	})
+
+	t.Run("rejects anything else", func(t *testing.T) {
+		for _, s := range []string{
+			"",
+			"4bf92f3577b34da6",
+			"4bf92f3577b34da6a3ce929d0e0e473g",
+			"00000000000000000000000000000000",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa-01",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"tracestate: 4bf92f3577b34da6a3ce929d0e0e4736",
+		} {
+			_, err := helpers.ParseTraceID(s)
+			assert.ErrorIs(t, err, helpers.ErrInvalidTraceID, s)
+		}
+	})
+}
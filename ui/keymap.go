@@ -0,0 +1,261 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// KeyMap has every key binding of the UI. Models match key presses against
+// these rather than the keys themselves, so they can be remapped.
+type KeyMap struct {
+	Quit    key.Binding
+	Help    key.Binding
+	Palette key.Binding
+
+	NextCommand     key.Binding
+	PreviousCommand key.Binding
+
+	SpansPage      key.Binding
+	FlamegraphPage key.Binding
+	ServiceMapPage key.Binding
+	ServicesPage   key.Binding
+	LogsPage       key.Binding
+
+	Up       key.Binding
+	Down     key.Binding
+	Left     key.Binding
+	Right    key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	Select   key.Binding
+	Back     key.Binding
+	Toggle   key.Binding
+
+	OpenTrace  key.Binding
+	MarkTrace  key.Binding
+	DiffTraces key.Binding
+	ClockSkew  key.Binding
+	Follow     key.Binding
+
+	FocusPane   key.Binding
+	GrowPane    key.Binding
+	ShrinkPane  key.Binding
+	RotateSplit key.Binding
+	Maximize    key.Binding
+
+	ZoomOut  key.Binding
+	SelfTime key.Binding
+
+	Export       key.Binding
+	Copy         key.Binding
+	CopyJSON     key.Binding
+	OpenInEditor key.Binding
+	Window       key.Binding
+
+	Search        key.Binding
+	Severity      key.Binding
+	Service       key.Binding
+	PreviousSlice key.Binding
+	NextSlice     key.Binding
+	ClearSlice    key.Binding
+}
+
+// keys is the key map used by all models, see SetKeyMap.
+var keys = DefaultKeyMap()
+
+// SetKeyMap replaces the key bindings, and has to be called before any
+// models are created.
+func SetKeyMap(km KeyMap) {
+	keys = km
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Quit:    newBinding("quit", "q", "ctrl+c"),
+		Help:    newBinding("toggle help", "?"),
+		Palette: newBinding("command palette", ":", "ctrl+p"),
+
+		NextCommand:     newBinding("next command", "down", "ctrl+n"),
+		PreviousCommand: newBinding("previous command", "up", "ctrl+p"),
+
+		SpansPage:      newBinding("spans", "1"),
+		FlamegraphPage: newBinding("flamegraph", "2"),
+		ServiceMapPage: newBinding("service map", "3"),
+		ServicesPage:   newBinding("services", "4"),
+		LogsPage:       newBinding("logs", "5"),
+
+		Up:       newBinding("up", "k", "up"),
+		Down:     newBinding("down", "j", "down"),
+		Left:     newBinding("left", "h", "left"),
+		Right:    newBinding("right", "l", "right"),
+		PageUp:   newBinding("page up", "pgup", "ctrl+u"),
+		PageDown: newBinding("page down", "pgdown", "ctrl+d"),
+		Top:      newBinding("top", "g", "home"),
+		Bottom:   newBinding("bottom", "G", "end"),
+		Select:   newBinding("select", "enter"),
+		Back:     newBinding("back", "esc"),
+		Toggle:   newBinding("expand/collapse", "enter", " "),
+
+		OpenTrace:  newBinding("open trace by ID", "o"),
+		MarkTrace:  newBinding("mark trace", "m"),
+		DiffTraces: newBinding("compare marked", "d"),
+		ClockSkew:  newBinding("adjust clock skew", "s"),
+		Follow:     newBinding("follow new traces", "f"),
+
+		FocusPane:   newBinding("switch pane", "tab"),
+		GrowPane:    newBinding("grow pane", "+", "="),
+		ShrinkPane:  newBinding("shrink pane", "-"),
+		RotateSplit: newBinding("rotate split", "|"),
+		Maximize:    newBinding("maximize pane", "z"),
+
+		ZoomOut:  newBinding("zoom out", "backspace", "u"),
+		SelfTime: newBinding("total/self time", "t"),
+
+		Export:       newBinding("export", "e"),
+		Copy:         newBinding("copy ID", "y"),
+		CopyJSON:     newBinding("copy as JSON", "Y"),
+		OpenInEditor: newBinding("open source", "E"),
+		Window:       newBinding("change window", "w"),
+
+		Search:        newBinding("search", "/"),
+		Severity:      newBinding("min severity", "s"),
+		Service:       newBinding("service", "v"),
+		PreviousSlice: newBinding("previous time slice", "["),
+		NextSlice:     newBinding("next time slice", "]"),
+		ClearSlice:    newBinding("clear time slice", "c"),
+	}
+}
+
+// named has the bindings by the names used in the key map file.
+func (km *KeyMap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"quit":             &km.Quit,
+		"help":             &km.Help,
+		"palette":          &km.Palette,
+		"next_command":     &km.NextCommand,
+		"previous_command": &km.PreviousCommand,
+		"spans_page":       &km.SpansPage,
+		"flamegraph_page":  &km.FlamegraphPage,
+		"service_map_page": &km.ServiceMapPage,
+		"services_page":    &km.ServicesPage,
+		"logs_page":        &km.LogsPage,
+		"up":               &km.Up,
+		"down":             &km.Down,
+		"left":             &km.Left,
+		"right":            &km.Right,
+		"page_up":          &km.PageUp,
+		"page_down":        &km.PageDown,
+		"top":              &km.Top,
+		"bottom":           &km.Bottom,
+		"select":           &km.Select,
+		"back":             &km.Back,
+		"toggle":           &km.Toggle,
+		"open_trace":       &km.OpenTrace,
+		"mark_trace":       &km.MarkTrace,
+		"diff_traces":      &km.DiffTraces,
+		"clock_skew":       &km.ClockSkew,
+		"follow":           &km.Follow,
+		"focus_pane":       &km.FocusPane,
+		"grow_pane":        &km.GrowPane,
+		"shrink_pane":      &km.ShrinkPane,
+		"rotate_split":     &km.RotateSplit,
+		"maximize":         &km.Maximize,
+		"zoom_out":         &km.ZoomOut,
+		"self_time":        &km.SelfTime,
+		"export":           &km.Export,
+		"copy":             &km.Copy,
+		"copy_json":        &km.CopyJSON,
+		"open_in_editor":   &km.OpenInEditor,
+		"window":           &km.Window,
+		"search":           &km.Search,
+		"severity":         &km.Severity,
+		"service":          &km.Service,
+		"previous_slice":   &km.PreviousSlice,
+		"next_slice":       &km.NextSlice,
+		"clear_slice":      &km.ClearSlice,
+	}
+}
+
+// LoadKeyMap returns the default key map with the bindings in the file
+// replaced. The file maps binding names to lists of keys, e.g.
+//
+//	down: [n, down]
+//	quit: [ctrl+q]
+//
+// A missing file isn't an error, as remapping keys is optional.
+func LoadKeyMap(path string) (KeyMap, error) {
+	km := DefaultKeyMap()
+
+	remapped := make(map[string][]string)
+	if err := loadYAML(path, &remapped); err != nil {
+		return km, err
+	}
+
+	named := km.named()
+	for name, keys := range remapped {
+		binding, ok := named[name]
+		if !ok {
+			return km, fmt.Errorf("unknown key binding %q in %s", name, path)
+		}
+		if len(keys) == 0 {
+			return km, fmt.Errorf("no keys for key binding %q in %s", name, path)
+		}
+
+		*binding = newBinding(binding.Help().Desc, keys...)
+	}
+
+	return km, nil
+}
+
+func newBinding(desc string, keys ...string) key.Binding {
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		labels[i] = keyLabel(k)
+	}
+
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(strings.Join(labels, "/"), desc))
+}
+
+// keyLabel is how the key is shown in help.
+func keyLabel(k string) string {
+	switch k {
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "left":
+		return "←"
+	case "right":
+		return "→"
+	case " ":
+		return "space"
+	}
+
+	return k
+}
+
+// keyName is the first key of the binding, for mentioning it in text.
+func keyName(b key.Binding) string {
+	if len(b.Keys()) == 0 {
+		return ""
+	}
+
+	return keyLabel(b.Keys()[0])
+}
+
+// withHelp is the binding described for where it's used, e.g. what
+// selecting does in a specific list.
+func withHelp(b key.Binding, desc string) key.Binding {
+	b.SetHelp(b.Help().Key, desc)
+
+	return b
+}
+
+// navigationBindings are the keys for moving around in lists.
+func navigationBindings() []key.Binding {
+	return []key.Binding{keys.Up, keys.Down, keys.PageUp, keys.PageDown, keys.Top, keys.Bottom}
+}
@@ -0,0 +1,49 @@
+package ui_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredrikaugust/otelly/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadKeyMap(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "keys.yaml")
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0o644))
+
+		return path
+	}
+
+	t.Run("uses the defaults without a file", func(t *testing.T) {
+		km, err := ui.LoadKeyMap(filepath.Join(t.TempDir(), "keys.yaml"))
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"j", "down"}, km.Down.Keys())
+	})
+
+	t.Run("remaps bindings", func(t *testing.T) {
+		km, err := ui.LoadKeyMap(write(t, "down: [n, down]\nquit: [ctrl+q]\n"))
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"n", "down"}, km.Down.Keys())
+		assert.Equal(t, "n/↓", km.Down.Help().Key)
+		assert.Equal(t, "down", km.Down.Help().Desc)
+		assert.Equal(t, []string{"ctrl+q"}, km.Quit.Keys())
+		assert.Equal(t, []string{"k", "up"}, km.Up.Keys())
+	})
+
+	t.Run("fails on unknown bindings", func(t *testing.T) {
+		_, err := ui.LoadKeyMap(write(t, "sideways: [x]\n"))
+
+		assert.ErrorContains(t, err, "sideways")
+	})
+
+	t.Run("fails on bindings without keys", func(t *testing.T) {
+		_, err := ui.LoadKeyMap(write(t, "down: []\n"))
+
+		assert.ErrorContains(t, err, "down")
+	})
+}
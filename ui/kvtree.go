@@ -0,0 +1,222 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+)
+
+// KVEntry is a top level entry in a KVTreeModel.
+type KVEntry struct {
+	Key   string
+	Value any
+}
+
+// KVTreeModel shows nested values, e.g. structured log bodies decoded from
+// JSON, as a tree of keys and values where maps and slices can be expanded
+// and collapsed. The top level entries start out expanded.
+type KVTreeModel struct {
+	width  int
+	height int
+
+	entries []KVEntry
+	// expanded is keyed by the path of the map or slice
+	expanded map[string]bool
+	rows     []kvTreeRow
+
+	cursor  int
+	yOffset int
+}
+
+type kvTreeRow struct {
+	path  string
+	depth int
+	key   string
+	value any
+}
+
+func NewKVTreeModel() KVTreeModel {
+	return KVTreeModel{expanded: make(map[string]bool)}
+}
+
+// SetEntries replaces the values shown. Maps and slices at the same path as
+// before stay expanded.
+func (m *KVTreeModel) SetEntries(entries []KVEntry) {
+	m.entries = entries
+	for _, e := range entries {
+		if _, ok := m.expanded[e.Key]; !ok {
+			m.expanded[e.Key] = true
+		}
+	}
+
+	m.updateRows()
+}
+
+func (m KVTreeModel) Update(msg tea.Msg) (KVTreeModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Down):
+			m.cursor += 1
+		case key.Matches(msg, keys.Up):
+			m.cursor -= 1
+		case key.Matches(msg, keys.PageDown):
+			m.cursor += max(1, m.height)
+		case key.Matches(msg, keys.PageUp):
+			m.cursor -= max(1, m.height)
+		case key.Matches(msg, keys.Top):
+			m.cursor = 0
+		case key.Matches(msg, keys.Bottom):
+			m.cursor = len(m.rows) - 1
+		case key.Matches(msg, keys.Toggle):
+			if row, ok := m.selectedRow(); ok && isContainer(row.value) {
+				m.expanded[row.path] = !m.expanded[row.path]
+			}
+		case key.Matches(msg, keys.Right):
+			if row, ok := m.selectedRow(); ok && isContainer(row.value) {
+				m.expanded[row.path] = true
+			}
+		case key.Matches(msg, keys.Left):
+			if row, ok := m.selectedRow(); ok && isContainer(row.value) && m.expanded[row.path] {
+				m.expanded[row.path] = false
+			} else if ok {
+				// Jump to the parent, like most tree views do
+				for i := m.cursor - 1; i >= 0; i-- {
+					if m.rows[i].depth < row.depth {
+						m.cursor = i
+						break
+					}
+				}
+			}
+		}
+
+		m.updateRows()
+	}
+
+	return m, nil
+}
+
+func (m KVTreeModel) selectedRow() (kvTreeRow, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return kvTreeRow{}, false
+	}
+
+	return m.rows[m.cursor], true
+}
+
+// updateRows flattens the expanded parts of the tree into rows and keeps
+// the cursor within them.
+func (m *KVTreeModel) updateRows() {
+	m.rows = make([]kvTreeRow, 0)
+	for _, e := range m.entries {
+		m.appendRows(e.Key, 0, e.Key, e.Value)
+	}
+
+	m.cursor = max(0, helpers.Clamp(0, m.cursor, len(m.rows)-1))
+	if m.cursor >= m.yOffset+m.height {
+		m.yOffset = m.cursor - m.height + 1
+	} else if m.cursor < m.yOffset {
+		m.yOffset = m.cursor
+	}
+}
+
+func (m *KVTreeModel) appendRows(path string, depth int, key string, value any) {
+	m.rows = append(m.rows, kvTreeRow{path, depth, key, value})
+	if !m.expanded[path] {
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			m.appendRows(path+"."+k, depth+1, k, v[k])
+		}
+	case []any:
+		for i, item := range v {
+			m.appendRows(path+"."+strconv.Itoa(i), depth+1, strconv.Itoa(i), item)
+		}
+	}
+}
+
+func isContainer(v any) bool {
+	switch v := v.(type) {
+	case map[string]any:
+		return len(v) > 0
+	case []any:
+		return len(v) > 0
+	}
+
+	return false
+}
+
+func (m KVTreeModel) View() string {
+	container := lipgloss.NewStyle().Width(m.width).MaxWidth(m.width).Height(m.height).MaxHeight(m.height)
+
+	end := min(len(m.rows), m.yOffset+m.height)
+	lines := make([]string, 0, max(0, end-m.yOffset))
+	for i := m.yOffset; i < end; i++ {
+		row := m.rows[i]
+
+		style := lipgloss.NewStyle().Width(m.width).MaxWidth(m.width)
+		if i == m.cursor {
+			style = helpers.Highlight(style, helpers.ColorPrimary, helpers.ColorPrimaryForeground)
+		}
+
+		lines = append(lines, style.Render(strings.Repeat("  ", row.depth)+m.rowView(row)))
+	}
+
+	return container.Render(helpers.VStack(lines...))
+}
+
+func (m KVTreeModel) rowView(row kvTreeRow) string {
+	if isContainer(row.value) {
+		marker := "▸"
+		if m.expanded[row.path] {
+			marker = "▾"
+		}
+
+		switch v := row.value.(type) {
+		case map[string]any:
+			return fmt.Sprintf("%s %s {%d}", marker, row.key, len(v))
+		case []any:
+			return fmt.Sprintf("%s %s [%d]", marker, row.key, len(v))
+		}
+	}
+
+	return fmt.Sprintf("  %s: %s", row.key, formatKVValue(row.value))
+}
+
+// formatKVValue formats scalars as they'd appear in JSON, except strings
+// which are shown without quotes and on a single line.
+func formatKVValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.ReplaceAll(v, "\n", "⏎")
+	case nil:
+		return "null"
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
+
+func (m *KVTreeModel) SetWidth(w int) {
+	m.width = w
+}
+
+func (m *KVTreeModel) SetHeight(h int) {
+	m.height = h
+	m.updateRows()
+}
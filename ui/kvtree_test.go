@@ -0,0 +1,66 @@
+package ui_test
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fredrikaugust/otelly/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKVTree(t *testing.T) {
+	newTree := func() ui.KVTreeModel {
+		tree := ui.NewKVTreeModel()
+		tree.SetWidth(40)
+		tree.SetHeight(10)
+		tree.SetEntries([]ui.KVEntry{
+			{"body", map[string]any{
+				"message": "order created",
+				"user":    map[string]any{"id": float64(42)},
+				"items":   []any{"a", "b"},
+			}},
+		})
+
+		return tree
+	}
+
+	key := func(r rune) tea.KeyMsg {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+	}
+
+	t.Run("expands the top level only", func(t *testing.T) {
+		view := newTree().View()
+
+		assert.Contains(t, view, "▾ body {3}")
+		assert.Contains(t, view, "message: order created")
+		assert.Contains(t, view, "▸ user {1}")
+		assert.Contains(t, view, "▸ items [2]")
+		assert.NotContains(t, view, "id: 42")
+	})
+
+	t.Run("expands and collapses the selected row", func(t *testing.T) {
+		tree := newTree()
+
+		// body, items, message, user
+		for range 3 {
+			tree, _ = tree.Update(key('j'))
+		}
+		tree, _ = tree.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Contains(t, tree.View(), "id: 42")
+
+		tree, _ = tree.Update(key('h'))
+		assert.NotContains(t, tree.View(), "id: 42")
+	})
+
+	t.Run("shows scalar entries", func(t *testing.T) {
+		tree := ui.NewKVTreeModel()
+		tree.SetWidth(40)
+		tree.SetHeight(10)
+		tree.SetEntries([]ui.KVEntry{{"body", "plain text"}, {"attributes", map[string]any{}}})
+
+		view := tree.View()
+
+		assert.Contains(t, view, "body: plain text")
+		assert.Contains(t, view, "attributes: {}")
+	})
+}
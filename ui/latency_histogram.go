@@ -0,0 +1,365 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"go.uber.org/zap"
+)
+
+const (
+	latencyHistogramBuckets = 20
+	// latencyBucketSpanLimit is the max number of spans listed for a bucket
+	latencyBucketSpanLimit = 100
+)
+
+// LatencyHistogramModel shows the distribution of durations for an
+// operation, and lists the traces which fell into the selected bucket so a
+// representative slow trace can be opened.
+type LatencyHistogramModel struct {
+	width  int
+	height int
+
+	serviceName string
+	// spanName is empty for all the operations of the service
+	spanName string
+	// stats are shown as markers on the buckets they fall into
+	stats db.OperationStats
+	// since is the start of the window the stats are for, which the
+	// histogram and its spans are limited to as well
+	since time.Time
+
+	buckets []db.LatencyBucket
+	// loaded is set once the histogram has been loaded for the first time
+	loaded   bool
+	selected int
+
+	// focusTraces is set when key presses go to the list of traces rather
+	// than the histogram.
+	focusTraces bool
+	tableModel  TableModel
+
+	db *db.Database
+}
+
+func NewLatencyHistogramModel(db *db.Database, stats db.OperationStats, since time.Time) LatencyHistogramModel {
+	tm := NewTableModel()
+	tm.SetColumnDefinitions([]ColumnDefinition{
+		{3, "Trace"},
+		{1, "Start time"},
+		{1, "Duration"},
+	})
+
+	return LatencyHistogramModel{
+		serviceName: stats.ServiceName,
+		spanName:    stats.Name.String,
+		stats:       stats,
+		since:       since,
+		tableModel:  tm,
+		db:          db,
+	}
+}
+
+func (m LatencyHistogramModel) Init() tea.Cmd {
+	return m.loadHistogram()
+}
+
+func (m LatencyHistogramModel) Update(msg tea.Msg) (LatencyHistogramModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case MsgNewSpans:
+		return m, m.loadHistogram()
+	case MsgLatencyHistogramUpdated:
+		if msg.serviceName != m.serviceName || msg.spanName != m.spanName {
+			return m, nil
+		}
+
+		m.buckets = msg.buckets
+		if !m.loaded {
+			// Start out on the slow end, which is usually why we're here
+			m.selected = m.bucketOf(m.stats.P99)
+			m.loaded = true
+		}
+		m.selected = max(0, helpers.Clamp(0, m.selected, len(m.buckets)-1))
+
+		return m, m.loadBucketSpans()
+	case MsgLatencyBucketSpansUpdated:
+		if msg.serviceName != m.serviceName || msg.spanName != m.spanName || msg.bucket != m.selectedBucket() {
+			return m, nil
+		}
+
+		items := make([]TableItemDelegate, len(msg.spans))
+		for i := range msg.spans {
+			items[i] = &latencySpanTableItemDelegate{span: msg.spans[i]}
+		}
+		m.tableModel.SetItems(items)
+		// Keep the cursor in range when a refresh returns fewer spans
+		m.tableModel.SetCursorRow(m.tableModel.cursorRow)
+		if len(items) == 0 {
+			m.focusTraces = false
+		}
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+	case tea.KeyMsg:
+		if m.focusTraces {
+			switch {
+			case key.Matches(msg, keys.Back):
+				m.focusTraces = false
+				return m, nil
+			case key.Matches(msg, keys.Select):
+				if item, ok := m.tableModel.SelectedItem().(*latencySpanTableItemDelegate); ok {
+					return m, helpers.Cmdize(MsgOpenTrace{traceID: item.span.TraceID})
+				}
+				return m, nil
+			}
+
+			m.tableModel, cmd = m.tableModel.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case key.Matches(msg, keys.Down):
+			m.selected += 1
+		case key.Matches(msg, keys.Up):
+			m.selected -= 1
+		case key.Matches(msg, keys.Top):
+			m.selected = 0
+		case key.Matches(msg, keys.Bottom):
+			m.selected = len(m.buckets) - 1
+		case key.Matches(msg, keys.Select):
+			if len(m.tableModel.items) > 0 {
+				m.focusTraces = true
+			}
+			return m, nil
+		default:
+			return m, nil
+		}
+
+		m.selected = max(0, helpers.Clamp(0, m.selected, len(m.buckets)-1))
+		m.tableModel.SetItems(nil)
+		m.tableModel.SetCursorRow(0)
+
+		return m, m.loadBucketSpans()
+	}
+
+	return m, nil
+}
+
+// updateMouse selects the clicked bucket, or a trace in the table.
+func (m LatencyHistogramModel) updateMouse(msg tea.MouseMsg) (LatencyHistogramModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	// Below the title and a spacer
+	bucket := translateMouse(msg, 0, 2).(tea.MouseMsg)
+	if bucket.Action == tea.MouseActionPress && bucket.Button == tea.MouseButtonLeft && mouseIn(bucket, m.width, len(m.buckets)) {
+		m.selected = bucket.Y
+		m.focusTraces = false
+		m.tableModel.SetItems(nil)
+		m.tableModel.SetCursorRow(0)
+
+		return m, m.loadBucketSpans()
+	}
+
+	table := translateMouse(msg, 0, 3+lipgloss.Height(m.histogramView())).(tea.MouseMsg)
+	if table.Action == tea.MouseActionPress && mouseIn(table, m.tableModel.width, m.tableModel.height) && len(m.tableModel.items) > 0 {
+		m.focusTraces = true
+	}
+	m.tableModel, cmd = m.tableModel.Update(table)
+
+	return m, cmd
+}
+
+// bucketOf returns the index of the bucket the duration falls into.
+func (m LatencyHistogramModel) bucketOf(d time.Duration) int {
+	for i, b := range m.buckets {
+		if d < b.Upper {
+			return i
+		}
+	}
+
+	return len(m.buckets) - 1
+}
+
+func (m LatencyHistogramModel) selectedBucket() db.LatencyBucket {
+	if m.selected < 0 || m.selected >= len(m.buckets) {
+		return db.LatencyBucket{}
+	}
+
+	return m.buckets[m.selected]
+}
+
+func (m LatencyHistogramModel) loadHistogram() tea.Cmd {
+	serviceName, spanName, since := m.serviceName, m.spanName, m.since
+
+	return func() tea.Msg {
+		buckets, err := m.db.GetLatencyHistogram(context.Background(), serviceName, spanName, since, latencyHistogramBuckets)
+		if err != nil {
+			zap.L().Warn("could not get latency histogram", zap.Error(err))
+			return nil
+		}
+
+		return MsgLatencyHistogramUpdated{
+			serviceName: serviceName,
+			spanName:    spanName,
+			buckets:     buckets,
+		}
+	}
+}
+
+func (m LatencyHistogramModel) loadBucketSpans() tea.Cmd {
+	if len(m.buckets) == 0 {
+		return nil
+	}
+
+	serviceName, spanName, since, bucket := m.serviceName, m.spanName, m.since, m.selectedBucket()
+
+	return func() tea.Msg {
+		spans, err := m.db.GetSpansInLatencyRange(context.Background(), serviceName, spanName, since, bucket.Lower, bucket.Upper, latencyBucketSpanLimit)
+		if err != nil {
+			zap.L().Warn("could not get spans in latency bucket", zap.Error(err))
+			return nil
+		}
+
+		return MsgLatencyBucketSpansUpdated{
+			serviceName: serviceName,
+			spanName:    spanName,
+			bucket:      bucket,
+			spans:       spans,
+		}
+	}
+}
+
+func (m LatencyHistogramModel) ShortHelp() []key.Binding {
+	if m.focusTraces {
+		return []key.Binding{
+			keys.Up,
+			keys.Down,
+			withHelp(keys.Select, "open in waterfall"),
+			withHelp(keys.Back, "back to histogram"),
+		}
+	}
+
+	return []key.Binding{
+		withHelp(keys.Up, "previous bucket"),
+		withHelp(keys.Down, "next bucket"),
+		withHelp(keys.Select, "list traces"),
+	}
+}
+
+func (m LatencyHistogramModel) FullHelp() [][]key.Binding {
+	navigation := []key.Binding{keys.Up, keys.Down, keys.Top, keys.Bottom}
+	if m.focusTraces {
+		navigation = navigationBindings()
+	}
+
+	return [][]key.Binding{navigation, m.ShortHelp()}
+}
+
+func (m LatencyHistogramModel) View() string {
+	title := m.serviceName
+	if m.stats.Name.Valid {
+		title = fmt.Sprintf("%s • %s", m.serviceName, m.spanName)
+	}
+
+	return helpers.VStack(
+		lipgloss.NewStyle().Bold(true).Render(title),
+		"", // spacer
+		m.histogramView(),
+		"", // spacer
+		m.tableModel.View(),
+	)
+}
+
+func (m LatencyHistogramModel) histogramView() string {
+	if len(m.buckets) == 0 {
+		return lipgloss.NewStyle().Faint(true).Render("No spans")
+	}
+
+	labels := make([]string, len(m.buckets))
+	counts := make([]string, len(m.buckets))
+	markers := make([]string, len(m.buckets))
+	maxCount := 0
+	for i, b := range m.buckets {
+		labels[i] = fmt.Sprintf("%s – %s", b.Lower.Round(time.Microsecond), b.Upper.Round(time.Microsecond))
+		counts[i] = fmt.Sprintf("%d", b.Count)
+		maxCount = max(maxCount, b.Count)
+	}
+
+	for _, p := range []struct {
+		label string
+		value time.Duration
+	}{
+		{"p50", m.stats.P50},
+		{"p90", m.stats.P90},
+		{"p99", m.stats.P99},
+	} {
+		i := m.bucketOf(p.value)
+		markers[i] = strings.TrimSpace(markers[i] + " " + p.label)
+	}
+
+	labelWidth := lipgloss.Width(helpers.VStack(labels...))
+	countWidth := lipgloss.Width(helpers.VStack(counts...))
+	markerWidth := lipgloss.Width(helpers.VStack(markers...))
+	barWidth := max(1, m.width-labelWidth-countWidth-markerWidth-4)
+
+	rows := make([]string, len(m.buckets))
+	for i, b := range m.buckets {
+		bar := ""
+		if maxCount > 0 {
+			bar = strings.Repeat("█", b.Count*barWidth/maxCount)
+			if b.Count > 0 && bar == "" {
+				bar = "▏"
+			}
+		}
+
+		labelStyle := lipgloss.NewStyle().Width(labelWidth).Align(lipgloss.Right)
+		barStyle := lipgloss.NewStyle().Foreground(helpers.ColorAccent)
+		if i == m.selected {
+			labelStyle = helpers.Highlight(labelStyle, helpers.ColorSecondary, helpers.ColorSecondaryForeground)
+			barStyle = barStyle.Foreground(helpers.ColorSecondary)
+		}
+
+		rows[i] = helpers.HStack(
+			labelStyle.Render(labels[i]),
+			" ",
+			lipgloss.NewStyle().Width(countWidth).Align(lipgloss.Right).Faint(true).Render(counts[i]),
+			" ",
+			barStyle.Render(bar),
+			" ",
+			lipgloss.NewStyle().Faint(true).Render(markers[i]),
+		)
+	}
+
+	return helpers.VStack(rows...)
+}
+
+func (m *LatencyHistogramModel) SetWidth(w int) {
+	m.width = w
+	m.tableModel.SetWidth(w)
+}
+
+func (m *LatencyHistogramModel) SetHeight(h int) {
+	m.height = h
+	// - title, spacers and the histogram
+	m.tableModel.SetHeight(max(3, h-3-latencyHistogramBuckets))
+}
+
+type latencySpanTableItemDelegate struct {
+	span db.Span
+}
+
+func (d latencySpanTableItemDelegate) Content() []string {
+	return []string{
+		d.span.TraceID,
+		d.span.StartTime.Format("15:04:05"),
+		d.span.Duration.Round(time.Microsecond).String(),
+	}
+}
@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+)
+
+// logHistogramLevels are the severities the bars are stacked by, from the
+// bottom up so errors are the easiest to spot.
+var logHistogramLevels = []int{severityError, severityWarn, severityInfo, 0}
+
+// logHistogram is the number of logs over time, stacked by severity.
+type logHistogram struct {
+	start      time.Time
+	bucketSize time.Duration
+	// counts has the number of logs per bucket and per index into
+	// logHistogramLevels
+	counts [][]int
+}
+
+func newLogHistogram(start time.Time, bucketSize time.Duration, buckets int, counts []db.LogCount) logHistogram {
+	h := logHistogram{
+		start:      start,
+		bucketSize: bucketSize,
+		counts:     make([][]int, buckets),
+	}
+	for i := range h.counts {
+		h.counts[i] = make([]int, len(logHistogramLevels))
+	}
+
+	for _, c := range counts {
+		if c.Bucket < 0 || c.Bucket >= buckets {
+			continue
+		}
+
+		for level, minSeverity := range logHistogramLevels {
+			if c.SeverityNumber >= minSeverity {
+				h.counts[c.Bucket][level] += c.Count
+				break
+			}
+		}
+	}
+
+	return h
+}
+
+// bucketAt returns the index of the bucket the time falls into, or -1.
+func (h logHistogram) bucketAt(t time.Time) int {
+	if t.IsZero() || h.bucketSize <= 0 || t.Before(h.start) {
+		return -1
+	}
+
+	i := int(t.Sub(h.start) / h.bucketSize)
+	if i >= len(h.counts) {
+		return -1
+	}
+
+	return i
+}
+
+// bucketRange returns the start and end of the bucket.
+func (h logHistogram) bucketRange(i int) (time.Time, time.Time) {
+	from := h.start.Add(time.Duration(i) * h.bucketSize)
+
+	return from, from.Add(h.bucketSize)
+}
+
+// View draws a bar per bucket, height rows tall, with the selected bucket
+// highlighted, followed by a line with the time range.
+func (h logHistogram) View(height int, selected int) string {
+	if len(h.counts) == 0 {
+		return lipgloss.NewStyle().Faint(true).Height(height + 1).Render("No logs")
+	}
+
+	maxTotal := 0
+	for _, levels := range h.counts {
+		total := 0
+		for _, c := range levels {
+			total += c
+		}
+		maxTotal = max(maxTotal, total)
+	}
+
+	// tops has the row each level reaches up to in each bucket, counting
+	// from the bottom
+	tops := make([][]int, len(h.counts))
+	for i, levels := range h.counts {
+		tops[i] = make([]int, len(levels))
+
+		cumulative, prevTop := 0, 0
+		for level, c := range levels {
+			cumulative += c
+			top := 0
+			if maxTotal > 0 {
+				top = int(float64(cumulative)/float64(maxTotal)*float64(height) + 0.5)
+			}
+			// Never hide a level entirely, as a single error matters
+			if c > 0 && top == prevTop && top < height {
+				top++
+			}
+			tops[i][level] = top
+			prevTop = top
+		}
+	}
+
+	rows := make([]string, height)
+	for row := range height {
+		fromBottom := height - row - 1
+
+		var line strings.Builder
+		for i := range h.counts {
+			cell := lipgloss.NewStyle()
+			if i == selected {
+				cell = cell.Background(helpers.ColorMuted)
+			}
+
+			char := " "
+			for level, top := range tops[i] {
+				if fromBottom < top {
+					char = "█"
+					cell = cell.Foreground(severityColor(logHistogramLevels[level]))
+					break
+				}
+			}
+
+			line.WriteString(cell.Render(char))
+		}
+		rows[row] = line.String()
+	}
+
+	end := h.start.Add(time.Duration(len(h.counts)) * h.bucketSize)
+	axis := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		h.start.Format("15:04:05"),
+		lipgloss.NewStyle().Width(max(0, len(h.counts)-16)).Render(""),
+		end.Format("15:04:05"),
+	)
+
+	return helpers.VStack(
+		helpers.VStack(rows...),
+		lipgloss.NewStyle().Faint(true).Render(axis),
+	)
+}
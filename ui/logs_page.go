@@ -0,0 +1,615 @@
+package ui
+
+import (
+	"context"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"go.uber.org/zap"
+)
+
+// logsLimit is the max number of logs listed, newest first.
+const logsLimit = 1000
+
+// logHistogramHeight is the number of rows of the bars in the histogram.
+const logHistogramHeight = 4
+
+// logSeverityFilters are the minimum severities cycled through.
+var logSeverityFilters = []int{0, severityDebug, severityInfo, severityWarn, severityError}
+
+// logsQuery is what the logs are filtered by.
+type logsQuery struct {
+	search      string
+	minSeverity int
+	service     string
+	// from and to is the time slice selected in the histogram
+	from time.Time
+	to   time.Time
+}
+
+func (q logsQuery) filter() db.LogFilter {
+	f := parseLogSearch(q.search)
+	f.MinSeverity = q.minSeverity
+	f.ServiceName = q.service
+	f.From = q.from
+	f.To = q.to
+
+	return f
+}
+
+// withoutTimeSlice is the query the histogram is showing, as it should
+// still show the time around the selected slice.
+func (q logsQuery) withoutTimeSlice() logsQuery {
+	q.from = time.Time{}
+	q.to = time.Time{}
+
+	return q
+}
+
+// LogsPageModel lists the logs, with a detail view showing structured
+// bodies and attributes as trees. Above the logs is a histogram of the
+// number of logs over time, where a bar can be selected to only list the
+// logs from that slice of time.
+type LogsPageModel struct {
+	width  int
+	height int
+
+	tableModel TableModel
+	treeModel  KVTreeModel
+
+	// focusDetail is set when key presses go to the tree of the selected
+	// log rather than the table.
+	focusDetail bool
+
+	// searching is set while typing in the search input
+	searching   bool
+	searchInput textinput.Model
+	// query is what the logs are currently filtered by
+	query logsQuery
+
+	histogram logHistogram
+	// services are the services which have sent logs, to filter by
+	services []string
+
+	// selected is the log the tree is showing
+	selected *db.Log
+
+	db *db.Database
+}
+
+func NewLogsPageModel(db *db.Database) LogsPageModel {
+	tm := NewTableModel()
+	tm.SetColumnDefinitions([]ColumnDefinition{
+		{3, "Time"},
+		{2, "Severity"},
+		{3, "Service"},
+		{12, "Body"},
+	})
+
+	input := textinput.New()
+	input.Prompt = "/"
+	input.Placeholder = "text or field=value, e.g. user.id=42"
+	// A blinking cursor would need its ticks routed to the input
+	input.Cursor.SetMode(cursor.CursorStatic)
+
+	return LogsPageModel{
+		tableModel:  tm,
+		treeModel:   NewKVTreeModel(),
+		searchInput: input,
+		db:          db,
+	}
+}
+
+func (m LogsPageModel) Init() tea.Cmd {
+	return tea.Batch(
+		m.loadLogs(),
+		m.loadHistogram(),
+		m.loadServices(),
+		m.tableModel.Init(),
+	)
+}
+
+// CapturesKeys is true while typing into the search, so global key
+// bindings shouldn't be acted on.
+func (m LogsPageModel) CapturesKeys() bool {
+	return m.searching
+}
+
+func (m LogsPageModel) Update(msg tea.Msg) (LogsPageModel, tea.Cmd) {
+	var cmd tea.Cmd
+	cmds := make([]tea.Cmd, 0)
+
+	switch msg := msg.(type) {
+	case MsgNewLogs:
+		cmds = append(cmds, m.loadLogs(), m.loadHistogram(), m.loadServices())
+	case tea.WindowSizeMsg:
+		// There's a bar per column, so the buckets depend on the width
+		cmds = append(cmds, m.loadHistogram())
+	case MsgLogHistogramUpdated:
+		if msg.query == m.query.withoutTimeSlice() {
+			m.histogram = msg.histogram
+		}
+	case MsgLogServicesUpdated:
+		m.services = msg.services
+	case MsgSearchLogs:
+		m.focusDetail = false
+		m.searching = true
+		return m, m.searchInput.Focus()
+	case MsgShowServiceLogs:
+		m.focusDetail = false
+		m.searchInput.SetValue("")
+		return m, m.setQuery(logsQuery{service: msg.service})
+	case MsgLogsFiltered:
+		if msg.query != m.query {
+			break
+		}
+
+		items := make([]TableItemDelegate, len(msg.logs))
+		for i := range msg.logs {
+			items[i] = &logTableItemDelegate{log: &msg.logs[i]}
+		}
+		m.tableModel.SetItems(items)
+		m.tableModel.SetCursorRow(m.tableModel.cursorRow)
+	case tea.KeyMsg:
+		if m.searching {
+			switch {
+			case key.Matches(msg, keys.Select):
+				m.searching = false
+				m.searchInput.Blur()
+				m.query.search = strings.TrimSpace(m.searchInput.Value())
+				return m, m.setQuery(m.query)
+			case key.Matches(msg, keys.Back):
+				m.searching = false
+				m.searchInput.Blur()
+				m.searchInput.SetValue(m.query.search)
+				return m, nil
+			}
+
+			m.searchInput, cmd = m.searchInput.Update(msg)
+			return m, cmd
+		}
+
+		if key.Matches(msg, keys.OpenInEditor) {
+			if m.selected != nil {
+				if loc, ok := CodeLocationOf(m.selected.Attributes); ok {
+					return m, openInEditor(loc)
+				}
+			}
+			return m, nil
+		}
+
+		if m.focusDetail {
+			switch {
+			case key.Matches(msg, keys.Back):
+				m.focusDetail = false
+				return m, nil
+			case key.Matches(msg, keys.Copy):
+				if row, ok := m.treeModel.selectedRow(); ok {
+					return m, copyText(row.key, clipboardText(row.value))
+				}
+				return m, nil
+			}
+
+			m.treeModel, cmd = m.treeModel.Update(msg)
+			return m, cmd
+		}
+
+		query := m.query
+		switch {
+		case key.Matches(msg, keys.Search):
+			m.searching = true
+			return m, m.searchInput.Focus()
+		case key.Matches(msg, keys.Severity):
+			i := slices.Index(logSeverityFilters, query.minSeverity)
+			query.minSeverity = logSeverityFilters[(i+1)%len(logSeverityFilters)]
+			return m, m.setQuery(query)
+		case key.Matches(msg, keys.Service):
+			// Cycles through all services and then back to none
+			services := append([]string{""}, m.services...)
+			i := slices.Index(services, query.service)
+			query.service = services[(i+1)%len(services)]
+			return m, m.setQuery(query)
+		case key.Matches(msg, keys.PreviousSlice, keys.NextSlice):
+			if len(m.histogram.counts) == 0 {
+				return m, nil
+			}
+
+			i := m.histogram.bucketAt(query.from)
+			switch {
+			case i < 0:
+				// Start from the newest logs
+				i = len(m.histogram.counts) - 1
+			case key.Matches(msg, keys.PreviousSlice):
+				i = max(0, i-1)
+			default:
+				i = min(len(m.histogram.counts)-1, i+1)
+			}
+
+			query.from, query.to = m.histogram.bucketRange(i)
+			return m, m.setQuery(query)
+		case key.Matches(msg, keys.ClearSlice):
+			return m, m.setQuery(query.withoutTimeSlice())
+		case key.Matches(msg, keys.Select):
+			if m.selected != nil {
+				m.focusDetail = true
+			}
+			return m, nil
+		case key.Matches(msg, keys.Copy):
+			if m.selected != nil && m.selected.TraceID.Valid {
+				return m, copyText("trace ID", m.selected.TraceID.String)
+			}
+			return m, nil
+		}
+	}
+
+	// Below the border, histogram with its axis, filters and search
+	m.tableModel, cmd = m.tableModel.Update(translateMouse(msg, 1, logHistogramHeight+4))
+	cmds = append(cmds, cmd)
+
+	m.updateSelected()
+
+	return m, tea.Batch(cmds...)
+}
+
+// Commands search the logs and show the logs of each service.
+func (m LogsPageModel) Commands() []Command {
+	commands := []Command{
+		{"Logs", "Search logs", helpers.Cmdize(MsgSearchLogs{})},
+		{"Logs", "Show all logs", helpers.Cmdize(MsgShowServiceLogs{})},
+	}
+	for _, service := range m.services {
+		commands = append(commands, Command{"Logs", "Show logs from " + service, helpers.Cmdize(MsgShowServiceLogs{service})})
+	}
+
+	if log := m.selected; log != nil {
+		if loc, ok := CodeLocationOf(log.Attributes); ok {
+			commands = append(commands, Command{"Action", "Open " + loc.String() + " in editor", openInEditor(loc)})
+		}
+		commands = append(commands, Command{"Copy", "Copy log body", copyText("log body", log.Body)})
+		if log.TraceID.Valid {
+			commands = append(commands, Command{"Copy", "Copy trace ID of log", copyText("trace ID", log.TraceID.String)})
+		}
+		if log.SpanID.Valid {
+			commands = append(commands, Command{"Copy", "Copy span ID of log", copyText("span ID", log.SpanID.String)})
+		}
+		for _, k := range slices.Sorted(maps.Keys(log.Attributes)) {
+			commands = append(commands, Command{"Copy", "Copy attribute " + k, copyText(k, clipboardText(log.Attributes[k]))})
+		}
+	}
+
+	return commands
+}
+
+func (m LogsPageModel) ShortHelp() []key.Binding {
+	switch {
+	case m.searching:
+		return []key.Binding{withHelp(keys.Select, "search"), withHelp(keys.Back, "cancel")}
+	case m.focusDetail:
+		return []key.Binding{keys.Up, keys.Down, keys.Toggle, withHelp(keys.Copy, "copy value"), keys.Back}
+	}
+
+	return []key.Binding{
+		keys.Search,
+		keys.Severity,
+		keys.Service,
+		keys.PreviousSlice,
+		keys.NextSlice,
+		withHelp(keys.Select, "browse body"),
+	}
+}
+
+func (m LogsPageModel) FullHelp() [][]key.Binding {
+	switch {
+	case m.searching:
+		return [][]key.Binding{m.ShortHelp()}
+	case m.focusDetail:
+		return [][]key.Binding{
+			navigationBindings(),
+			{
+				keys.Toggle,
+				withHelp(keys.Right, "expand"),
+				withHelp(keys.Left, "collapse/parent"),
+				withHelp(keys.Copy, "copy value"),
+				keys.Back,
+			},
+		}
+	}
+
+	return [][]key.Binding{
+		navigationBindings(),
+		{keys.Search, keys.Severity, keys.Service, withHelp(keys.Select, "browse body"), withHelp(keys.Copy, "copy trace ID"), keys.OpenInEditor},
+		{keys.PreviousSlice, keys.NextSlice, keys.ClearSlice},
+	}
+}
+
+// updateSelected shows the log under the cursor in the tree.
+func (m *LogsPageModel) updateSelected() {
+	item, ok := m.tableModel.SelectedItem().(*logTableItemDelegate)
+	if !ok {
+		m.selected = nil
+		m.treeModel.SetEntries(nil)
+		return
+	}
+
+	if m.selected == item.log {
+		return
+	}
+
+	m.selected = item.log
+
+	body := item.log.BodyValue
+	if body == nil {
+		// Stored before bodies were kept as JSON
+		body = item.log.Body
+	}
+
+	m.treeModel.SetEntries([]KVEntry{
+		{"body", body},
+		{"attributes", item.log.Attributes},
+	})
+}
+
+// setQuery filters the logs by the query, and updates the histogram unless
+// only the time slice changed.
+func (m *LogsPageModel) setQuery(query logsQuery) tea.Cmd {
+	changedSlice := query.withoutTimeSlice() == m.query.withoutTimeSlice()
+	m.query = query
+	m.tableModel.SetCursorRow(0)
+
+	if changedSlice {
+		return m.loadLogs()
+	}
+
+	return tea.Batch(m.loadLogs(), m.loadHistogram())
+}
+
+func (m LogsPageModel) loadLogs() tea.Cmd {
+	query := m.query
+
+	return func() tea.Msg {
+		logs, err := m.db.FilterLogs(context.Background(), query.filter(), logsLimit)
+		if err != nil {
+			zap.L().Warn("could not get logs", zap.String("search", query.search), zap.Error(err))
+			return nil
+		}
+
+		return MsgLogsFiltered{query: query, logs: logs}
+	}
+}
+
+func (m LogsPageModel) loadHistogram() tea.Cmd {
+	query := m.query.withoutTimeSlice()
+	buckets := m.histogramWidth()
+
+	return func() tea.Msg {
+		ctx := context.Background()
+		filter := query.filter()
+
+		first, last, err := m.db.GetLogTimeRange(ctx, filter)
+		if err != nil {
+			zap.L().Warn("could not get log time range", zap.Error(err))
+			return nil
+		}
+		if first.IsZero() || buckets <= 0 {
+			return MsgLogHistogramUpdated{query: query}
+		}
+
+		// Timestamps are stored with microsecond precision, and the extra
+		// microsecond makes the newest log fall into the last bucket.
+		bucketSize := (last.Sub(first) / time.Duration(buckets)).Truncate(time.Microsecond) + time.Microsecond
+
+		counts, err := m.db.GetLogCounts(ctx, filter, first, bucketSize)
+		if err != nil {
+			zap.L().Warn("could not get log counts", zap.Error(err))
+			return nil
+		}
+
+		return MsgLogHistogramUpdated{
+			query:     query,
+			histogram: newLogHistogram(first, bucketSize, buckets, counts),
+		}
+	}
+}
+
+func (m LogsPageModel) loadServices() tea.Cmd {
+	return func() tea.Msg {
+		services, err := m.db.GetLogServiceNames(context.Background())
+		if err != nil {
+			zap.L().Warn("could not get log services", zap.Error(err))
+			return nil
+		}
+
+		return MsgLogServicesUpdated{services: services}
+	}
+}
+
+// parseLogSearch turns a search into a filter. Words of the form
+// field=value match fields in structured bodies or attributes, the rest
+// is matched against the whole body.
+func parseLogSearch(query string) db.LogFilter {
+	filter := db.LogFilter{}
+
+	words := make([]string, 0)
+	for _, word := range strings.Fields(query) {
+		path, value, ok := strings.Cut(word, "=")
+		if ok && path != "" {
+			filter.Fields = append(filter.Fields, db.LogFieldFilter{Path: path, Value: value})
+			continue
+		}
+		words = append(words, word)
+	}
+	filter.Text = strings.Join(words, " ")
+
+	return filter
+}
+
+func (m LogsPageModel) View() string {
+	return helpers.HStack(m.tableView(), m.detailView())
+}
+
+func (m LogsPageModel) tableView() string {
+	container := lipgloss.
+		NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(helpers.ColorBorder).
+		BorderBackground(helpers.ColorBackground).
+		Background(helpers.ColorBackground)
+
+	search := lipgloss.NewStyle().Faint(true).Render(keyName(keys.Search) + " search")
+	if m.searching {
+		search = m.searchInput.View()
+	} else if m.query.search != "" {
+		search = helpers.HStack("/", m.query.search, lipgloss.NewStyle().Faint(true).Render(" ("+keyName(keys.Search)+" to change)"))
+	}
+
+	return container.Render(
+		helpers.VStack(
+			m.histogram.View(logHistogramHeight, m.histogram.bucketAt(m.query.from)),
+			lipgloss.NewStyle().MaxWidth(m.tableModel.width).Render(m.filtersView()),
+			lipgloss.NewStyle().MaxWidth(m.tableModel.width).Render(search),
+			m.tableModel.View(),
+		),
+	)
+}
+
+// filtersView shows the severity, service and time slice filters along
+// with the keys changing them.
+func (m LogsPageModel) filtersView() string {
+	muted := lipgloss.NewStyle().Faint(true)
+
+	severity := "all"
+	if m.query.minSeverity > 0 {
+		severity = severityName(m.query.minSeverity) + "+"
+	}
+
+	service := "all"
+	if m.query.service != "" {
+		service = m.query.service
+	}
+
+	slice := muted.Render(keyName(keys.PreviousSlice) + " " + keyName(keys.NextSlice) + " select time")
+	if !m.query.from.IsZero() {
+		slice = helpers.HStack(
+			m.query.from.Format("15:04:05.000"),
+			"–",
+			m.query.to.Format("15:04:05.000"),
+			muted.Render(" ("+keyName(keys.ClearSlice)+" to clear)"),
+		)
+	}
+
+	return helpers.HStack(
+		muted.Render("severity "),
+		lipgloss.NewStyle().Foreground(severityColor(m.query.minSeverity)).Render(severity),
+		muted.Render(" ("+keyName(keys.Severity)+") • service "),
+		service,
+		muted.Render(" ("+keyName(keys.Service)+") • "),
+		slice,
+	)
+}
+
+// histogramWidth is the number of bars in the histogram, one per column.
+func (m LogsPageModel) histogramWidth() int {
+	return m.tableModel.width
+}
+
+func (m LogsPageModel) detailView() string {
+	width := m.detailWidth()
+
+	container := lipgloss.
+		NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(helpers.ColorBorder).
+		Width(width).
+		Height(m.height - 2)
+	if m.focusDetail {
+		container = container.BorderForeground(helpers.ColorPrimary)
+	}
+
+	if m.selected == nil {
+		return container.Align(lipgloss.Center, lipgloss.Center).Render("No log selected")
+	}
+
+	return container.Render(
+		helpers.VStack(
+			m.metadataView(),
+			"", // spacer
+			m.treeModel.View(),
+		),
+	)
+}
+
+// metadataLines is the height of metadataView
+const metadataLines = 3
+
+func (m LogsPageModel) metadataView() string {
+	l := m.selected
+	muted := lipgloss.NewStyle().Faint(true)
+
+	event := ""
+	if l.EventName != "" {
+		event = muted.Render(" • ") + l.EventName
+	}
+
+	source := ""
+	if loc, ok := CodeLocationOf(l.Attributes); ok {
+		source = muted.Render(" • at ") + loc.String()
+	}
+
+	trace := muted.Render("no trace context")
+	if l.TraceID.Valid {
+		trace = helpers.HStack(muted.Render("trace "), l.TraceID.String, muted.Render(" span "), l.SpanID.String)
+	}
+
+	return lipgloss.NewStyle().MaxWidth(m.detailWidth()).Render(
+		helpers.VStack(
+			helpers.HStack(
+				lipgloss.NewStyle().Foreground(severityColor(l.SeverityNumber)).Render(severityLabel(*l)),
+				" ",
+				l.Timestamp.Format(time.DateTime+".000"),
+				event,
+			),
+			helpers.HStack(l.ServiceName, muted.Render(" • "+l.BodyType+" body"), source),
+			trace,
+		),
+	)
+}
+
+func (m LogsPageModel) detailWidth() int {
+	return int(math.Ceil(float64(m.width)*(1.0/3.0))) - 2
+}
+
+func (m *LogsPageModel) SetWidth(w int) {
+	m.width = w
+	m.tableModel.SetWidth(int(math.Floor(float64(w)*2.0/3.0)) - 2)
+	m.treeModel.SetWidth(m.detailWidth())
+	m.searchInput.Width = m.tableModel.width - 2
+}
+
+func (m *LogsPageModel) SetHeight(h int) {
+	m.height = h
+	m.tableModel.SetHeight(h - 2 - logHistogramHeight - 1 - 2) // - border, histogram with axis, filters and search
+	m.treeModel.SetHeight(h - 2 - metadataLines - 1)
+}
+
+type logTableItemDelegate struct {
+	log *db.Log
+}
+
+func (d logTableItemDelegate) Content() []string {
+	return []string{
+		d.log.Timestamp.Format("15:04:05.000"),
+		severityLabel(*d.log),
+		d.log.ServiceName,
+		strings.ReplaceAll(d.log.Body, "\n", " "),
+	}
+}
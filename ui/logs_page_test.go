@@ -0,0 +1,128 @@
+package ui_test
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func TestLogsPage(t *testing.T) {
+	database, err := db.NewDB(":memory:")
+	assert.Nil(t, err)
+	defer database.Close()
+	assert.Nil(t, database.Migrate(t.Context()))
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	rl := plog.NewResourceLogs()
+	rl.Resource().Attributes().PutStr("service.name", "worker")
+	records := rl.ScopeLogs().AppendEmpty().LogRecords()
+	for i, id := range []int64{42, 43} {
+		record := records.AppendEmpty()
+		record.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Duration(i) * time.Second)))
+		body := record.Body().SetEmptyMap()
+		body.PutStr("message", "order created")
+		body.PutEmptyMap("user").PutInt("id", id)
+	}
+	plain := records.AppendEmpty()
+	plain.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(2 * time.Second)))
+	plain.Body().SetStr("plain text")
+	assert.Nil(t, database.InsertResourceLogs(t.Context(), rl))
+
+	rl = plog.NewResourceLogs()
+	rl.Resource().Attributes().PutStr("service.name", "api")
+	failed := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	failed.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(10 * time.Second)))
+	failed.SetSeverityNumber(plog.SeverityNumberError)
+	failed.Body().SetStr("request failed")
+	assert.Nil(t, database.InsertResourceLogs(t.Context(), rl))
+
+	newPage := func() ui.LogsPageModel {
+		m := ui.NewLogsPageModel(database)
+		m.SetWidth(150)
+		m.SetHeight(30)
+
+		return runCmds(m, ui.LogsPageModel.Update, m.Init())
+	}
+
+	search := func(m ui.LogsPageModel, query string) ui.LogsPageModel {
+		m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+		m = runCmds(m, ui.LogsPageModel.Update, cmd)
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(query)})
+		m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		return runCmds(m, ui.LogsPageModel.Update, cmd)
+	}
+
+	press := func(m ui.LogsPageModel, keys string) ui.LogsPageModel {
+		for _, r := range keys {
+			var cmd tea.Cmd
+			m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			m = runCmds(m, ui.LogsPageModel.Update, cmd)
+		}
+
+		return m
+	}
+
+	t.Run("lists logs with structured bodies", func(t *testing.T) {
+		view := press(newPage(), "jj").View()
+
+		assert.Contains(t, view, "plain text")
+		assert.Contains(t, view, `{"message":"order created"`)
+		assert.Contains(t, view, "Map body")
+	})
+
+	t.Run("searches by field", func(t *testing.T) {
+		m := search(newPage(), "user.id=43")
+
+		view := m.View()
+		assert.NotContains(t, view, "plain text")
+		assert.Contains(t, view, `"id":43`)
+		assert.NotContains(t, view, `"id":42`)
+	})
+
+	t.Run("filters by minimum severity", func(t *testing.T) {
+		m := press(newPage(), "ssss")
+
+		view := m.View()
+		assert.Contains(t, view, "ERROR+")
+		assert.Contains(t, view, "request failed")
+		assert.NotContains(t, view, "plain text")
+	})
+
+	t.Run("cycles through services", func(t *testing.T) {
+		m := press(newPage(), "vv")
+
+		view := m.View()
+		assert.Contains(t, view, "service worker")
+		assert.Contains(t, view, "plain text")
+		assert.NotContains(t, view, "request failed")
+
+		m = press(m, "v")
+		assert.Contains(t, m.View(), "request failed")
+	})
+
+	t.Run("narrows logs to the selected bar", func(t *testing.T) {
+		m := press(newPage(), "]")
+
+		view := m.View()
+		assert.Contains(t, view, "request failed")
+		assert.NotContains(t, view, "plain text")
+		assert.Contains(t, view, "c to clear")
+
+		m = press(m, "c")
+		assert.Contains(t, m.View(), "plain text")
+	})
+
+	t.Run("captures keys while searching", func(t *testing.T) {
+		m, _ := newPage().Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+
+		assert.True(t, m.CapturesKeys())
+	})
+}
@@ -1,15 +1,117 @@
 package ui
 
 import (
+	"time"
+
 	"github.com/fredrikaugust/otelly/db"
 	"github.com/fredrikaugust/otelly/ui/flamegraph"
+	"github.com/fredrikaugust/otelly/ui/helpers"
 )
 
 type (
 	MsgSpanPageUpdateTable struct{}
 	MsgNewSpans            struct{ spans []db.Span }
 	MsgNewLogs             struct{ logs []db.Log }
+	MsgNewStatus           struct{ text string }
 
 	MsgLoadTrace   struct{ traceID string }
-	MsgTreeUpdated struct{ tree flamegraph.Node }
+	MsgTreeUpdated struct {
+		traceID string
+		spans   []db.Span
+		roots   flamegraph.Forest
+		skews   []flamegraph.ClockSkew
+	}
+
+	MsgLogsFiltered struct {
+		query logsQuery
+		logs  []db.Log
+	}
+	MsgLogHistogramUpdated struct {
+		query     logsQuery
+		histogram logHistogram
+	}
+	MsgLogServicesUpdated struct {
+		services []string
+	}
+	MsgTraceLogsUpdated struct {
+		traceID   string
+		traceLogs []db.Log
+		spanLogs  []db.Log
+	}
+
+	MsgServiceMapExported struct{ err error }
+
+	MsgOperationStatsUpdated struct {
+		// window is the index into statsWindows the stats were loaded for
+		window int
+		// since is the start of the window
+		since    time.Time
+		duration time.Duration
+		stats    []db.OperationStats
+		rates    []db.OperationRate
+	}
+
+	MsgRootSpanNamesUpdated struct{ names []db.SpanNameCount }
+	MsgAggregateUpdated     struct {
+		rootSpanName string
+		root         flamegraph.Frame
+	}
+
+	MsgLatencyHistogramUpdated struct {
+		serviceName string
+		spanName    string
+		buckets     []db.LatencyBucket
+	}
+	MsgLatencyBucketSpansUpdated struct {
+		serviceName string
+		spanName    string
+		bucket      db.LatencyBucket
+		spans       []db.Span
+	}
+
+	MsgTraceDiffUpdated struct {
+		baseTraceID   string
+		targetTraceID string
+		diff          flamegraph.Diff
+	}
+
+	// MsgOpenTrace switches to the spans page with the root span of the
+	// trace selected. The spans are those of the trace which have arrived,
+	// if they've been looked up.
+	MsgOpenTrace struct {
+		traceID string
+		spans   []db.Span
+	}
+	// MsgOpenService switches to the services page with the service
+	// selected.
+	MsgOpenService struct{ service string }
+	// MsgOpenRootSpan switches to the flamegraph of the traces with the
+	// root span name.
+	MsgOpenRootSpan struct{ name string }
+	// MsgShowServiceLogs switches to the logs page, showing the logs of
+	// the service without any other filters, or all logs if it's empty.
+	MsgShowServiceLogs struct{ service string }
+	// MsgSearchLogs switches to the logs page and starts a search
+	MsgSearchLogs struct{}
+	MsgSwitchPage struct{ page Page }
+
+	// MsgPromptTrace asks for the ID of a trace to open
+	MsgPromptTrace struct{}
+	// MsgTraceNotFound is sent when asking for a trace which hasn't arrived
+	// yet, to open it once it does.
+	MsgTraceNotFound       struct{ traceID string }
+	MsgStopWaitingForTrace struct{}
+	MsgClipboardRead       struct{ text string }
+
+	// MsgClearData deletes all spans, logs and metrics, and MsgDataCleared
+	// is sent once they're gone.
+	MsgClearData   struct{}
+	MsgDataCleared struct{}
+
+	MsgToggleFollow struct{}
+
+	MsgSetTheme struct{ theme helpers.Theme }
+
+	// MsgStatus is shown in the header until the next key press.
+	MsgStatus struct{ text string }
 )
@@ -0,0 +1,24 @@
+package ui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// translateMouse makes mouse events relative to a model drawn at x, y, so
+// it can tell where within itself the mouse is. Other messages are left as
+// they are.
+func translateMouse(msg tea.Msg, x, y int) tea.Msg {
+	mouse, ok := msg.(tea.MouseMsg)
+	if !ok {
+		return msg
+	}
+
+	mouse.X -= x
+	mouse.Y -= y
+
+	return mouse
+}
+
+// mouseIn is true when the mouse event, relative to a model, is within its
+// width and height.
+func mouseIn(msg tea.MouseMsg, width, height int) bool {
+	return msg.X >= 0 && msg.Y >= 0 && msg.X < width && msg.Y < height
+}
@@ -0,0 +1,26 @@
+package ui_test
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// runCmds runs the command and any commands resulting from updating the
+// model with its messages, until there are none left.
+func runCmds[M any](m M, update func(M, tea.Msg) (M, tea.Cmd), cmd tea.Cmd) M {
+	cmds := []tea.Cmd{cmd}
+	for len(cmds) > 0 {
+		cmd, cmds = cmds[0], cmds[1:]
+		if cmd == nil {
+			continue
+		}
+
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			cmds = append(cmds, batch...)
+			continue
+		}
+
+		m, cmd = update(m, msg)
+		cmds = append(cmds, cmd)
+	}
+
+	return m
+}
@@ -0,0 +1,278 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"github.com/fredrikaugust/otelly/ui/servicemap"
+	"go.uber.org/zap"
+)
+
+const (
+	serviceMapDOTPath     = "service-map.dot"
+	serviceMapMermaidPath = "service-map.mmd"
+)
+
+// ServiceMapPageModel shows how services call each other, derived from
+// spans crossing service boundaries.
+type ServiceMapPageModel struct {
+	width  int
+	height int
+
+	edges  []servicemap.Edge
+	layers [][]string
+
+	// selectedLayer and selectedRow point at the selected service in layers
+	selectedLayer int
+	selectedRow   int
+
+	status string
+}
+
+func NewServiceMapPageModel(spans []db.Span) ServiceMapPageModel {
+	m := ServiceMapPageModel{}
+	m.setSpans(spans)
+
+	return m
+}
+
+func (m ServiceMapPageModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m ServiceMapPageModel) Update(msg tea.Msg) (ServiceMapPageModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case MsgNewSpans:
+		m.setSpans(msg.spans)
+	case MsgServiceMapExported:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Export failed: %v", msg.err)
+		} else {
+			m.status = fmt.Sprintf("Exported to %s and %s", serviceMapDOTPath, serviceMapMermaidPath)
+		}
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Down):
+			m.selectedRow += 1
+		case key.Matches(msg, keys.Up):
+			m.selectedRow -= 1
+		case key.Matches(msg, keys.Right):
+			m.selectedLayer += 1
+		case key.Matches(msg, keys.Left):
+			m.selectedLayer -= 1
+		case key.Matches(msg, keys.Export):
+			return m, m.export()
+		}
+
+		m.clampSelection()
+	}
+
+	return m, nil
+}
+
+func (m *ServiceMapPageModel) setSpans(spans []db.Span) {
+	selected := m.selectedService()
+
+	m.edges = servicemap.Derive(spans)
+	m.layers = servicemap.Layers(m.edges)
+
+	// Keep the same service selected if it's still around
+	for i, layer := range m.layers {
+		for j, service := range layer {
+			if service == selected {
+				m.selectedLayer, m.selectedRow = i, j
+			}
+		}
+	}
+
+	m.clampSelection()
+}
+
+func (m *ServiceMapPageModel) clampSelection() {
+	if len(m.layers) == 0 {
+		m.selectedLayer, m.selectedRow = 0, 0
+		return
+	}
+
+	m.selectedLayer = helpers.Clamp(0, m.selectedLayer, len(m.layers)-1)
+	m.selectedRow = helpers.Clamp(0, m.selectedRow, len(m.layers[m.selectedLayer])-1)
+}
+
+func (m ServiceMapPageModel) selectedService() string {
+	if m.selectedLayer >= len(m.layers) || m.selectedRow >= len(m.layers[m.selectedLayer]) {
+		return ""
+	}
+
+	return m.layers[m.selectedLayer][m.selectedRow]
+}
+
+func (m ServiceMapPageModel) export() tea.Cmd {
+	edges := m.edges
+
+	return func() tea.Msg {
+		if err := os.WriteFile(serviceMapDOTPath, []byte(servicemap.DOT(edges)), 0o644); err != nil {
+			zap.L().Warn("could not export service map", zap.Error(err))
+			return MsgServiceMapExported{err: err}
+		}
+		if err := os.WriteFile(serviceMapMermaidPath, []byte(servicemap.Mermaid(edges)), 0o644); err != nil {
+			zap.L().Warn("could not export service map", zap.Error(err))
+			return MsgServiceMapExported{err: err}
+		}
+
+		return MsgServiceMapExported{}
+	}
+}
+
+// Commands export the service map.
+func (m ServiceMapPageModel) Commands() []Command {
+	return []Command{{"Action", "Export service map to Graphviz and Mermaid", m.export()}}
+}
+
+func (m ServiceMapPageModel) ShortHelp() []key.Binding {
+	return []key.Binding{
+		withHelp(keys.Left, "callers"),
+		withHelp(keys.Right, "callees"),
+		withHelp(keys.Export, "export to Graphviz and Mermaid"),
+	}
+}
+
+func (m ServiceMapPageModel) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{
+			withHelp(keys.Up, "previous service"),
+			withHelp(keys.Down, "next service"),
+			withHelp(keys.Left, "callers"),
+			withHelp(keys.Right, "callees"),
+		},
+		{withHelp(keys.Export, "export to Graphviz and Mermaid")},
+	}
+}
+
+func (m ServiceMapPageModel) View() string {
+	container := lipgloss.
+		NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(helpers.ColorBorder).
+		Width(m.width - 2).
+		MaxWidth(m.width).
+		Height(m.height - 2).
+		MaxHeight(m.height)
+
+	if len(m.edges) == 0 {
+		return container.Align(lipgloss.Center, lipgloss.Center).Render("No calls between services yet")
+	}
+
+	return container.Render(
+		helpers.VStack(
+			lipgloss.NewStyle().Faint(true).Render(m.status),
+			"", // spacer
+			m.layersView(),
+			"", // spacer
+			m.neighbourhoodView(),
+		),
+	)
+}
+
+// layersView shows every service, with callers to the left of the
+// services they call.
+func (m ServiceMapPageModel) layersView() string {
+	columns := make([]string, 0, len(m.layers)*2)
+
+	for i, layer := range m.layers {
+		if i > 0 {
+			columns = append(columns, lipgloss.NewStyle().Padding(0, 1).Render("──▶"))
+		}
+
+		names := make([]string, len(layer))
+		for j, service := range layer {
+			style := lipgloss.NewStyle().Padding(0, 1)
+			if i == m.selectedLayer && j == m.selectedRow {
+				style = helpers.Highlight(style, helpers.ColorSecondary, helpers.ColorSecondaryForeground)
+			}
+			names[j] = style.Render(service)
+		}
+
+		columns = append(
+			columns,
+			lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(helpers.ColorBorder).Render(helpers.VStack(names...)),
+		)
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Center, columns...)
+}
+
+// neighbourhoodView shows the callers and callees of the selected service
+// along with the stats of each edge.
+func (m ServiceMapPageModel) neighbourhoodView() string {
+	selected := m.selectedService()
+
+	callers := make([]string, 0)
+	callees := make([]string, 0)
+	for _, e := range m.edges {
+		if e.To == selected {
+			callers = append(callers, fmt.Sprintf("%s %s %s", e.From, edgeStatsView(e), edgeArrow(e)))
+		}
+		if e.From == selected {
+			callees = append(callees, fmt.Sprintf("%s %s %s", edgeArrow(e), e.To, edgeStatsView(e)))
+		}
+	}
+
+	if len(callers) == 0 {
+		callers = append(callers, lipgloss.NewStyle().Faint(true).Render("no callers"))
+	}
+	if len(callees) == 0 {
+		callees = append(callees, lipgloss.NewStyle().Faint(true).Render("no callees"))
+	}
+
+	left := lipgloss.NewStyle().Align(lipgloss.Right).Render(helpers.VStack(callers...))
+	middle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(helpers.ColorSecondary).Padding(0, 1).Margin(0, 1).Render(selected)
+
+	// Truncate rather than wrap the callees so the lines stay lined up
+	rightWidth := max(1, m.width-2-lipgloss.Width(left)-lipgloss.Width(middle))
+	for i, callee := range callees {
+		callees[i] = lipgloss.NewStyle().MaxWidth(rightWidth).Render(callee)
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Center, left, middle, helpers.VStack(callees...))
+}
+
+func edgeArrow(e servicemap.Edge) string {
+	if e.Async {
+		return "╌╌▶"
+	}
+
+	return "──▶"
+}
+
+func edgeStatsView(e servicemap.Edge) string {
+	stats := lipgloss.NewStyle().Faint(true).Render(
+		fmt.Sprintf(
+			"(%d× • p50 %s • p90 %s • p99 %s •",
+			e.Calls,
+			e.P50.Round(time.Microsecond),
+			e.P90.Round(time.Microsecond),
+			e.P99.Round(time.Microsecond),
+		),
+	)
+
+	errorRate := fmt.Sprintf("%.1f%% err)", e.ErrorRate()*100)
+	if e.Errors > 0 {
+		return stats + " " + lipgloss.NewStyle().Foreground(helpers.ColorDestructive).Render(errorRate)
+	}
+
+	return stats + " " + lipgloss.NewStyle().Faint(true).Render(errorRate)
+}
+
+func (m *ServiceMapPageModel) SetWidth(w int) {
+	m.width = w
+}
+
+func (m *ServiceMapPageModel) SetHeight(h int) {
+	m.height = h
+}
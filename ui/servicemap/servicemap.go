@@ -0,0 +1,281 @@
+// Package servicemap derives which services call each other from the
+// parent/child relationships of spans.
+package servicemap
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fredrikaugust/otelly/db"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+type Edge struct {
+	From string
+	To   string
+	// Async is set for PRODUCER/CONSUMER edges, i.e. via a message queue
+	Async  bool
+	Calls  int
+	Errors int
+	// Latency percentiles as seen by the caller when the calling span is
+	// a CLIENT or PRODUCER span, otherwise as seen by the callee.
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+}
+
+func (e Edge) ErrorRate() float64 {
+	if e.Calls == 0 {
+		return 0
+	}
+
+	return float64(e.Errors) / float64(e.Calls)
+}
+
+// peerAttributes are checked in order to name the other end of a CLIENT or
+// PRODUCER span which doesn't have any children in another service, e.g. a
+// database or an uninstrumented third party API.
+var peerAttributes = []string{
+	"peer.service",
+	"db.system.name",
+	"db.system",
+	"messaging.destination.name",
+	"messaging.system",
+	"server.address",
+	"net.peer.name",
+}
+
+type edgeKey struct {
+	from, to string
+}
+
+type edgeSamples struct {
+	async     bool
+	errors    int
+	latencies []time.Duration
+}
+
+// Derive finds the edges between services. An edge is added for every
+// span whose parent belongs to another service, and for every CLIENT or
+// PRODUCER span calling something outside of the traced services.
+func Derive(spans []db.Span) []Edge {
+	byID := make(map[string]*db.Span, len(spans))
+	for i := range spans {
+		byID[spans[i].ID] = &spans[i]
+	}
+
+	callsOut := make(map[string]bool)
+
+	keys := make([]edgeKey, 0)
+	samples := make(map[edgeKey]*edgeSamples)
+	add := func(key edgeKey, async bool, failed bool, latency time.Duration) {
+		s, ok := samples[key]
+		if !ok {
+			s = &edgeSamples{}
+			samples[key] = s
+			keys = append(keys, key)
+		}
+
+		s.async = s.async || async
+		if failed {
+			s.errors++
+		}
+		s.latencies = append(s.latencies, latency)
+	}
+
+	for i := range spans {
+		child := &spans[i]
+		parent, ok := byID[child.ParentSpanID.String]
+		if !child.ParentSpanID.Valid || !ok || parent.ServiceName == child.ServiceName {
+			continue
+		}
+
+		callsOut[parent.ID] = true
+
+		latency := child.Duration
+		if isOutgoing(parent.Kind) {
+			latency = parent.Duration
+		}
+
+		add(
+			edgeKey{parent.ServiceName, child.ServiceName},
+			parent.Kind == ptrace.SpanKindProducer.String() || child.Kind == ptrace.SpanKindConsumer.String(),
+			isError(parent) || isError(child),
+			latency,
+		)
+	}
+
+	for i := range spans {
+		span := &spans[i]
+		if !isOutgoing(span.Kind) || callsOut[span.ID] {
+			continue
+		}
+
+		peer := peerName(span)
+		if peer == "" || peer == span.ServiceName {
+			continue
+		}
+
+		add(
+			edgeKey{span.ServiceName, peer},
+			span.Kind == ptrace.SpanKindProducer.String(),
+			isError(span),
+			span.Duration,
+		)
+	}
+
+	edges := make([]Edge, len(keys))
+	for i, key := range keys {
+		s := samples[key]
+		slices.Sort(s.latencies)
+
+		edges[i] = Edge{
+			From:   key.from,
+			To:     key.to,
+			Async:  s.async,
+			Calls:  len(s.latencies),
+			Errors: s.errors,
+			P50:    percentile(s.latencies, 0.5),
+			P90:    percentile(s.latencies, 0.9),
+			P99:    percentile(s.latencies, 0.99),
+		}
+	}
+
+	slices.SortFunc(edges, func(a, b Edge) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To))
+	})
+
+	return edges
+}
+
+func isOutgoing(kind string) bool {
+	return kind == ptrace.SpanKindClient.String() || kind == ptrace.SpanKindProducer.String()
+}
+
+func isError(span *db.Span) bool {
+	return span.StatusCode == ptrace.StatusCodeError.String()
+}
+
+func peerName(span *db.Span) string {
+	for _, attr := range peerAttributes {
+		if v, ok := span.Attributes[attr].(string); ok && v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// percentile uses the nearest rank method on the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(float64(len(sorted))*p+0.5) - 1
+
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// Services returns the names of all services in the edges, sorted.
+func Services(edges []Edge) []string {
+	services := make([]string, 0)
+	for _, e := range edges {
+		for _, s := range []string{e.From, e.To} {
+			if !slices.Contains(services, s) {
+				services = append(services, s)
+			}
+		}
+	}
+
+	slices.Sort(services)
+
+	return services
+}
+
+// Layers groups the services by how far downstream they are. Services
+// which aren't called by anyone are in the first layer. Cycles are broken
+// by only ever pushing a service further downstream len(services) times.
+func Layers(edges []Edge) [][]string {
+	services := Services(edges)
+
+	depth := make(map[string]int, len(services))
+	for range services {
+		changed := false
+		for _, e := range edges {
+			if e.From != e.To && depth[e.To] < depth[e.From]+1 && depth[e.From]+1 < len(services) {
+				depth[e.To] = depth[e.From] + 1
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	layers := make([][]string, 0)
+	for _, s := range services {
+		for len(layers) <= depth[s] {
+			layers = append(layers, make([]string, 0))
+		}
+		layers[depth[s]] = append(layers[depth[s]], s)
+	}
+
+	return layers
+}
+
+func edgeLabel(e Edge) string {
+	return fmt.Sprintf("%d calls, %.1f%% errors, p50 %s, p99 %s", e.Calls, e.ErrorRate()*100, e.P50.Round(time.Microsecond), e.P99.Round(time.Microsecond))
+}
+
+// DOT renders the edges as a Graphviz digraph.
+func DOT(edges []Edge) string {
+	var b strings.Builder
+
+	b.WriteString("digraph services {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, s := range Services(edges) {
+		fmt.Fprintf(&b, "  %q;\n", s)
+	}
+	for _, e := range edges {
+		style := ""
+		if e.Async {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %q -> %q [label=%q%s];\n", e.From, e.To, edgeLabel(e), style)
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// Mermaid renders the edges as a Mermaid flowchart.
+func Mermaid(edges []Edge) string {
+	var b strings.Builder
+
+	ids := make(map[string]string)
+	b.WriteString("flowchart LR\n")
+	for i, s := range Services(edges) {
+		ids[s] = fmt.Sprintf("s%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[s], mermaidText(s))
+	}
+	for _, e := range edges {
+		arrow := "-->"
+		if e.Async {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s|\"%s\"| %s\n", ids[e.From], arrow, mermaidText(edgeLabel(e)), ids[e.To])
+	}
+
+	return b.String()
+}
+
+// mermaidText escapes text to go in double quotes in Mermaid, which has
+// entity codes rather than backslashes. # is escaped too, as it starts one.
+func mermaidText(s string) string {
+	return strings.NewReplacer("#", "#35;", `"`, "#quot;").Replace(s)
+}
@@ -0,0 +1,122 @@
+package servicemap_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui/servicemap"
+	"github.com/stretchr/testify/assert"
+)
+
+func span(id, parentID, service, kind string, duration time.Duration) db.Span {
+	return db.Span{
+		ID:           id,
+		ParentSpanID: sql.NullString{String: parentID, Valid: parentID != ""},
+		ServiceName:  service,
+		Kind:         kind,
+		Duration:     duration,
+		StatusCode:   "Unset",
+	}
+}
+
+func TestDerive(t *testing.T) {
+	t.Run("adds edges for spans crossing services", func(t *testing.T) {
+		failed := span("s2", "c2", "api", "Server", 5*time.Millisecond)
+		failed.StatusCode = "Error"
+
+		edges := servicemap.Derive([]db.Span{
+			span("root", "", "web", "Server", 100*time.Millisecond),
+			span("c1", "root", "web", "Client", 20*time.Millisecond),
+			span("s1", "c1", "api", "Server", 10*time.Millisecond),
+			span("c2", "root", "web", "Client", 30*time.Millisecond),
+			failed,
+			span("internal", "s1", "api", "Internal", time.Millisecond),
+		})
+
+		assert.Equal(t, []servicemap.Edge{
+			{From: "web", To: "api", Calls: 2, Errors: 1, P50: 20 * time.Millisecond, P90: 30 * time.Millisecond, P99: 30 * time.Millisecond},
+		}, edges)
+		assert.InDelta(t, 0.5, edges[0].ErrorRate(), 0.001)
+	})
+
+	t.Run("names uninstrumented peers from attributes", func(t *testing.T) {
+		query := span("q", "root", "api", "Client", 2*time.Millisecond)
+		query.Attributes = map[string]any{"db.system": "postgresql"}
+		unnamed := span("u", "root", "api", "Client", 2*time.Millisecond)
+
+		edges := servicemap.Derive([]db.Span{
+			span("root", "", "api", "Server", 10*time.Millisecond),
+			query,
+			unnamed,
+		})
+
+		assert.Len(t, edges, 1)
+		assert.Equal(t, "postgresql", edges[0].To)
+	})
+
+	t.Run("marks message queue edges as async", func(t *testing.T) {
+		edges := servicemap.Derive([]db.Span{
+			span("p", "", "web", "Producer", time.Millisecond),
+			span("c", "p", "worker", "Consumer", time.Second),
+		})
+
+		assert.Len(t, edges, 1)
+		assert.True(t, edges[0].Async)
+		assert.Equal(t, time.Millisecond, edges[0].P50)
+	})
+}
+
+func TestLayers(t *testing.T) {
+	t.Run("layers services by depth", func(t *testing.T) {
+		layers := servicemap.Layers([]servicemap.Edge{
+			{From: "web", To: "api"},
+			{From: "api", To: "db"},
+			{From: "web", To: "db"},
+			{From: "cron", To: "api"},
+		})
+
+		assert.Equal(t, [][]string{{"cron", "web"}, {"api"}, {"db"}}, layers)
+	})
+
+	t.Run("terminates on cycles", func(t *testing.T) {
+		layers := servicemap.Layers([]servicemap.Edge{
+			{From: "a", To: "b"},
+			{From: "b", To: "a"},
+		})
+
+		assert.Len(t, layers, 2)
+	})
+}
+
+func TestExport(t *testing.T) {
+	edges := []servicemap.Edge{
+		{From: "web", To: "api", Calls: 4, Errors: 1, P50: time.Millisecond, P99: 3 * time.Millisecond},
+		{From: "api", To: "worker", Async: true, Calls: 1},
+	}
+
+	t.Run("renders dot", func(t *testing.T) {
+		dot := servicemap.DOT(edges)
+
+		assert.Contains(t, dot, "digraph services {")
+		assert.Contains(t, dot, `"web" -> "api" [label="4 calls, 25.0% errors, p50 1ms, p99 3ms"];`)
+		assert.Contains(t, dot, `"api" -> "worker" [label="1 calls, 0.0% errors, p50 0s, p99 0s", style=dashed];`)
+	})
+
+	t.Run("renders mermaid", func(t *testing.T) {
+		mermaid := servicemap.Mermaid(edges)
+
+		assert.Contains(t, mermaid, "flowchart LR")
+		assert.Contains(t, mermaid, `s0["api"]`)
+		assert.Contains(t, mermaid, `s1 -->|"4 calls, 25.0% errors, p50 1ms, p99 3ms"| s0`)
+		assert.Contains(t, mermaid, `s0 -.->|"1 calls, 0.0% errors, p50 0s, p99 0s"| s2`)
+	})
+
+	t.Run("escapes quotes for mermaid", func(t *testing.T) {
+		mermaid := servicemap.Mermaid([]servicemap.Edge{{From: `say "hi" #1`, To: `C:\api`}})
+
+		assert.Contains(t, mermaid, `["say #quot;hi#quot; #35;1"]`)
+		assert.Contains(t, mermaid, `["C:\api"]`)
+	})
+}
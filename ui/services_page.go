@@ -0,0 +1,325 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"go.uber.org/zap"
+)
+
+type statsWindow struct {
+	label string
+	// duration of 0 means all the spans we have
+	duration time.Duration
+}
+
+var statsWindows = []statsWindow{
+	{"5m", 5 * time.Minute},
+	{"15m", 15 * time.Minute},
+	{"1h", time.Hour},
+	{"6h", 6 * time.Hour},
+	{"all", 0},
+}
+
+// sparklineBuckets is how many buckets the window is split into for the
+// rate sparklines.
+const sparklineBuckets = 30
+
+// ServicesPageModel shows rate, errors and duration per service and per
+// operation within each service.
+type ServicesPageModel struct {
+	width  int
+	height int
+
+	tableModel TableModel
+
+	window int
+	// since is the start of the window the stats were loaded for
+	since time.Time
+
+	// showHistogram is set when drilled into the latency histogram of the
+	// selected service or operation.
+	showHistogram  bool
+	histogramModel LatencyHistogramModel
+
+	db *db.Database
+}
+
+func NewServicesPageModel(db *db.Database) ServicesPageModel {
+	tm := NewTableModel()
+	tm.SetColumnDefinitions([]ColumnDefinition{
+		{4, "Service / operation"},
+		{1, "Rate"},
+		{1, "Errors"},
+		{1, "p50"},
+		{1, "p90"},
+		{1, "p99"},
+		{3, "Rate over time"},
+	})
+	// Operations are listed below their service
+	tm.SetSortable(false)
+
+	return ServicesPageModel{
+		tableModel: tm,
+		db:         db,
+	}
+}
+
+func (m ServicesPageModel) Init() tea.Cmd {
+	return tea.Batch(
+		m.loadStats(),
+		m.tableModel.Init(),
+	)
+}
+
+func (m ServicesPageModel) Update(msg tea.Msg) (ServicesPageModel, tea.Cmd) {
+	var cmd tea.Cmd
+	cmds := make([]tea.Cmd, 0)
+
+	switch msg := msg.(type) {
+	case MsgNewSpans:
+		cmds = append(cmds, m.loadStats())
+	case MsgOpenService:
+		m.showHistogram = false
+		m.selectService(msg.service)
+	case tea.MouseMsg:
+		if m.showHistogram {
+			m.histogramModel, cmd = m.histogramModel.Update(translateMouse(msg, 1, 1))
+			return m, cmd
+		}
+	case MsgOperationStatsUpdated:
+		if msg.window == m.window {
+			m.since = msg.since
+			m.setStats(msg)
+		}
+	case tea.KeyMsg:
+		if m.showHistogram {
+			if key.Matches(msg, keys.Back) && !m.histogramModel.focusTraces {
+				m.showHistogram = false
+				return m, nil
+			}
+
+			m.histogramModel, cmd = m.histogramModel.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case key.Matches(msg, keys.Window):
+			m.window = (m.window + 1) % len(statsWindows)
+			return m, m.loadStats()
+		case key.Matches(msg, keys.Select):
+			if item, ok := m.tableModel.SelectedItem().(*operationStatsTableItemDelegate); ok {
+				m.showHistogram = true
+				m.histogramModel = NewLatencyHistogramModel(m.db, item.stats, m.since)
+				m.histogramModel.SetWidth(m.width - 2)
+				m.histogramModel.SetHeight(m.height - 2)
+				return m, m.histogramModel.Init()
+			}
+		}
+	}
+
+	if m.showHistogram {
+		m.histogramModel, cmd = m.histogramModel.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	// Below the border and the window selector
+	m.tableModel, cmd = m.tableModel.Update(translateMouse(msg, 1, 2))
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+// Commands open the stats of each service.
+func (m ServicesPageModel) Commands() []Command {
+	commands := make([]Command, 0)
+	for _, item := range m.tableModel.items {
+		if d, ok := item.(*operationStatsTableItemDelegate); ok && !d.stats.Name.Valid {
+			commands = append(commands, Command{"Service", d.stats.ServiceName, helpers.Cmdize(MsgOpenService{d.stats.ServiceName})})
+		}
+	}
+
+	return commands
+}
+
+func (m *ServicesPageModel) selectService(service string) {
+	for i, item := range m.tableModel.items {
+		if d, ok := item.(*operationStatsTableItemDelegate); ok && !d.stats.Name.Valid && d.stats.ServiceName == service {
+			m.tableModel.SetCursorRow(i)
+			return
+		}
+	}
+}
+
+func (m ServicesPageModel) View() string {
+	container := lipgloss.
+		NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(helpers.ColorBorder).
+		BorderBackground(helpers.ColorBackground).
+		Background(helpers.ColorBackground)
+
+	windows := make([]string, len(statsWindows))
+	for i, w := range statsWindows {
+		style := helpers.NavigationPillBaseStyle
+		if i == m.window {
+			style = helpers.Highlight(style, helpers.ColorSecondary, helpers.ColorSecondaryForeground)
+		}
+		windows[i] = style.Render(w.label)
+	}
+
+	if m.showHistogram {
+		return container.Render(m.histogramModel.View())
+	}
+
+	return container.Render(
+		helpers.VStack(
+			helpers.HStack(
+				lipgloss.NewStyle().Faint(true).Render("Window "),
+				helpers.HStack(windows...),
+			),
+			m.tableModel.View(),
+		),
+	)
+}
+
+func (m ServicesPageModel) ShortHelp() []key.Binding {
+	if m.showHistogram {
+		bindings := m.histogramModel.ShortHelp()
+		if !m.histogramModel.focusTraces {
+			bindings = append(bindings, keys.Back)
+		}
+		return bindings
+	}
+
+	return []key.Binding{keys.Window, withHelp(keys.Select, "latency histogram")}
+}
+
+func (m ServicesPageModel) FullHelp() [][]key.Binding {
+	if m.showHistogram {
+		return append(m.histogramModel.FullHelp(), []key.Binding{keys.Back})
+	}
+
+	return [][]key.Binding{navigationBindings(), m.ShortHelp()}
+}
+
+func (m ServicesPageModel) loadStats() tea.Cmd {
+	window := m.window
+
+	return func() tea.Msg {
+		ctx := context.Background()
+		now := time.Now()
+
+		since := now.Add(-statsWindows[window].duration)
+		if statsWindows[window].duration == 0 {
+			earliest, err := m.db.GetEarliestSpanStartTime(ctx)
+			if err != nil {
+				zap.L().Warn("could not get earliest span", zap.Error(err))
+				return nil
+			}
+			since = earliest
+		}
+
+		stats, err := m.db.GetOperationStats(ctx, since)
+		if err != nil {
+			zap.L().Warn("could not get operation stats", zap.Error(err))
+			return nil
+		}
+
+		bucketSize := now.Sub(since) / sparklineBuckets
+		rates, err := m.db.GetOperationRates(ctx, since, bucketSize)
+		if err != nil {
+			zap.L().Warn("could not get operation rates", zap.Error(err))
+			return nil
+		}
+
+		return MsgOperationStatsUpdated{
+			window:   window,
+			since:    since,
+			duration: now.Sub(since),
+			stats:    stats,
+			rates:    rates,
+		}
+	}
+}
+
+func (m *ServicesPageModel) setStats(msg MsgOperationStatsUpdated) {
+	type operation struct {
+		service string
+		name    string
+	}
+
+	buckets := make(map[operation][]float64)
+	for _, r := range msg.rates {
+		op := operation{r.ServiceName, r.Name.String}
+		if _, ok := buckets[op]; !ok {
+			buckets[op] = make([]float64, sparklineBuckets)
+		}
+		if r.Bucket >= 0 && r.Bucket < sparklineBuckets {
+			buckets[op][r.Bucket] = float64(r.Count)
+		}
+	}
+
+	items := make([]TableItemDelegate, len(msg.stats))
+	for i, s := range msg.stats {
+		items[i] = &operationStatsTableItemDelegate{
+			stats:    s,
+			duration: msg.duration,
+			buckets:  buckets[operation{s.ServiceName, s.Name.String}],
+		}
+	}
+
+	m.tableModel.SetItems(items)
+}
+
+func (m *ServicesPageModel) SetWidth(w int) {
+	m.width = w
+	m.tableModel.SetWidth(w - 2)
+	m.histogramModel.SetWidth(w - 2)
+}
+
+func (m *ServicesPageModel) SetHeight(h int) {
+	m.height = h
+	m.tableModel.SetHeight(h - 3) // - border and window selector
+	m.histogramModel.SetHeight(h - 2)
+}
+
+type operationStatsTableItemDelegate struct {
+	stats db.OperationStats
+	// duration is how long the stats are computed over
+	duration time.Duration
+	buckets  []float64
+}
+
+func (d operationStatsTableItemDelegate) Content() []string {
+	name := d.stats.ServiceName
+	if d.stats.Name.Valid {
+		name = "  " + d.stats.Name.String
+	}
+
+	rate := 0.0
+	if d.duration > 0 {
+		rate = float64(d.stats.Count) / d.duration.Seconds()
+	}
+
+	errorRatio := 0.0
+	if d.stats.Count > 0 {
+		errorRatio = float64(d.stats.Errors) / float64(d.stats.Count) * 100
+	}
+
+	return []string{
+		name,
+		fmt.Sprintf("%.2f/s", rate),
+		fmt.Sprintf("%.1f%%", errorRatio),
+		d.stats.P50.Round(time.Microsecond).String(),
+		d.stats.P90.Round(time.Microsecond).String(),
+		d.stats.P99.Round(time.Microsecond).String(),
+		helpers.Sparkline(d.buckets),
+	}
+}
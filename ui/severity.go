@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+)
+
+// Lower bounds of the severity number ranges, see
+// https://opentelemetry.io/docs/specs/otel/logs/data-model/#field-severitynumber
+const (
+	severityTrace = 1
+	severityDebug = 5
+	severityInfo  = 9
+	severityWarn  = 13
+	severityError = 17
+	severityFatal = 21
+)
+
+// severityName is the short name of the range the severity number is in.
+func severityName(n int) string {
+	switch {
+	case n >= severityFatal:
+		return "FATAL"
+	case n >= severityError:
+		return "ERROR"
+	case n >= severityWarn:
+		return "WARN"
+	case n >= severityInfo:
+		return "INFO"
+	case n >= severityDebug:
+		return "DEBUG"
+	case n >= severityTrace:
+		return "TRACE"
+	}
+
+	return "UNSET"
+}
+
+// severityLabel prefers the severity text set by the emitter, as it's what
+// people will recognise from their own logging library.
+func severityLabel(l db.Log) string {
+	if l.SeverityText != "" {
+		return l.SeverityText
+	}
+
+	return severityName(l.SeverityNumber)
+}
+
+func severityColor(n int) lipgloss.TerminalColor {
+	switch {
+	case n >= severityError:
+		return helpers.ColorDestructive
+	case n >= severityWarn:
+		return helpers.ColorSecondary
+	case n >= severityInfo:
+		return helpers.ColorAccent
+	}
+
+	return helpers.ColorMutedForeground
+}
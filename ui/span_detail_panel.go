@@ -2,21 +2,61 @@ package ui
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/fredrikaugust/otelly/db"
 	"github.com/fredrikaugust/otelly/ui/flamegraph"
 	"github.com/fredrikaugust/otelly/ui/helpers"
 	"go.uber.org/zap"
 )
 
+// waterfallTop is the line of the first bar of the waterfall.
+const waterfallTop = 4
+
+// timeRange is a part of the trace, from 0 at its start to 1 at its end.
+type timeRange struct {
+	start float64
+	end   float64
+}
+
 type SpanDetailPanelModel struct {
 	span *db.Span
 
-	tree flamegraph.Node
+	// spans are all the spans of the trace, and selectedID the one clicked
+	// in the waterfall if it isn't the root span
+	spans      []db.Span
+	selectedID string
+
+	roots flamegraph.Forest
+	skews []flamegraph.ClockSkew
+
+	// zoom is the part of the trace shown in the waterfall, which is
+	// dragged over with the mouse to zoom in.
+	zoom timeRange
+	// dragFrom is where dragging started, and dragTo where the mouse is
+	// now. They're -1 when not dragging.
+	dragFrom int
+	dragTo   int
+
+	// traceLogs are shown as markers on the waterfall, spanLogs are listed
+	traceLogs []db.Log
+	spanLogs  []db.Log
+
+	// adjustClockSkew shifts the spans of each service in the waterfall to
+	// line up with their callers. The stored timestamps are left as is.
+	adjustClockSkew bool
+
+	// focused is set when the panel has the focus of the page, in which
+	// case the navigation keys scroll it by yOffset lines.
+	focused bool
+	yOffset int
 
 	height int
 	width  int
@@ -25,7 +65,12 @@ type SpanDetailPanelModel struct {
 }
 
 func NewSpanDetailPanelModel(db *db.Database) SpanDetailPanelModel {
-	return SpanDetailPanelModel{db: db}
+	return SpanDetailPanelModel{
+		zoom:     timeRange{0, 1},
+		dragFrom: -1,
+		dragTo:   -1,
+		db:       db,
+	}
 }
 
 func (m SpanDetailPanelModel) Init() tea.Cmd {
@@ -45,28 +90,189 @@ func (m SpanDetailPanelModel) Update(msg tea.Msg) (SpanDetailPanelModel, tea.Cmd
 					zap.L().Warn("could not get spans for trace", zap.String("traceID", msg.traceID), zap.Error(err))
 					return nil
 				}
-				node, err := flamegraph.Build(spans, func(s db.Span) flamegraph.NodeInput {
-					return flamegraph.NodeInput{
-						ID:        s.ID,
-						Name:      s.Name,
-						Duration:  s.Duration,
-						ParentID:  s.ParentSpanID.String,
-						StartTime: s.StartTime,
-					}
-				})
+				opts := make([]flamegraph.BuildOption, 0)
+				if m.adjustClockSkew {
+					opts = append(opts, flamegraph.WithClockSkewAdjustment())
+				}
+				roots, err := flamegraph.Build(spans, spanNodeInput, opts...)
 				if err != nil {
 					zap.L().Warn("could not create flamegraph for trace", zap.String("traceID", msg.traceID), zap.Error(err))
 				}
-				return MsgTreeUpdated{tree: node}
+				inputs := make([]flamegraph.NodeInput, len(spans))
+				for i, s := range spans {
+					inputs[i] = spanNodeInput(s)
+				}
+				return MsgTreeUpdated{traceID: msg.traceID, spans: spans, roots: roots, skews: flamegraph.DetectClockSkew(inputs)}
 			},
+			m.loadLogs(),
 		)
 	case MsgTreeUpdated:
-		m.tree = msg.tree
+		if m.span == nil || m.span.TraceID != msg.traceID {
+			break
+		}
+		m.spans = msg.spans
+		m.roots = msg.roots
+		m.skews = msg.skews
+	case MsgNewLogs:
+		cmds = append(cmds, m.loadLogs())
+	case MsgTraceLogsUpdated:
+		if m.span != nil && m.span.TraceID == msg.traceID {
+			m.traceLogs = msg.traceLogs
+			m.spanLogs = msg.spanLogs
+		}
+	case tea.MouseMsg:
+		cmds = append(cmds, m.updateMouse(msg))
+	case tea.KeyMsg:
+		switch {
+		case m.focused && key.Matches(msg, navigationBindings()...):
+			m.scroll(msg)
+		case key.Matches(msg, keys.ZoomOut):
+			m.zoom = timeRange{0, 1}
+		case key.Matches(msg, keys.ClockSkew):
+			m.adjustClockSkew = !m.adjustClockSkew
+			if m.span != nil {
+				cmds = append(cmds, helpers.Cmdize(MsgLoadTrace{traceID: m.span.TraceID}))
+			}
+		}
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// scroll moves the view of the panel by the navigation key.
+func (m *SpanDetailPanelModel) scroll(msg tea.KeyMsg) {
+	switch {
+	case key.Matches(msg, keys.Down):
+		m.yOffset += 1
+	case key.Matches(msg, keys.Up):
+		m.yOffset -= 1
+	case key.Matches(msg, keys.PageDown):
+		m.yOffset += max(1, m.height/2)
+	case key.Matches(msg, keys.PageUp):
+		m.yOffset -= max(1, m.height/2)
+	case key.Matches(msg, keys.Top):
+		m.yOffset = 0
+	case key.Matches(msg, keys.Bottom):
+		m.yOffset = m.maxYOffset()
+	}
+
+	m.yOffset = max(0, helpers.Clamp(0, m.yOffset, m.maxYOffset()))
+}
+
+// maxYOffset is how far the panel can be scrolled before the end of its
+// content reaches the bottom.
+func (m SpanDetailPanelModel) maxYOffset() int {
+	if m.span == nil {
+		return 0
+	}
+
+	return max(0, lipgloss.Height(m.contentView())-m.height)
+}
+
+// updateMouse selects the span of a clicked bar in the waterfall, zooms
+// into the time dragged over and scrolls with the wheel.
+func (m *SpanDetailPanelModel) updateMouse(msg tea.MouseMsg) tea.Cmd {
+	node := m.nodeAt(msg.Y + m.yOffset - waterfallTop)
+	inWaterfall := mouseIn(msg, m.width, m.height) && node != nil
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelDown && mouseIn(msg, m.width, m.height):
+		m.yOffset = min(m.yOffset+1, m.maxYOffset())
+	case msg.Button == tea.MouseButtonWheelUp && mouseIn(msg, m.width, m.height):
+		m.yOffset = max(m.yOffset-1, 0)
+	case msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonRight && inWaterfall:
+		m.zoom = timeRange{0, 1}
+	case msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft && inWaterfall:
+		m.dragFrom, m.dragTo = msg.X, msg.X
+	case msg.Action == tea.MouseActionMotion && m.dragFrom >= 0:
+		m.dragTo = max(0, helpers.Clamp(0, msg.X, m.width-1))
+	case msg.Action == tea.MouseActionRelease && m.dragFrom >= 0:
+		from, to := min(m.dragFrom, m.dragTo), max(m.dragFrom, m.dragTo)
+		m.dragFrom, m.dragTo = -1, -1
+
+		// A short drag is most likely a sloppy click
+		if to-from >= 2 {
+			m.zoom = timeRange{m.timeAt(from), m.timeAt(to + 1)}
+			return nil
+		}
+
+		if !inWaterfall || node.Missing {
+			return nil
+		}
+
+		m.selectedID = node.ID
+		if m.span != nil && node.ID == m.span.ID {
+			m.selectedID = ""
+		}
+		return m.loadLogs()
+	}
+
+	return nil
+}
+
+// nodeAt returns the node on the row of the waterfall, if there is one.
+func (m SpanDetailPanelModel) nodeAt(row int) *flamegraph.Node {
+	i := 0
+	for _, n := range m.roots.All() {
+		if i == row {
+			return n
+		}
+		i++
+	}
+
+	return nil
+}
+
+// timeAt is the part of the trace at column x of the waterfall.
+func (m SpanDetailPanelModel) timeAt(x int) float64 {
+	if m.width == 0 {
+		return m.zoom.start
+	}
+
+	return m.zoom.start + float64(x)/float64(m.width)*(m.zoom.end-m.zoom.start)
+}
+
+// columnAt is the column of the waterfall showing the part of the trace,
+// which is outside it when the waterfall is zoomed in elsewhere.
+func (m SpanDetailPanelModel) columnAt(t float64) float64 {
+	return (t - m.zoom.start) / (m.zoom.end - m.zoom.start) * float64(m.width)
+}
+
+// selectedSpan is the span clicked in the waterfall, or the root span.
+func (m SpanDetailPanelModel) selectedSpan() *db.Span {
+	for i := range m.spans {
+		if m.spans[i].ID == m.selectedID {
+			return &m.spans[i]
+		}
+	}
+
+	return m.span
+}
+
+func (m SpanDetailPanelModel) loadLogs() tea.Cmd {
+	span := m.selectedSpan()
+	if span == nil {
+		return nil
+	}
+
+	traceID, spanID := span.TraceID, span.ID
+
+	return func() tea.Msg {
+		traceLogs, err := m.db.GetLogsForTrace(context.Background(), traceID)
+		if err != nil {
+			zap.L().Warn("could not get logs for trace", zap.String("traceID", traceID), zap.Error(err))
+			return nil
+		}
+		spanLogs, err := m.db.GetLogsForSpan(context.Background(), spanID)
+		if err != nil {
+			zap.L().Warn("could not get logs for span", zap.String("spanID", spanID), zap.Error(err))
+			return nil
+		}
+
+		return MsgTraceLogsUpdated{traceID: traceID, traceLogs: traceLogs, spanLogs: spanLogs}
+	}
+}
+
 func (m SpanDetailPanelModel) View() string {
 	container := lipgloss.
 		NewStyle().
@@ -79,20 +285,46 @@ func (m SpanDetailPanelModel) View() string {
 		return container.Align(lipgloss.Center, lipgloss.Center).Render("No span selected")
 	}
 
-	return container.Render(
-		helpers.VStack(
-			lipgloss.NewStyle().Render("Span", m.span.ID, "•", m.spanKindView()),
-			lipgloss.NewStyle().Render(m.span.Name, "•", m.span.Duration.Round(time.Microsecond).String()),
-			"", // spacer
-			m.traceView(),
-			"", // spacer
-			m.attributeView(),
-			"", // spacer
-			m.resourceView(),
-		),
+	lines := strings.Split(m.contentView(), "\n")
+
+	return container.Render(strings.Join(lines[min(m.yOffset, len(lines)-1):], "\n"))
+}
+
+// contentView is everything in the panel, before scrolling.
+func (m SpanDetailPanelModel) contentView() string {
+	span := m.selectedSpan()
+
+	// Notes below the waterfall, which are left out when there's nothing
+	// to say
+	notes := slices.DeleteFunc([]string{m.clockSkewView(), m.codeLocationView()}, func(s string) bool { return s == "" })
+
+	return helpers.VStack(
+		lipgloss.NewStyle().Render("Span", span.ID, "•", span.Kind),
+		lipgloss.NewStyle().Render(span.Name, "•", span.Duration.Round(time.Microsecond).String(), m.selfTimeView()),
+		"", // spacer
+		m.traceView(),
+		helpers.VStack(notes...),
+		"", // spacer
+		m.selfTimeTableView(),
+		"", // spacer
+		m.logsView(),
+		"", // spacer
+		m.attributeView(),
+		"", // spacer
+		m.resourceView(),
 	)
 }
 
+// codeLocationView shows where in the source the span comes from, if known.
+func (m SpanDetailPanelModel) codeLocationView() string {
+	loc, ok := CodeLocationOf(m.selectedSpan().Attributes, m.selectedSpan().Events...)
+	if !ok {
+		return ""
+	}
+
+	return helpers.HStack(lipgloss.NewStyle().Faint(true).Render("Source "), loc.String())
+}
+
 func (m SpanDetailPanelModel) resourceView() string {
 	return "resource"
 }
@@ -102,45 +334,331 @@ func (m SpanDetailPanelModel) attributeView() string {
 }
 
 func (m SpanDetailPanelModel) traceView() string {
-	if m.tree.Name == "" {
+	if len(m.roots) == 0 {
 		return "Trace not set"
 	}
 
 	spans := make([]string, 0)
+	selected := m.selectedSpan()
+
+	for _, c := range m.roots.All() {
+		start := max(0, m.columnAt(c.OffsetPct))
+		end := min(float64(m.width), m.columnAt(c.OffsetPct+c.WidthPct))
+		offset := max(0, min(int(start), m.width-1))
+		width := helpers.Clamp(1, int(end-start), m.width)
 
-	for _, c := range m.tree.All() {
-		width := helpers.Clamp(1, int(c.WidthPct*float64(m.width)), m.width)
-		offset := int(c.OffsetPct * float64(m.width))
+		bar := helpers.Highlight(lipgloss.NewStyle(), helpers.ColorPrimary, helpers.ColorPrimaryForeground)
+		if m.selectedID != "" && c.ID == selected.ID {
+			bar = helpers.Highlight(bar, helpers.ColorSecondary, helpers.ColorSecondaryForeground)
+		}
+		if c.Missing {
+			bar = bar.Reverse(false).Background(helpers.ColorMuted).Foreground(helpers.ColorMutedForeground)
+		}
 
-		name := lipgloss.NewStyle().Render(
-			lipgloss.NewStyle().Render(c.Name),
-			lipgloss.NewStyle().Faint(true).Render(c.Duration.Round(time.Microsecond).String()),
+		segments := []barSegment{
+			{nodeMarkers(c) + c.Name, bar},
+			{" " + c.Duration.Round(time.Microsecond).String(), bar.Faint(true)},
+			{" (self " + c.SelfTime.Round(time.Microsecond).String() + ")", bar.Faint(true)},
+		}
+		if c.SkewAdjustment != 0 {
+			segments = append(segments, barSegment{" (shifted " + formatOffset(c.SkewAdjustment) + ")", bar.Faint(true)})
+		}
+		segments = m.withLogMarkers(fitBar(segments, bar, width), bar, c, offset, width)
+
+		row := helpers.HStack(
+			strings.Repeat(" ", offset),
+			renderBar(segments),
 		)
+		spans = append(spans, row)
+	}
+
+	return helpers.VStack(
+		helpers.HStack("Trace", lipgloss.NewStyle().Faint(true).Render(m.zoomView())),
+		helpers.VStack(spans...),
+	)
+}
+
+// zoomView describes the part of the trace being dragged over or zoomed
+// into.
+func (m SpanDetailPanelModel) zoomView() string {
+	d := float64(m.roots.Duration())
+	describe := func(r timeRange) string {
+		from := time.Duration(r.start * d).Round(time.Microsecond)
+		to := time.Duration(r.end * d).Round(time.Microsecond)
+
+		return fmt.Sprintf("%s–%s", from, to)
+	}
+
+	switch {
+	case m.dragFrom >= 0 && m.dragFrom != m.dragTo:
+		from, to := min(m.dragFrom, m.dragTo), max(m.dragFrom, m.dragTo)
+		return " • release to zoom to " + describe(timeRange{m.timeAt(from), m.timeAt(to + 1)})
+	case m.zoomed():
+		return fmt.Sprintf(" • zoomed to %s, %s to reset", describe(m.zoom), keyName(keys.ZoomOut))
+	}
+
+	return ""
+}
+
+func (m SpanDetailPanelModel) zoomed() bool {
+	return m.zoom != timeRange{0, 1}
+}
 
-		spans = append(
-			spans,
+// barSegment is part of the text of a bar in the trace. Each is styled on
+// its own, so that none of the bar's style is lost to the reset at the end
+// of another.
+type barSegment struct {
+	text  string
+	style lipgloss.Style
+}
+
+// fitBar cuts the segments off at the width of the bar, or pads them to it.
+func fitBar(segments []barSegment, bar lipgloss.Style, width int) []barSegment {
+	fitted := make([]barSegment, 0, len(segments)+1)
+	for _, s := range segments {
+		s.text = ansi.Truncate(strings.ReplaceAll(s.text, "\n", ""), width, "")
+		width -= ansi.StringWidth(s.text)
+		fitted = append(fitted, s)
+	}
+
+	if width > 0 {
+		fitted = append(fitted, barSegment{strings.Repeat(" ", width), bar})
+	}
+
+	return fitted
+}
+
+// replaceColumn replaces the character at the column of the bar with the
+// segment.
+func replaceColumn(segments []barSegment, column int, with barSegment) []barSegment {
+	for i, s := range segments {
+		width := ansi.StringWidth(s.text)
+		if column >= width {
+			column -= width
+			continue
+		}
+
+		return slices.Concat(
+			segments[:i:i],
+			[]barSegment{{ansi.Cut(s.text, 0, column), s.style}, with, {ansi.Cut(s.text, column+1, width), s.style}},
+			segments[i+1:],
+		)
+	}
+
+	return segments
+}
+
+func renderBar(segments []barSegment) string {
+	var b strings.Builder
+	for _, s := range segments {
+		if s.text != "" {
+			b.WriteString(s.style.Render(s.text))
+		}
+	}
+
+	return b.String()
+}
+
+// withLogMarkers draws a marker on the bar of the node at the time of each
+// log emitted within the span.
+func (m SpanDetailPanelModel) withLogMarkers(segments []barSegment, bar lipgloss.Style, n *flamegraph.Node, offset, width int) []barSegment {
+	for _, l := range m.traceLogs {
+		if l.SpanID.String != n.ID {
+			continue
+		}
+
+		t := n.OffsetPct
+		if n.Duration > 0 {
+			t += float64(l.Timestamp.Sub(n.StartTime.Add(-n.SkewAdjustment))) / float64(n.Duration) * n.WidthPct
+		}
+		if column := m.columnAt(t); column < 0 || column >= float64(m.width) {
+			// Zoomed in elsewhere
+			continue
+		}
+		pos := helpers.Clamp(offset, int(m.columnAt(t)), offset+width-1)
+
+		// On the bar's background, so it doesn't leave a gap in it
+		marker := barSegment{"◆", bar.Foreground(severityColor(l.SeverityNumber)).Bold(true)}
+		segments = replaceColumn(segments, pos-offset, marker)
+	}
+
+	return segments
+}
+
+// logsView lists the logs emitted within the selected span.
+func (m SpanDetailPanelModel) logsView() string {
+	title := fmt.Sprintf("Logs (%d)", len(m.spanLogs))
+	if others := len(m.traceLogs) - len(m.spanLogs); others > 0 {
+		title += lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf(" • %d more in the trace, marked ◆ above", others))
+	}
+
+	rows := make([]string, len(m.spanLogs))
+	for i, l := range m.spanLogs {
+		rows[i] = lipgloss.NewStyle().MaxWidth(m.width).Render(
 			helpers.HStack(
-				strings.Repeat(" ", offset),
-				lipgloss.
-					NewStyle().
-					Width(width).
-					MaxWidth(width).
-					Inline(true).
-					Background(helpers.ColorPrimary).
-					Foreground(helpers.ColorPrimaryForeground).
-					Render(name),
+				lipgloss.NewStyle().Faint(true).Render(l.Timestamp.Format("15:04:05.000")),
+				" ",
+				lipgloss.NewStyle().Foreground(severityColor(l.SeverityNumber)).Render(severityLabel(l)),
+				" ",
+				strings.ReplaceAll(l.Body, "\n", " "),
 			),
 		)
 	}
 
 	return helpers.VStack(
-		"Trace",
-		helpers.VStack(spans...),
+		title,
+		helpers.VStack(rows...),
+	)
+}
+
+// selfTimeView shows the self time of the selected span if it's part of the
+// loaded trace.
+func (m SpanDetailPanelModel) selfTimeView() string {
+	for _, n := range m.roots.All() {
+		if n.ID == m.span.ID {
+			return lipgloss.NewStyle().Faint(true).Render("• self", n.SelfTime.Round(time.Microsecond).String())
+		}
+	}
+
+	return ""
+}
+
+// selfTimeTableView shows where the time in the trace was spent, aggregated
+// by span name and by service.
+func (m SpanDetailPanelModel) selfTimeTableView() string {
+	if len(m.roots) == 0 {
+		return ""
+	}
+
+	return helpers.VStack(
+		"Self time by span name",
+		m.selfTimeEntriesView(m.roots.SelfTimeBy(func(n *flamegraph.Node) string { return n.Name })),
+		"", // spacer
+		"Self time by service",
+		m.selfTimeEntriesView(m.roots.SelfTimeBy(func(n *flamegraph.Node) string { return n.Service })),
+	)
+}
+
+func (m SpanDetailPanelModel) selfTimeEntriesView(entries []flamegraph.SelfTimeEntry) string {
+	durationWidth := 12
+	pctWidth := 6
+	keyWidth := max(1, m.width-durationWidth-pctWidth)
+
+	rows := make([]string, len(entries))
+	for i, e := range entries {
+		pct := 0.0
+		if d := m.roots.Duration(); d > 0 {
+			pct = float64(e.SelfTime) / float64(d) * 100
+		}
+
+		rows[i] = helpers.HStack(
+			lipgloss.NewStyle().Width(keyWidth).MaxWidth(keyWidth).Inline(true).Render(fmt.Sprintf("%s (%d)", e.Key, e.Count)),
+			lipgloss.NewStyle().Width(durationWidth).Align(lipgloss.Right).Render(e.SelfTime.Round(time.Microsecond).String()),
+			lipgloss.NewStyle().Width(pctWidth).Align(lipgloss.Right).Faint(true).Render(fmt.Sprintf("%.0f%%", pct)),
+		)
+	}
+
+	return helpers.VStack(rows...)
+}
+
+// ShortHelp has the keys to reset the zoom and adjust clock skew, when
+// zoomed in and when there is any skew.
+func (m SpanDetailPanelModel) ShortHelp() []key.Binding {
+	bindings := make([]key.Binding, 0)
+	if span := m.selectedSpan(); span != nil {
+		if _, ok := CodeLocationOf(span.Attributes, span.Events...); ok {
+			bindings = append(bindings, keys.OpenInEditor)
+		}
+	}
+	if m.zoomed() {
+		bindings = append(bindings, withHelp(keys.ZoomOut, "reset zoom"))
+	}
+
+	for _, skew := range m.skews {
+		if skew.Offset != 0 {
+			return append(bindings, keys.ClockSkew)
+		}
+	}
+
+	return bindings
+}
+
+// resourceName names the service, and the instance of it when there is
+// one, e.g. api (pod-1).
+func resourceName(service, instance string) string {
+	if instance == "" {
+		return service
+	}
+
+	return fmt.Sprintf("%s (%s)", service, instance)
+}
+
+// clockSkewView lists the services, or instances of them, whose clocks
+// disagree with their callers.
+func (m SpanDetailPanelModel) clockSkewView() string {
+	rows := make([]string, 0)
+	for _, skew := range m.skews {
+		if skew.Offset == 0 {
+			continue
+		}
+		rows = append(rows, lipgloss.NewStyle().Faint(true).Render(
+			resourceName(skew.ClientService, skew.ClientInstance), "→",
+			resourceName(skew.ServerService, skew.ServerInstance), formatOffset(skew.Offset),
+		))
+	}
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	status := fmt.Sprintf("not adjusted, press %s to adjust", keyName(keys.ClockSkew))
+	if m.adjustClockSkew {
+		status = fmt.Sprintf("adjusted, press %s to show raw timestamps", keyName(keys.ClockSkew))
+	}
+
+	return helpers.VStack(
+		"", // spacer
+		fmt.Sprintf("Clock skew (%s)", status),
+		helpers.VStack(rows...),
 	)
 }
 
-func (m SpanDetailPanelModel) spanKindView() string {
-	return m.span.Kind
+func formatOffset(d time.Duration) string {
+	if d > 0 {
+		return "+" + d.Round(time.Microsecond).String()
+	}
+
+	return d.Round(time.Microsecond).String()
+}
+
+// nodeMarkers returns a prefix flagging nodes which didn't fit neatly
+// into the trace tree.
+func nodeMarkers(n *flamegraph.Node) string {
+	var markers strings.Builder
+	if n.Cycle {
+		markers.WriteString("↻ ")
+	}
+	if n.ClockSkewed {
+		markers.WriteString("⚠ ")
+	}
+	if n.SkewAdjustment != 0 {
+		markers.WriteString("⇆ ")
+	}
+
+	return markers.String()
+}
+
+func spanNodeInput(s db.Span) flamegraph.NodeInput {
+	return flamegraph.NodeInput{
+		ID:        s.ID,
+		Name:      s.Name,
+		Service:   s.ServiceName,
+		Instance:  s.ServiceInstance,
+		Kind:      s.Kind,
+		Duration:  s.Duration,
+		ParentID:  s.ParentSpanID.String,
+		StartTime: s.StartTime,
+	}
 }
 
 func (m *SpanDetailPanelModel) SetHeight(i int) {
@@ -151,10 +669,19 @@ func (m *SpanDetailPanelModel) SetWidth(i int) {
 	m.width = i
 }
 
+// SetFocused sets whether the navigation keys scroll the panel.
+func (m *SpanDetailPanelModel) SetFocused(focused bool) {
+	m.focused = focused
+}
+
 func (m SpanDetailPanelModel) UpdateSpan(span *db.Span) (SpanDetailPanelModel, tea.Cmd) {
 	if span == nil {
 		m.span = nil
-		m.tree = flamegraph.Node{}
+		m.spans = nil
+		m.selectedID = ""
+		m.roots = nil
+		m.traceLogs = nil
+		m.spanLogs = nil
 
 		return m, nil
 	}
@@ -164,6 +691,11 @@ func (m SpanDetailPanelModel) UpdateSpan(span *db.Span) (SpanDetailPanelModel, t
 	}
 
 	m.span = span
+	m.selectedID = ""
+	m.zoom = timeRange{0, 1}
+	m.yOffset = 0
+	m.traceLogs = nil
+	m.spanLogs = nil
 
 	return m, helpers.Cmdize(MsgLoadTrace{traceID: span.TraceID})
 }
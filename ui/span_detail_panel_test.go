@@ -1,11 +1,20 @@
 package ui_test
 
 import (
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/fredrikaugust/otelly/db"
 	"github.com/fredrikaugust/otelly/ui"
+	"github.com/muesli/termenv"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
 func TestUpdateSpan(t *testing.T) {
@@ -25,3 +34,148 @@ func TestUpdateSpan(t *testing.T) {
 		assert.IsType(t, ui.MsgLoadTrace{}, cmd())
 	})
 }
+
+func TestClockSkewToggle(t *testing.T) {
+	t.Run("reloads the trace when toggling", func(t *testing.T) {
+		m := ui.NewSpanDetailPanelModel(nil)
+		m, _ = m.UpdateSpan(&db.Span{ID: "test-id", TraceID: "trace-id"})
+
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+
+		assert.NotNil(t, cmd)
+		assert.IsType(t, ui.MsgLoadTrace{}, cmd())
+	})
+
+	t.Run("does nothing without a span", func(t *testing.T) {
+		m := ui.NewSpanDetailPanelModel(nil)
+
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+
+		assert.Nil(t, cmd)
+	})
+}
+
+func TestSpanLogs(t *testing.T) {
+	database, err := db.NewDB(":memory:")
+	assert.Nil(t, err)
+	defer database.Close()
+	assert.Nil(t, database.Migrate(t.Context()))
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	rs := ptrace.NewResourceSpans()
+	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID{1})
+	span.SetSpanID(pcommon.SpanID{1})
+	span.SetName("GET /")
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Second)))
+	assert.Nil(t, database.InsertResourceSpans(t.Context(), rs))
+
+	rl := plog.NewResourceLogs()
+	record := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	record.SetTraceID(pcommon.TraceID{1})
+	record.SetSpanID(pcommon.SpanID{1})
+	record.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(500 * time.Millisecond)))
+	record.SetSeverityNumber(plog.SeverityNumberError)
+	record.Body().SetStr("connection refused")
+	assert.Nil(t, database.InsertResourceLogs(t.Context(), rl))
+
+	spans, err := database.GetSpans(t.Context())
+	assert.Nil(t, err)
+
+	m := ui.NewSpanDetailPanelModel(database)
+	m.SetWidth(80)
+	m.SetHeight(40)
+	m, cmd := m.UpdateSpan(&spans[0])
+
+	m = runCmds(m, ui.SpanDetailPanelModel.Update, cmd)
+
+	view := m.View()
+
+	assert.Contains(t, view, "Logs (1)")
+	assert.Contains(t, view, "connection refused")
+	assert.Contains(t, view, "◆")
+
+	t.Run("keeps the bar's colors around the marker", func(t *testing.T) {
+		profile := lipgloss.ColorProfile()
+		lipgloss.SetColorProfile(termenv.TrueColor)
+		t.Cleanup(func() { lipgloss.SetColorProfile(profile) })
+
+		for _, line := range strings.Split(m.View(), "\n") {
+			if _, after, ok := strings.Cut(line, "◆\x1b[0m"); ok {
+				// The marker has the bar's background, and the bar goes on
+				assert.Regexp(t, `48;[0-9;]*m◆`, line)
+				assert.Regexp(t, `^\x1b\[[0-9;]*48;`, after)
+				return
+			}
+		}
+		t.Fatal("no marker")
+	})
+}
+
+func TestWaterfallMouse(t *testing.T) {
+	database, err := db.NewDB(":memory:")
+	assert.Nil(t, err)
+	defer database.Close()
+	assert.Nil(t, database.Migrate(t.Context()))
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	rs := ptrace.NewResourceSpans()
+	spans := rs.ScopeSpans().AppendEmpty().Spans()
+	root := spans.AppendEmpty()
+	root.SetTraceID(pcommon.TraceID{1})
+	root.SetSpanID(pcommon.SpanID{1})
+	root.SetName("GET /")
+	root.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	root.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Second)))
+	child := spans.AppendEmpty()
+	child.SetTraceID(pcommon.TraceID{1})
+	child.SetSpanID(pcommon.SpanID{2})
+	child.SetParentSpanID(pcommon.SpanID{1})
+	child.SetName("SELECT users")
+	child.SetStartTimestamp(pcommon.NewTimestampFromTime(start.Add(500 * time.Millisecond)))
+	child.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Second)))
+	assert.Nil(t, database.InsertResourceSpans(t.Context(), rs))
+
+	all, err := database.GetSpans(t.Context())
+	assert.Nil(t, err)
+	i := slices.IndexFunc(all, func(s db.Span) bool { return s.Name == "GET /" })
+
+	newPanel := func() ui.SpanDetailPanelModel {
+		m := ui.NewSpanDetailPanelModel(database)
+		m.SetWidth(80)
+		m.SetHeight(40)
+		m, cmd := m.UpdateSpan(&all[i])
+
+		return runCmds(m, ui.SpanDetailPanelModel.Update, cmd)
+	}
+	mouse := func(x, y int, action tea.MouseAction) tea.MouseMsg {
+		return tea.MouseMsg{X: x, Y: y, Action: action, Button: tea.MouseButtonLeft}
+	}
+
+	t.Run("clicking a bar selects its span", func(t *testing.T) {
+		m := newPanel()
+		assert.Contains(t, m.View(), "GET / • 1s")
+
+		m, _ = m.Update(mouse(70, 5, tea.MouseActionPress))
+		m, cmd := m.Update(mouse(70, 5, tea.MouseActionRelease))
+		m = runCmds(m, ui.SpanDetailPanelModel.Update, cmd)
+
+		assert.Contains(t, m.View(), "SELECT users • 500ms")
+	})
+
+	t.Run("dragging zooms into the time range", func(t *testing.T) {
+		m := newPanel()
+
+		m, _ = m.Update(mouse(40, 4, tea.MouseActionPress))
+		m, _ = m.Update(mouse(60, 4, tea.MouseActionMotion))
+		m, _ = m.Update(mouse(60, 4, tea.MouseActionRelease))
+		assert.Contains(t, m.View(), "zoomed to")
+		assert.Contains(t, m.View(), "GET / • 1s")
+
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+		assert.NotContains(t, m.View(), "zoomed to")
+	})
+}
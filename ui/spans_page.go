@@ -1,23 +1,78 @@
 package ui
 
 import (
+	"context"
+	"fmt"
+	"maps"
 	"math"
+	"os"
+	"slices"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/fredrikaugust/otelly/db"
 	"github.com/fredrikaugust/otelly/ui/helpers"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
 )
 
+const (
+	// defaultSplit gives two thirds of the page to the table
+	defaultSplit = 2.0 / 3.0
+	splitStep    = 0.05
+	minSplit     = 0.2
+	maxSplit     = 0.8
+	// narrowWidth is the width below which the panes are stacked rather
+	// than next to each other, as the detail panel is too narrow to read.
+	narrowWidth = 100
+)
+
+// paneSize is the size of a pane including its border. Hidden panes have a
+// size of zero.
+type paneSize struct {
+	width  int
+	height int
+}
+
 type SpansPageModel struct {
 	spans []db.Span
+	// rootless are the top spans of traces opened before their root span
+	// arrived, which are shown until it does
+	rootless []db.Span
 
 	width  int
 	height int
 
 	tableModel           TableModel
 	spanDetailPanelModel SpanDetailPanelModel
+
+	// marked are the IDs of the traces marked for comparison, in the order
+	// they were marked. The first one is the base of the diff.
+	marked []string
+	// showDiff is set when comparing the marked traces
+	showDiff       bool
+	traceDiffModel TraceDiffModel
+
+	// following keeps the newest trace selected as traces come in
+	following bool
+
+	// split is the share of the page given to the table, the rest goes to
+	// the detail panel.
+	split float64
+	// vertical stacks the table above the detail panel. It's flipped on
+	// narrow terminals, so toggling it always changes the layout.
+	vertical bool
+	// maximized shows only the focused pane
+	maximized bool
+	// focusDetail is set when keys go to the detail panel rather than the
+	// table.
+	focusDetail bool
+	// resizing is set while the border between the panes is dragged
+	resizing bool
+
+	db *db.Database
 }
 
 func NewSpansPageModel(spans []db.Span, db *db.Database) SpansPageModel {
@@ -31,6 +86,8 @@ func NewSpansPageModel(spans []db.Span, db *db.Database) SpansPageModel {
 		spans:                spans,
 		tableModel:           tm,
 		spanDetailPanelModel: NewSpanDetailPanelModel(db),
+		split:                defaultSplit,
+		db:                   db,
 	}
 }
 
@@ -46,13 +103,96 @@ func (m SpansPageModel) Update(msg tea.Msg) (SpansPageModel, tea.Cmd) {
 	var cmd tea.Cmd
 	cmds := make([]tea.Cmd, 0)
 
-	switch msg.(type) {
+	switch msg := msg.(type) {
 	case MsgSpanPageUpdateTable:
 		m.updateTable()
+	case MsgOpenTrace:
+		m.showDiff = false
+	case MsgToggleFollow:
+		m.toggleFollowing()
+	case tea.MouseMsg:
+		if m.showDiff || m.updateResize(msg) {
+			return m, nil
+		}
+
+		if table, _ := m.layout(); msg.Action == tea.MouseActionPress && mouseIn(msg, m.width, m.height) {
+			if m.stacked() {
+				m.setFocusDetail(msg.Y >= table.height)
+			} else {
+				m.setFocusDetail(msg.X >= table.width)
+			}
+		}
+	case tea.KeyMsg:
+		if m.showDiff {
+			if key.Matches(msg, keys.Back) {
+				m.showDiff = false
+				return m, nil
+			}
+
+			m.traceDiffModel, cmd = m.traceDiffModel.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case key.Matches(msg, keys.FocusPane):
+			m.setFocusDetail(!m.focusDetail)
+			return m, nil
+		case m.focusDetail && key.Matches(msg, keys.Back):
+			m.setFocusDetail(false)
+			return m, nil
+		case key.Matches(msg, keys.GrowPane):
+			m.growFocused(splitStep)
+			return m, nil
+		case key.Matches(msg, keys.ShrinkPane):
+			m.growFocused(-splitStep)
+			return m, nil
+		case key.Matches(msg, keys.RotateSplit):
+			m.vertical = !m.vertical
+			m.resizePanes()
+			return m, nil
+		case key.Matches(msg, keys.Maximize):
+			m.maximized = !m.maximized
+			m.resizePanes()
+			return m, nil
+		case key.Matches(msg, keys.Copy):
+			return m, m.copyID()
+		case key.Matches(msg, keys.CopyJSON):
+			return m, m.copyJSON()
+		case key.Matches(msg, keys.OpenInEditor):
+			if span := m.spanDetailPanelModel.selectedSpan(); span != nil {
+				if loc, ok := CodeLocationOf(span.Attributes, span.Events...); ok {
+					return m, openInEditor(loc)
+				}
+			}
+			return m, nil
+		case key.Matches(msg, keys.Follow):
+			m.toggleFollowing()
+		case key.Matches(msg, keys.MarkTrace):
+			m.toggleMarked()
+			return m, nil
+		case key.Matches(msg, keys.DiffTraces):
+			if len(m.marked) == 2 {
+				m.showDiff = true
+				m.traceDiffModel = NewTraceDiffModel(m.db, m.marked[0], m.marked[1])
+				m.traceDiffModel.SetWidth(m.width - 2)
+				m.traceDiffModel.SetHeight(m.height - 2)
+				return m, m.traceDiffModel.Init()
+			}
+			return m, nil
+		}
 	}
 
-	m.tableModel, cmd = m.tableModel.Update(msg)
-	cmds = append(cmds, cmd)
+	if m.showDiff {
+		m.traceDiffModel, cmd = m.traceDiffModel.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	// Keys go to the focused pane, so the table keeps its selection while
+	// the detail panel is scrolled
+	if _, isKeyMsg := msg.(tea.KeyMsg); !isKeyMsg || !m.focusDetail {
+		m.tableModel, cmd = m.tableModel.Update(translateMouse(msg, 1, 1))
+		cmds = append(cmds, cmd)
+	}
 
 	item, ok := m.tableModel.SelectedItem().(*spanTableItemDelegate)
 	if ok {
@@ -62,70 +202,467 @@ func (m SpansPageModel) Update(msg tea.Msg) (SpansPageModel, tea.Cmd) {
 	}
 	cmds = append(cmds, cmd)
 
-	m.spanDetailPanelModel, cmd = m.spanDetailPanelModel.Update(msg)
+	x, y := m.detailOffset()
+	m.spanDetailPanelModel, cmd = m.spanDetailPanelModel.Update(translateMouse(msg, x, y))
 	cmds = append(cmds, cmd)
 
 	return m, tea.Batch(cmds...)
 }
 
 func (m SpansPageModel) View() string {
-	return helpers.HStack(m.tableView(), m.detailView())
+	if m.showDiff {
+		return lipgloss.
+			NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(helpers.ColorBorder).
+			Render(m.traceDiffModel.View())
+	}
+
+	table, detail := m.layout()
+
+	panes := make([]string, 0, 2)
+	if table.width > 0 {
+		panes = append(panes, m.tableView())
+	}
+	if detail.width > 0 {
+		panes = append(panes, m.detailView())
+	}
+
+	if m.stacked() {
+		return helpers.VStack(panes...)
+	}
+
+	return helpers.HStack(panes...)
+}
+
+// paneBorderColor shows which pane has focus.
+func paneBorderColor(focused bool) lipgloss.TerminalColor {
+	if focused {
+		return helpers.ColorPrimary
+	}
+
+	return helpers.ColorBorder
 }
 
 func (m SpansPageModel) tableView() string {
 	container := lipgloss.
 		NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(helpers.ColorBorder).
+		BorderForeground(paneBorderColor(!m.focusDetail)).
 		BorderBackground(helpers.ColorBackground).
 		Background(helpers.ColorBackground)
 
 	return container.Render(m.tableModel.View())
 }
 
+func (m SpansPageModel) ShortHelp() []key.Binding {
+	if m.showDiff {
+		return append(m.traceDiffModel.ShortHelp(), keys.Back)
+	}
+
+	follow := keys.Follow
+	if m.following {
+		follow = withHelp(keys.Follow, "stop following")
+	}
+
+	focus := withHelp(keys.FocusPane, "focus detail")
+	if m.focusDetail {
+		focus = withHelp(keys.FocusPane, "focus table")
+	}
+
+	bindings := []key.Binding{follow, keys.MarkTrace}
+	if len(m.marked) == 2 {
+		bindings = append(bindings, keys.DiffTraces)
+	}
+	bindings = append(bindings, focus)
+
+	return append(bindings, m.spanDetailPanelModel.ShortHelp()...)
+}
+
+func (m SpansPageModel) FullHelp() [][]key.Binding {
+	if m.showDiff {
+		return append(m.traceDiffModel.FullHelp(), []key.Binding{keys.Back})
+	}
+
+	return [][]key.Binding{
+		navigationBindings(),
+		append([]key.Binding{keys.Follow, keys.MarkTrace, keys.DiffTraces}, m.spanDetailPanelModel.ShortHelp()...),
+		{keys.FocusPane, keys.GrowPane, keys.ShrinkPane, keys.RotateSplit, keys.Maximize},
+		{withHelp(keys.Copy, "copy trace/span ID"), withHelp(keys.CopyJSON, "copy trace/span as JSON")},
+	}
+}
+
+// Commands are the actions on the traces, and every trace to open.
+func (m SpansPageModel) Commands() []Command {
+	follow := "Follow new traces"
+	if m.following {
+		follow = "Stop following new traces"
+	}
+
+	commands := []Command{{"Action", follow, helpers.Cmdize(MsgToggleFollow{})}}
+
+	if item, ok := m.tableModel.SelectedItem().(*spanTableItemDelegate); ok {
+		commands = append(commands,
+			Command{"Action", "Export selected trace as OTLP JSON", m.exportTrace(item.span.TraceID)},
+			Command{"Copy", "Copy trace ID", copyText("trace ID", item.span.TraceID)},
+			Command{"Copy", "Copy trace as OTLP JSON", m.copyTrace(item.span.TraceID)},
+		)
+	}
+
+	if span := m.spanDetailPanelModel.selectedSpan(); span != nil {
+		commands = append(commands,
+			Command{"Copy", "Copy span ID", copyText("span ID", span.ID)},
+			Command{"Copy", "Copy span as JSON", copySpan(*span)},
+		)
+		if loc, ok := CodeLocationOf(span.Attributes, span.Events...); ok {
+			commands = append(commands, Command{"Action", "Open " + loc.String() + " in editor", openInEditor(loc)})
+		}
+		for _, k := range slices.Sorted(maps.Keys(span.Attributes)) {
+			commands = append(commands, Command{"Copy", "Copy attribute " + k, copyText(k, clipboardText(span.Attributes[k]))})
+		}
+	}
+
+	for _, span := range m.spans {
+		commands = append(commands, Command{"Trace", span.Name + " " + span.TraceID, helpers.Cmdize(MsgOpenTrace{traceID: span.TraceID})})
+	}
+
+	return commands
+}
+
+func (m *SpansPageModel) toggleFollowing() {
+	m.following = !m.following
+	if m.following {
+		m.tableModel.SetCursorRow(0)
+	}
+}
+
+// copyID copies the ID of the selected trace, or of the span in the detail
+// panel when it has focus.
+func (m SpansPageModel) copyID() tea.Cmd {
+	if m.focusDetail {
+		if span := m.spanDetailPanelModel.selectedSpan(); span != nil {
+			return copyText("span ID", span.ID)
+		}
+		return nil
+	}
+
+	if item, ok := m.tableModel.SelectedItem().(*spanTableItemDelegate); ok {
+		return copyText("trace ID", item.span.TraceID)
+	}
+
+	return nil
+}
+
+// copyJSON copies the selected trace, or the span in the detail panel when
+// it has focus, as OTLP JSON.
+func (m SpansPageModel) copyJSON() tea.Cmd {
+	if m.focusDetail {
+		if span := m.spanDetailPanelModel.selectedSpan(); span != nil {
+			return copySpan(*span)
+		}
+		return nil
+	}
+
+	if item, ok := m.tableModel.SelectedItem().(*spanTableItemDelegate); ok {
+		return m.copyTrace(item.span.TraceID)
+	}
+
+	return nil
+}
+
+func (m SpansPageModel) copyTrace(traceID string) tea.Cmd {
+	return func() tea.Msg {
+		b, err := m.traceJSON(traceID)
+		if err != nil {
+			return MsgStatus{fmt.Sprintf("Could not copy trace: %v", err)}
+		}
+
+		return copyText("trace", string(b))()
+	}
+}
+
+// copySpan copies the span on its own as OTLP JSON.
+func copySpan(span db.Span) tea.Cmd {
+	return func() tea.Msg {
+		b, err := (&ptrace.JSONMarshaler{}).MarshalTraces(db.SpansToTraces([]db.Span{span}))
+		if err != nil {
+			zap.L().Warn("could not marshal span", zap.String("spanID", span.ID), zap.Error(err))
+			return MsgStatus{fmt.Sprintf("Could not copy span: %v", err)}
+		}
+
+		return copyText("span", string(b))()
+	}
+}
+
+// traceJSON is the trace in the OTLP/HTTP JSON encoding.
+func (m SpansPageModel) traceJSON(traceID string) ([]byte, error) {
+	spans, err := m.db.GetSpansForTrace(context.Background(), traceID)
+	if err != nil {
+		zap.L().Warn("could not get spans for trace", zap.String("traceID", traceID), zap.Error(err))
+		return nil, err
+	}
+
+	b, err := (&ptrace.JSONMarshaler{}).MarshalTraces(db.SpansToTraces(spans))
+	if err != nil {
+		zap.L().Warn("could not marshal trace", zap.String("traceID", traceID), zap.Error(err))
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// exportTrace writes the trace to trace-<id>.json in the working directory,
+// in the format of the OTLP/HTTP JSON encoding so it can be sent to a
+// collector or loaded into other tools.
+func (m SpansPageModel) exportTrace(traceID string) tea.Cmd {
+	return func() tea.Msg {
+		b, err := m.traceJSON(traceID)
+		if err != nil {
+			return MsgStatus{fmt.Sprintf("Could not export trace: %v", err)}
+		}
+
+		path := fmt.Sprintf("trace-%s.json", traceID)
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			zap.L().Warn("could not write trace", zap.String("path", path), zap.Error(err))
+			return MsgStatus{fmt.Sprintf("Could not export trace: %v", err)}
+		}
+
+		return MsgStatus{"Exported trace to " + path}
+	}
+}
+
+// toggleMarked marks or unmarks the selected trace for comparison. Marking
+// a third trace replaces the oldest mark.
+func (m *SpansPageModel) toggleMarked() {
+	item, ok := m.tableModel.SelectedItem().(*spanTableItemDelegate)
+	if !ok {
+		return
+	}
+
+	if i := slices.Index(m.marked, item.span.TraceID); i != -1 {
+		m.marked = slices.Delete(m.marked, i, i+1)
+	} else {
+		m.marked = append(m.marked, item.span.TraceID)
+		if len(m.marked) > 2 {
+			m.marked = m.marked[1:]
+		}
+	}
+
+	m.updateTable()
+}
+
 func (m SpansPageModel) detailView() string {
 	container := lipgloss.
 		NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(helpers.ColorBorder)
+		BorderForeground(paneBorderColor(m.focusDetail))
 
 	return container.Render(m.spanDetailPanelModel.View())
 }
 
+// SelectTrace moves the cursor to the root span of the trace. The spans of
+// the trace which have arrived are used when its root span hasn't, as it
+// may never arrive, e.g. when it's from a service which doesn't send here,
+// and a row is added for the top of them. It returns false if there's
+// neither.
+func (m *SpansPageModel) SelectTrace(traceID string, spans []db.Span) bool {
+	if m.selectRow(traceID) {
+		return true
+	}
+	if len(spans) == 0 {
+		return false
+	}
+
+	m.rootless = append(m.rootless, topSpan(spans))
+	m.updateTable()
+
+	return m.selectRow(traceID)
+}
+
+func (m *SpansPageModel) selectRow(traceID string) bool {
+	// The table may be sorted differently than the spans
+	for i, item := range m.tableModel.items {
+		if d, ok := item.(*spanTableItemDelegate); ok && d.span.TraceID == traceID {
+			m.tableModel.SetCursorRow(i)
+			return true
+		}
+	}
+
+	return false
+}
+
+// topSpan is the earliest of the spans whose parent isn't among them.
+func topSpan(spans []db.Span) db.Span {
+	ids := make(map[string]bool, len(spans))
+	for _, span := range spans {
+		ids[span.ID] = true
+	}
+
+	top := spans[0]
+	for _, span := range spans {
+		if !ids[span.ParentSpanID.String] && (ids[top.ParentSpanID.String] || span.StartTime.Before(top.StartTime)) {
+			top = span
+		}
+	}
+
+	return top
+}
+
 func (m *SpansPageModel) SetSpans(spans []db.Span) {
 	m.spans = spans
 	m.updateTable()
+
+	if m.following {
+		m.tableModel.SetCursorRow(0)
+	}
 }
 
 type spanTableItemDelegate struct {
 	span *db.Span
+	// mark is shown in front of the name of traces marked for comparison
+	mark string
 }
 
 func (d spanTableItemDelegate) Content() []string {
 	return []string{
-		d.span.Name,
+		d.mark + d.span.Name,
 		d.span.StartTime.Format("15:04:05"),
 		d.span.Duration.Round(time.Microsecond).String(),
 	}
 }
 
 func (m *SpansPageModel) updateTable() {
-	items := make([]TableItemDelegate, len(m.spans))
-	for i, span := range m.spans {
+	// The root-less traces are dropped once their root span arrives
+	m.rootless = slices.DeleteFunc(m.rootless, func(rootless db.Span) bool {
+		return slices.ContainsFunc(m.spans, func(span db.Span) bool { return span.TraceID == rootless.TraceID })
+	})
+
+	// Newest first, like the spans
+	spans := slices.Concat(m.spans, m.rootless)
+	slices.SortStableFunc(spans, func(a, b db.Span) int { return b.StartTime.Compare(a.StartTime) })
+
+	items := make([]TableItemDelegate, 0, len(spans))
+	for _, span := range spans {
 		d := &spanTableItemDelegate{span: &span}
-		items[i] = d
+		switch slices.Index(m.marked, span.TraceID) {
+		case 0:
+			d.mark = "[base] "
+		case 1:
+			d.mark = "[target] "
+		}
+		items = append(items, d)
 	}
 	m.tableModel.SetItems(items)
 }
 
 func (m *SpansPageModel) SetWidth(w int) {
 	m.width = w
-	m.tableModel.SetWidth(int(math.Floor(float64(w)*2.0/3.0)) - 2)
-	m.spanDetailPanelModel.SetWidth(int(math.Ceil(float64(w)*(1.0/3.0))) - 2)
+	m.traceDiffModel.SetWidth(w - 2)
+	m.resizePanes()
 }
 
 func (m *SpansPageModel) SetHeight(h int) {
 	m.height = h
-	m.tableModel.SetHeight(h - 2)
-	m.spanDetailPanelModel.SetHeight(h - 2)
+	m.traceDiffModel.SetHeight(h - 2)
+	m.resizePanes()
+}
+
+// stacked is true when the table is above the detail panel rather than
+// next to it.
+func (m SpansPageModel) stacked() bool {
+	return m.vertical != (m.width < narrowWidth)
+}
+
+// layout returns the sizes of the table and the detail panel.
+func (m SpansPageModel) layout() (table, detail paneSize) {
+	switch {
+	case m.maximized && m.focusDetail:
+		return paneSize{}, paneSize{m.width, m.height}
+	case m.maximized:
+		return paneSize{m.width, m.height}, paneSize{}
+	case m.stacked():
+		h := int(math.Round(float64(m.height) * m.split))
+		return paneSize{m.width, h}, paneSize{m.width, m.height - h}
+	default:
+		w := int(math.Round(float64(m.width) * m.split))
+		return paneSize{w, m.height}, paneSize{m.width - w, m.height}
+	}
+}
+
+// detailOffset is where the inside of the detail panel is on the page.
+func (m SpansPageModel) detailOffset() (int, int) {
+	table, _ := m.layout()
+	if m.stacked() {
+		return 1, table.height + 1
+	}
+
+	return table.width + 1, 1
+}
+
+// resizePanes sizes the table and the detail panel to fit the layout,
+// inside their borders.
+func (m *SpansPageModel) resizePanes() {
+	// Hidden panes keep their size until they're shown again
+	table, detail := m.layout()
+	if table.width > 0 {
+		m.tableModel.SetWidth(max(0, table.width-2))
+		m.tableModel.SetHeight(max(0, table.height-2))
+		// Keep the selected row in view of the resized table
+		m.tableModel.SetCursorRow(m.tableModel.cursorRow)
+	}
+	if detail.width > 0 {
+		m.spanDetailPanelModel.SetWidth(max(0, detail.width-2))
+		m.spanDetailPanelModel.SetHeight(max(0, detail.height-2))
+	}
+}
+
+func (m *SpansPageModel) setFocusDetail(focusDetail bool) {
+	m.focusDetail = focusDetail
+	m.spanDetailPanelModel.SetFocused(focusDetail)
+	// The maximized pane follows the focus
+	m.resizePanes()
+}
+
+func (m *SpansPageModel) setSplit(split float64) {
+	m.split = min(maxSplit, max(minSplit, split))
+	m.resizePanes()
+}
+
+// growFocused makes the focused pane bigger, or smaller when negative.
+func (m *SpansPageModel) growFocused(by float64) {
+	if m.focusDetail {
+		by = -by
+	}
+
+	m.setSplit(m.split + by)
+}
+
+// updateResize resizes the panes by dragging the border between them. It
+// returns true when the mouse event was used for that.
+func (m *SpansPageModel) updateResize(msg tea.MouseMsg) bool {
+	stacked := m.stacked()
+
+	switch {
+	case m.resizing && msg.Action == tea.MouseActionMotion:
+		if stacked {
+			m.setSplit(float64(msg.Y+1) / float64(m.height))
+		} else {
+			m.setSplit(float64(msg.X+1) / float64(m.width))
+		}
+		return true
+	case m.resizing && msg.Action == tea.MouseActionRelease:
+		m.resizing = false
+		return true
+	case m.maximized || msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft || !mouseIn(msg, m.width, m.height):
+		return false
+	}
+
+	// Either of the borders where the panes meet
+	table, _ := m.layout()
+	if stacked && (msg.Y == table.height-1 || msg.Y == table.height) || !stacked && (msg.X == table.width-1 || msg.X == table.width) {
+		m.resizing = true
+		return true
+	}
+
+	return false
 }
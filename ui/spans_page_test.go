@@ -0,0 +1,148 @@
+package ui_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestSpansPagePanes(t *testing.T) {
+	database, err := db.NewDB(":memory:")
+	assert.Nil(t, err)
+	defer database.Close()
+	assert.Nil(t, database.Migrate(t.Context()))
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	rs := ptrace.NewResourceSpans()
+	for i, name := range []string{"GET /older", "GET /newer"} {
+		span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetTraceID(pcommon.TraceID{byte(i + 1)})
+		span.SetSpanID(pcommon.SpanID{byte(i + 1)})
+		span.SetName(name)
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Duration(i) * time.Second)))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Duration(i)*time.Second + time.Millisecond)))
+	}
+	assert.Nil(t, database.InsertResourceSpans(t.Context(), rs))
+
+	spans, err := database.GetSpans(t.Context())
+	assert.Nil(t, err)
+
+	newPage := func(width int) ui.SpansPageModel {
+		m := ui.NewSpansPageModel(spans, database)
+		m.SetWidth(width)
+		m.SetHeight(16)
+
+		return runCmds(m, ui.SpansPageModel.Update, m.Init())
+	}
+	update := func(m ui.SpansPageModel, msgs ...tea.Msg) ui.SpansPageModel {
+		for _, msg := range msgs {
+			var cmd tea.Cmd
+			m, cmd = m.Update(msg)
+			m = runCmds(m, ui.SpansPageModel.Update, cmd)
+		}
+
+		return m
+	}
+	// lineOf returns the line of the view with the text, or -1
+	lineOf := func(m ui.SpansPageModel, text string) int {
+		for i, line := range strings.Split(m.View(), "\n") {
+			if strings.Contains(line, text) {
+				return i
+			}
+		}
+
+		return -1
+	}
+
+	t.Run("scroll keys go to the focused pane", func(t *testing.T) {
+		m := newPage(120)
+		assert.Contains(t, m.View(), "Span 0")
+
+		m = update(m, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyDown})
+		assert.NotContains(t, m.View(), "Span 0")
+		assert.Contains(t, m.View(), "1 / 2")
+
+		m = update(m, tea.KeyMsg{Type: tea.KeyEsc}, tea.KeyMsg{Type: tea.KeyDown})
+		assert.Contains(t, m.View(), "2 / 2")
+	})
+
+	t.Run("maximizes the focused pane", func(t *testing.T) {
+		m := update(newPage(120), tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'z'}})
+		assert.Contains(t, m.View(), "1 / 2")
+		assert.NotContains(t, m.View(), "Span 0")
+
+		m = update(m, tea.KeyMsg{Type: tea.KeyTab})
+		assert.NotContains(t, m.View(), "1 / 2")
+		assert.Contains(t, m.View(), "Span 0")
+	})
+
+	t.Run("stacks the panes when rotated or narrow", func(t *testing.T) {
+		m := newPage(120)
+		assert.Less(t, lineOf(m, "Span 0"), lineOf(m, "1 / 2"))
+
+		m = update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'|'}})
+		assert.Greater(t, lineOf(m, "Span 0"), lineOf(m, "1 / 2"))
+
+		m = newPage(80)
+		assert.Greater(t, lineOf(m, "Span 0"), lineOf(m, "1 / 2"))
+	})
+
+	t.Run("resizes by keys and by dragging the border", func(t *testing.T) {
+		detailX := func(m ui.SpansPageModel) int {
+			line := strings.Split(m.View(), "\n")[lineOf(m, "Span 0")]
+			return lipgloss.Width(line[:strings.Index(line, "Span 0")])
+		}
+
+		m := newPage(120)
+		assert.Equal(t, 81, detailX(m))
+
+		m = update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'-'}})
+		assert.Equal(t, 75, detailX(m))
+
+		m = update(
+			m,
+			tea.MouseMsg{X: 74, Y: 5, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft},
+			tea.MouseMsg{X: 59, Y: 5, Action: tea.MouseActionMotion, Button: tea.MouseButtonLeft},
+			tea.MouseMsg{X: 59, Y: 5, Action: tea.MouseActionRelease, Button: tea.MouseButtonLeft},
+		)
+		assert.Equal(t, 61, detailX(m))
+	})
+
+	t.Run("copies the ID of the focused pane", func(t *testing.T) {
+		var out bytes.Buffer
+		ui.SetClipboard(ui.Clipboard{Out: &out, Terminal: true})
+		t.Cleanup(func() { ui.SetClipboard(ui.DefaultClipboard()) })
+
+		// copied runs the command, which copies without suspending the
+		// program, and returns the text copied through the terminal
+		copied := func(cmd tea.Cmd) string {
+			assert.IsType(t, ui.MsgStatus{}, cmd())
+
+			payload := strings.TrimSuffix(strings.TrimPrefix(out.String(), "\x1b]52;c;"), "\x07")
+			b, err := base64.StdEncoding.DecodeString(payload)
+			assert.Nil(t, err)
+			out.Reset()
+
+			return string(b)
+		}
+
+		m := newPage(120)
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+		assert.Equal(t, pcommon.TraceID{2}.String(), copied(cmd))
+
+		m = update(m, tea.KeyMsg{Type: tea.KeyTab})
+		_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'Y'}})
+		assert.Contains(t, copied(cmd), `"name":"GET /newer"`)
+	})
+}
@@ -1,9 +1,14 @@
 package ui
 
 import (
+	"cmp"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/fredrikaugust/otelly/ui/helpers"
@@ -38,6 +43,18 @@ type TableModel struct {
 	columnDefinitions []ColumnDefinition
 	rowHeight         int
 
+	// unsortedItems are the items in the order they were set, and order
+	// has the index into them of each sorted item.
+	unsortedItems []TableItemDelegate
+	order         []int
+	// sortColumn is the column the items are sorted by, or -1 to keep the
+	// order they were set in. Clicking a column header sorts by it.
+	sortColumn int
+	sortDesc   bool
+	// unsortable tables ignore clicks on the header, e.g. when the order
+	// of the rows has a meaning of its own.
+	unsortable bool
+
 	cursorRow    int
 	cursorColumn int
 
@@ -56,6 +73,7 @@ func NewTableModel() TableModel {
 		itemViews:         make([][]string, 0),
 		columnDefinitions: make([]ColumnDefinition, 0),
 		rowHeight:         1,
+		sortColumn:        -1,
 	}
 }
 
@@ -67,21 +85,33 @@ func (m TableModel) Init() tea.Cmd {
 	return nil
 }
 
+// SetSortable sets whether the table can be sorted by clicking a column
+// header.
+func (m *TableModel) SetSortable(sortable bool) {
+	m.unsortable = !sortable
+}
+
 func (m TableModel) Update(msg tea.Msg) (TableModel, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		m.updateMouse(msg)
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "j", "down":
+		switch {
+		case key.Matches(msg, keys.Down):
 			m.cursorRow += 1
-		case "k", "up":
+		case key.Matches(msg, keys.Up):
 			m.cursorRow -= 1
-		case "l", "right":
+		case key.Matches(msg, keys.PageDown):
+			m.cursorRow += max(1, m.contentHeight()/m.rowHeight)
+		case key.Matches(msg, keys.PageUp):
+			m.cursorRow -= max(1, m.contentHeight()/m.rowHeight)
+		case key.Matches(msg, keys.Right):
 			m.cursorColumn += 1
-		case "h", "left":
+		case key.Matches(msg, keys.Left):
 			m.cursorColumn -= 1
-		case "g":
+		case key.Matches(msg, keys.Top):
 			m.cursorRow = 0
-		case "G":
+		case key.Matches(msg, keys.Bottom):
 			m.cursorRow = len(m.itemViews) - 1
 		}
 
@@ -98,6 +128,134 @@ func (m TableModel) Update(msg tea.Msg) (TableModel, tea.Cmd) {
 	return m, nil
 }
 
+// updateMouse handles mouse events relative to the top left of the table.
+func (m *TableModel) updateMouse(msg tea.MouseMsg) {
+	if !mouseIn(msg, m.width, m.height) {
+		return
+	}
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelDown:
+		m.cursorRow += 1
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.cursorRow -= 1
+	case msg.Button != tea.MouseButtonLeft || msg.Action != tea.MouseActionPress:
+		return
+	case msg.Y == 0:
+		if column := m.columnAt(msg.X); column >= 0 && !m.unsortable {
+			m.toggleSort(column)
+		}
+		return
+	case msg.Y <= m.contentHeight():
+		row := (msg.Y - 1 + m.yOffset) / m.rowHeight
+		if row >= len(m.items) {
+			return
+		}
+
+		m.cursorRow = row
+		if column := m.columnAt(msg.X); column >= 0 {
+			m.cursorColumn = column
+		}
+	}
+
+	m.cursorRow = max(0, helpers.Clamp(0, m.cursorRow, len(m.items)-1))
+}
+
+// columnAt returns the column at x, or -1 if there's none.
+func (m TableModel) columnAt(x int) int {
+	right := 0
+	for i, w := range m.ColumnWidths() {
+		right += w
+		if x < right {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// toggleSort sorts by the column, first ascending, then descending and
+// then back to the order the items were set in, keeping the same item
+// selected.
+func (m *TableModel) toggleSort(column int) {
+	switch {
+	case m.sortColumn != column:
+		m.sortColumn = column
+		m.sortDesc = false
+	case !m.sortDesc:
+		m.sortDesc = true
+	default:
+		m.sortColumn = -1
+	}
+
+	selected := -1
+	if m.cursorRow < len(m.order) {
+		selected = m.order[m.cursorRow]
+	}
+
+	m.sortItems()
+
+	if i := slices.Index(m.order, selected); i >= 0 {
+		m.cursorRow = i
+	}
+}
+
+// sortItems orders the items by the sort column.
+func (m *TableModel) sortItems() {
+	views := make([][]string, len(m.unsortedItems))
+	m.order = make([]int, len(m.unsortedItems))
+	for i, item := range m.unsortedItems {
+		views[i] = item.Content()
+		m.order[i] = i
+	}
+
+	if m.sortColumn >= 0 {
+		slices.SortStableFunc(m.order, func(a, b int) int {
+			c := compareCells(cell(views[a], m.sortColumn), cell(views[b], m.sortColumn))
+			if m.sortDesc {
+				return -c
+			}
+
+			return c
+		})
+	}
+
+	m.items = make([]TableItemDelegate, len(m.order))
+	m.itemViews = make([][]string, len(m.order))
+	for i, j := range m.order {
+		m.items[i] = m.unsortedItems[j]
+		m.itemViews[i] = views[j]
+	}
+}
+
+func cell(row []string, column int) string {
+	if column >= len(row) {
+		return ""
+	}
+
+	return row[column]
+}
+
+var leadingNumber = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?`)
+
+// compareCells compares durations and numbers by value, so 900µs comes
+// before 1.5ms and 9 before 10, and everything else as text.
+func compareCells(a, b string) int {
+	da, errA := time.ParseDuration(a)
+	db, errB := time.ParseDuration(b)
+	if errA == nil && errB == nil {
+		return cmp.Compare(da, db)
+	}
+
+	na, errA := strconv.ParseFloat(leadingNumber.FindString(a), 64)
+	nb, errB := strconv.ParseFloat(leadingNumber.FindString(b), 64)
+	if errA == nil && errB == nil && na != nb {
+		return cmp.Compare(na, nb)
+	}
+
+	return cmp.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
 // updateYOffset calculates and sets the yOffset which is how far up/down the viewport is scrolled.
 func (m *TableModel) updateYOffset() {
 	selectedItemYOffset := m.cursorRow * m.rowHeight
@@ -127,9 +285,9 @@ func (m TableModel) View() string {
 		for j, col := range cols {
 			style := lipgloss.NewStyle().Width(colWidths[j]).MaxWidth(colWidths[j]).Height(m.rowHeight).MaxHeight(m.rowHeight)
 			if m.cursorRow == i && m.cursorColumn == j {
-				style = style.Background(helpers.ColorSecondary).Foreground(helpers.ColorSecondaryForeground)
+				style = helpers.Highlight(style, helpers.ColorSecondary, helpers.ColorSecondaryForeground)
 			} else if m.cursorRow == i {
-				style = style.Background(helpers.ColorPrimary).Foreground(helpers.ColorPrimaryForeground)
+				style = helpers.Highlight(style, helpers.ColorPrimary, helpers.ColorPrimaryForeground)
 			}
 			row += style.Render(col)
 		}
@@ -180,8 +338,15 @@ func (m TableModel) HeaderView() string {
 	var view strings.Builder
 
 	for i, col := range m.columnDefinitions {
+		title := col.Title
+		if i == m.sortColumn && m.sortDesc {
+			title += " ▼"
+		} else if i == m.sortColumn {
+			title += " ▲"
+		}
+
 		view.WriteString(
-			lipgloss.NewStyle().Width(colWidths[i]).Bold(true).Background(helpers.ColorBackground).Foreground(helpers.ColorForeground).Render(col.Title),
+			lipgloss.NewStyle().Width(colWidths[i]).MaxWidth(colWidths[i]).MaxHeight(1).Bold(true).Background(helpers.ColorBackground).Foreground(helpers.ColorForeground).Render(title),
 		)
 	}
 
@@ -189,12 +354,8 @@ func (m TableModel) HeaderView() string {
 }
 
 func (m *TableModel) SetItems(items []TableItemDelegate) {
-	m.items = items
-	m.itemViews = make([][]string, len(items))
-
-	for i, item := range items {
-		m.itemViews[i] = item.Content()
-	}
+	m.unsortedItems = items
+	m.sortItems()
 }
 
 func (m *TableModel) SetWidth(i int) {
@@ -212,3 +373,9 @@ func (m *TableModel) SelectedItem() TableItemDelegate {
 
 	return nil
 }
+
+// SetCursorRow moves the cursor to the given row, scrolling it into view.
+func (m *TableModel) SetCursorRow(i int) {
+	m.cursorRow = max(0, helpers.Clamp(0, i, len(m.items)-1))
+	m.updateYOffset()
+}
@@ -166,6 +166,11 @@ func TestTable_Navigation(t *testing.T) {
 			{tea.KeyMsg{Type: tea.KeyUp}, []string{"string1", "string2"}, []string{"string3", "string4"}},
 			{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'G'}}, []string{"string3", "string4"}, []string{"string1", "string2"}},
 			{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}}, []string{"string1", "string2"}, []string{"string3", "string4"}},
+			{tea.KeyMsg{Type: tea.KeyEnd}, []string{"string3", "string4"}, []string{"string1", "string2"}},
+			{tea.KeyMsg{Type: tea.KeyHome}, []string{"string1", "string2"}, []string{"string3", "string4"}},
+			{tea.KeyMsg{Type: tea.KeyPgDown}, []string{"string2", "string3"}, []string{"string1", "string4"}},
+			{tea.KeyMsg{Type: tea.KeyPgDown}, []string{"string3", "string4"}, []string{"string1", "string2"}},
+			{tea.KeyMsg{Type: tea.KeyPgUp}, []string{"string2", "string3"}, []string{"string1", "string4"}},
 		}
 
 		var view string
@@ -189,6 +194,38 @@ func TestTable_Navigation(t *testing.T) {
 	})
 }
 
+func TestTable_SetCursorRow(t *testing.T) {
+	table := ui.NewTableModel()
+	table.SetHeight(4) // means 2 rows for content
+	table.SetWidth(20)
+	table.SetRowHeight(1)
+	table.SetColumnDefinitions([]ui.ColumnDefinition{{1, "title"}})
+
+	items := make([]ui.TableItemDelegate, 4)
+	for i := range items {
+		d := ui.NewDefaultTableItemDelegate()
+		d.ContentFn = func() []string { return []string{fmt.Sprintf("string%d", i+1)} }
+		items[i] = d
+	}
+	table.SetItems(items)
+
+	t.Run("scrolls the row into view", func(t *testing.T) {
+		table.SetCursorRow(3)
+
+		assert.Equal(t, items[3].Content(), table.SelectedItem().Content())
+		assert.Contains(t, table.View(), "string4")
+		assert.NotContains(t, table.View(), "string1")
+	})
+
+	t.Run("clamps to the items", func(t *testing.T) {
+		table.SetCursorRow(10)
+		assert.Equal(t, items[3].Content(), table.SelectedItem().Content())
+
+		table.SetCursorRow(-1)
+		assert.Equal(t, items[0].Content(), table.SelectedItem().Content())
+	})
+}
+
 func TestTable_ColumnWidths(t *testing.T) {
 	for _, tc := range []struct {
 		w        int
@@ -238,3 +275,89 @@ func TestTable_SelectedItem(t *testing.T) {
 		assert.Nil(t, table.SelectedItem())
 	})
 }
+
+func TestTable_Mouse(t *testing.T) {
+	newTable := func(rows ...[]string) ui.TableModel {
+		table := ui.NewTableModel()
+		table.SetHeight(10)
+		table.SetWidth(20)
+		table.SetColumnDefinitions([]ui.ColumnDefinition{{1, "Name"}, {1, "Duration"}})
+
+		items := make([]ui.TableItemDelegate, len(rows))
+		for i, row := range rows {
+			d := ui.NewDefaultTableItemDelegate()
+			d.ContentFn = func() []string { return row }
+			items[i] = d
+		}
+		table.SetItems(items)
+
+		return table
+	}
+	click := func(x, y int) tea.MouseMsg {
+		return tea.MouseMsg{X: x, Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+	}
+
+	t.Run("click selects the row", func(t *testing.T) {
+		table := newTable([]string{"a", "1ms"}, []string{"b", "2ms"}, []string{"c", "3ms"})
+
+		table, _ = table.Update(click(2, 3))
+		assert.Equal(t, "c", table.SelectedItem().Content()[0])
+
+		// Below the last row
+		table, _ = table.Update(click(2, 6))
+		assert.Equal(t, "c", table.SelectedItem().Content()[0])
+	})
+
+	t.Run("wheel moves the cursor", func(t *testing.T) {
+		table := newTable([]string{"a", "1ms"}, []string{"b", "2ms"})
+
+		table, _ = table.Update(tea.MouseMsg{X: 2, Y: 2, Button: tea.MouseButtonWheelDown})
+		assert.Equal(t, "b", table.SelectedItem().Content()[0])
+		table, _ = table.Update(tea.MouseMsg{X: 2, Y: 2, Button: tea.MouseButtonWheelDown})
+		assert.Equal(t, "b", table.SelectedItem().Content()[0])
+		table, _ = table.Update(tea.MouseMsg{X: 2, Y: 2, Button: tea.MouseButtonWheelUp})
+		assert.Equal(t, "a", table.SelectedItem().Content()[0])
+	})
+
+	t.Run("clicking a header sorts ascending, descending and then not at all", func(t *testing.T) {
+		table := newTable([]string{"a", "1.5ms"}, []string{"b", "900µs"}, []string{"c", "2s"})
+		names := func() string {
+			rows := regexp.MustCompile(`(?m)^([abc]) `).FindAllStringSubmatch(table.View(), -1)
+			names := ""
+			for _, row := range rows {
+				names += row[1]
+			}
+			return names
+		}
+
+		table, _ = table.Update(click(12, 0))
+		assert.Equal(t, "bac", names())
+		assert.Contains(t, table.View(), "Duration ▲")
+
+		table, _ = table.Update(click(12, 0))
+		assert.Equal(t, "cab", names())
+		assert.Contains(t, table.View(), "Duration ▼")
+
+		table, _ = table.Update(click(12, 0))
+		assert.Equal(t, "abc", names())
+		assert.NotContains(t, table.View(), "▲")
+	})
+
+	t.Run("sorting keeps the selected item", func(t *testing.T) {
+		table := newTable([]string{"b", "1ms"}, []string{"a", "2ms"})
+		table, _ = table.Update(click(2, 2))
+
+		table, _ = table.Update(click(2, 0))
+		assert.Equal(t, "a", table.SelectedItem().Content()[0])
+		assert.Contains(t, table.View(), "1 / 2")
+	})
+
+	t.Run("unsortable tables ignore the header", func(t *testing.T) {
+		table := newTable([]string{"b", "1ms"}, []string{"a", "2ms"})
+		table.SetSortable(false)
+
+		table, _ = table.Update(click(2, 0))
+		assert.NotContains(t, table.View(), "▲")
+		assert.Equal(t, "b", table.SelectedItem().Content()[0])
+	})
+}
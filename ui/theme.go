@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"github.com/muesli/termenv"
+	"gopkg.in/yaml.v3"
+)
+
+type themeFile struct {
+	// Name is shown when changing theme, and defaults to "custom"
+	Name string `yaml:"name"`
+	// Base is the built-in theme to start from
+	Base   string                `yaml:"base"`
+	Colors map[string]themeColor `yaml:"colors"`
+}
+
+// themeColor is either a single color, used on every terminal and
+// degraded to the closest color where needed, or a color for each color
+// profile.
+type themeColor struct {
+	color lipgloss.TerminalColor
+}
+
+func (c *themeColor) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		var complete struct {
+			TrueColor string `yaml:"truecolor"`
+			ANSI256   string `yaml:"ansi256"`
+			ANSI      string `yaml:"ansi"`
+		}
+		if err := node.Decode(&complete); err != nil {
+			return err
+		}
+
+		for _, s := range []string{complete.TrueColor, complete.ANSI256, complete.ANSI} {
+			if !validColor(s) {
+				return fmt.Errorf("line %d: invalid color %q", node.Line, s)
+			}
+		}
+
+		c.color = lipgloss.CompleteColor(complete)
+		return nil
+	}
+
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+
+	switch {
+	case s == "none":
+		// Uses the terminal's own color
+		c.color = lipgloss.NoColor{}
+	case validColor(s):
+		c.color = lipgloss.Color(s)
+	default:
+		return fmt.Errorf("line %d: invalid color %q", node.Line, s)
+	}
+
+	return nil
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// validColor is true for hex colors and ANSI color numbers.
+func validColor(s string) bool {
+	if hexColor.MatchString(s) {
+		return true
+	}
+
+	n, err := strconv.Atoi(s)
+
+	return err == nil && n >= 0 && n <= 255
+}
+
+// themeColors has the colors of the theme by the names used in the theme
+// file.
+func themeColors(t *helpers.Theme) map[string]*lipgloss.TerminalColor {
+	return map[string]*lipgloss.TerminalColor{
+		"primary":                &t.Primary,
+		"primary_foreground":     &t.PrimaryForeground,
+		"secondary":              &t.Secondary,
+		"secondary_foreground":   &t.SecondaryForeground,
+		"accent":                 &t.Accent,
+		"accent_foreground":      &t.AccentForeground,
+		"background":             &t.Background,
+		"foreground":             &t.Foreground,
+		"muted":                  &t.Muted,
+		"muted_foreground":       &t.MutedForeground,
+		"card":                   &t.Card,
+		"card_foreground":        &t.CardForeground,
+		"popover":                &t.Popover,
+		"popover_foreground":     &t.PopoverForeground,
+		"border":                 &t.Border,
+		"input":                  &t.Input,
+		"ring":                   &t.Ring,
+		"destructive":            &t.Destructive,
+		"destructive_foreground": &t.DestructiveForeground,
+	}
+}
+
+// LoadTheme returns the theme in the file, which picks a built-in theme
+// and optionally changes its colors, e.g.
+//
+//	base: light
+//	colors:
+//	  primary: "#005f87"
+//	  background: none
+//	  border: {truecolor: "#aaaaaa", ansi256: "248", ansi: "7"}
+//
+// A missing file isn't an error, the default theme is used instead.
+func LoadTheme(path string) (helpers.Theme, error) {
+	var file themeFile
+	if err := loadYAML(path, &file); err != nil {
+		return helpers.ThemeDracula, err
+	}
+
+	theme := helpers.ThemeDracula
+	if file.Base != "" {
+		var ok bool
+		theme, ok = builtinTheme(file.Base)
+		if !ok {
+			return helpers.ThemeDracula, fmt.Errorf("unknown theme %q in %s", file.Base, path)
+		}
+	}
+
+	if len(file.Colors) == 0 && file.Name == "" {
+		return theme, nil
+	}
+
+	theme.Name = file.Name
+	if theme.Name == "" {
+		theme.Name = "custom"
+	}
+
+	named := themeColors(&theme)
+	for name, c := range file.Colors {
+		color, ok := named[name]
+		if !ok {
+			return helpers.ThemeDracula, fmt.Errorf("unknown color %q in %s", name, path)
+		}
+
+		*color = c.color
+	}
+
+	return theme, nil
+}
+
+// SetTheme changes the colors of the UI, unless NO_COLOR is set in which
+// case nothing is colored.
+func SetTheme(t helpers.Theme) {
+	if termenv.EnvNoColor() {
+		// Without colors lipgloss leaves out reverse and bold too, which
+		// are needed to see what's selected
+		lipgloss.SetColorProfile(termenv.ANSI)
+		t = helpers.ThemeNoColor
+	}
+
+	helpers.SetTheme(t)
+}
+
+func builtinTheme(name string) (helpers.Theme, bool) {
+	for _, t := range helpers.Themes {
+		if t.Name == name {
+			return t, true
+		}
+	}
+
+	return helpers.Theme{}, false
+}
@@ -0,0 +1,70 @@
+package ui_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/ui"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTheme(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "theme.yaml")
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0o644))
+
+		return path
+	}
+
+	t.Run("uses the default theme without a file", func(t *testing.T) {
+		theme, err := ui.LoadTheme(filepath.Join(t.TempDir(), "theme.yaml"))
+
+		assert.Nil(t, err)
+		assert.Equal(t, "dracula", theme.Name)
+	})
+
+	t.Run("picks a built-in theme", func(t *testing.T) {
+		theme, err := ui.LoadTheme(write(t, "base: light\n"))
+
+		assert.Nil(t, err)
+		assert.Equal(t, helpers.ThemeLight, theme)
+	})
+
+	t.Run("changes colors of the base theme", func(t *testing.T) {
+		theme, err := ui.LoadTheme(write(t, `
+base: mono
+colors:
+  primary: "#005f87"
+  secondary: "13"
+  background: none
+  border: {truecolor: "#aaaaaa", ansi256: "248", ansi: "7"}
+`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, "custom", theme.Name)
+		assert.Equal(t, lipgloss.Color("#005f87"), theme.Primary)
+		assert.Equal(t, lipgloss.Color("13"), theme.Secondary)
+		assert.Equal(t, lipgloss.NoColor{}, theme.Background)
+		assert.Equal(t, lipgloss.CompleteColor{TrueColor: "#aaaaaa", ANSI256: "248", ANSI: "7"}, theme.Border)
+		assert.Equal(t, helpers.ThemeMono.Accent, theme.Accent)
+	})
+
+	t.Run("fails on unknown themes and colors", func(t *testing.T) {
+		_, err := ui.LoadTheme(write(t, "base: solarized\n"))
+		assert.ErrorContains(t, err, "solarized")
+
+		_, err = ui.LoadTheme(write(t, "colors: {tertiary: \"#ffffff\"}\n"))
+		assert.ErrorContains(t, err, "tertiary")
+	})
+
+	t.Run("fails on invalid colors", func(t *testing.T) {
+		_, err := ui.LoadTheme(write(t, "colors: {primary: purple}\n"))
+		assert.ErrorContains(t, err, "purple")
+
+		_, err = ui.LoadTheme(write(t, "colors: {primary: {truecolor: \"#ffffff\", ansi256: \"256\", ansi: \"7\"}}\n"))
+		assert.ErrorContains(t, err, "256")
+	})
+}
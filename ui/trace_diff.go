@@ -0,0 +1,276 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/db"
+	"github.com/fredrikaugust/otelly/ui/flamegraph"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+	"go.uber.org/zap"
+)
+
+// highlightedRegressions is how many of the largest regressions are listed
+// and highlighted in the diff.
+const highlightedRegressions = 3
+
+// TraceDiffModel compares two traces span by span, to find out why one
+// request took longer than another.
+type TraceDiffModel struct {
+	width  int
+	height int
+
+	baseTraceID   string
+	targetTraceID string
+
+	diff        flamegraph.Diff
+	rows        []traceDiffRow
+	regressions []*flamegraph.DiffNode
+
+	cursor  int
+	yOffset int
+
+	db *db.Database
+}
+
+type traceDiffRow struct {
+	depth int
+	node  *flamegraph.DiffNode
+}
+
+func NewTraceDiffModel(db *db.Database, baseTraceID, targetTraceID string) TraceDiffModel {
+	return TraceDiffModel{
+		baseTraceID:   baseTraceID,
+		targetTraceID: targetTraceID,
+		db:            db,
+	}
+}
+
+func (m TraceDiffModel) Init() tea.Cmd {
+	return m.loadDiff()
+}
+
+func (m TraceDiffModel) Update(msg tea.Msg) (TraceDiffModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case MsgNewSpans:
+		return m, m.loadDiff()
+	case MsgTraceDiffUpdated:
+		if msg.baseTraceID != m.baseTraceID || msg.targetTraceID != m.targetTraceID {
+			return m, nil
+		}
+
+		m.diff = msg.diff
+		m.regressions = m.diff.Regressions(highlightedRegressions)
+		m.rows = make([]traceDiffRow, 0)
+		for depth, n := range m.diff.All() {
+			m.rows = append(m.rows, traceDiffRow{depth, n})
+		}
+		m.clampCursor()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Down):
+			m.cursor += 1
+		case key.Matches(msg, keys.Up):
+			m.cursor -= 1
+		case key.Matches(msg, keys.PageDown):
+			m.cursor += max(1, m.rowsHeight())
+		case key.Matches(msg, keys.PageUp):
+			m.cursor -= max(1, m.rowsHeight())
+		case key.Matches(msg, keys.Top):
+			m.cursor = 0
+		case key.Matches(msg, keys.Bottom):
+			m.cursor = len(m.rows) - 1
+		}
+		m.clampCursor()
+	}
+
+	return m, nil
+}
+
+func (m *TraceDiffModel) clampCursor() {
+	m.cursor = max(0, helpers.Clamp(0, m.cursor, len(m.rows)-1))
+
+	height := m.rowsHeight()
+	if m.cursor >= m.yOffset+height {
+		m.yOffset = m.cursor - height + 1
+	} else if m.cursor < m.yOffset {
+		m.yOffset = m.cursor
+	}
+}
+
+func (m TraceDiffModel) loadDiff() tea.Cmd {
+	baseTraceID, targetTraceID := m.baseTraceID, m.targetTraceID
+
+	return func() tea.Msg {
+		forests := make([]flamegraph.Forest, 2)
+		for i, traceID := range []string{baseTraceID, targetTraceID} {
+			spans, err := m.db.GetSpansForTrace(context.Background(), traceID)
+			if err != nil {
+				zap.L().Warn("could not get spans for trace", zap.String("traceID", traceID), zap.Error(err))
+				return nil
+			}
+			forests[i], err = flamegraph.Build(spans, spanNodeInput)
+			if err != nil {
+				zap.L().Warn("could not create flamegraph for trace", zap.String("traceID", traceID), zap.Error(err))
+			}
+		}
+
+		return MsgTraceDiffUpdated{
+			baseTraceID:   baseTraceID,
+			targetTraceID: targetTraceID,
+			diff:          flamegraph.DiffForests(forests[0], forests[1]),
+		}
+	}
+}
+
+// rowsHeight is the number of diff rows which fit below the header and the
+// list of regressions.
+func (m TraceDiffModel) rowsHeight() int {
+	return max(1, m.height-lipgloss.Height(m.headerView())-1)
+}
+
+func (m TraceDiffModel) ShortHelp() []key.Binding {
+	return []key.Binding{keys.Up, keys.Down}
+}
+
+func (m TraceDiffModel) FullHelp() [][]key.Binding {
+	return [][]key.Binding{navigationBindings()}
+}
+
+func (m TraceDiffModel) View() string {
+	container := lipgloss.NewStyle().Width(m.width).MaxWidth(m.width).Height(m.height).MaxHeight(m.height)
+
+	return container.Render(
+		helpers.VStack(
+			m.headerView(),
+			m.columnsView(),
+			m.rowsView(),
+		),
+	)
+}
+
+func (m TraceDiffModel) headerView() string {
+	muted := lipgloss.NewStyle().Faint(true)
+
+	lines := []string{
+		helpers.HStack(muted.Render("base   "), m.baseTraceID),
+		helpers.HStack(muted.Render("target "), m.targetTraceID),
+		"", // spacer
+	}
+
+	if len(m.regressions) > 0 {
+		lines = append(lines, lipgloss.NewStyle().Bold(true).Render("Largest regressions"))
+		for _, r := range m.regressions {
+			lines = append(lines, fmt.Sprintf("  %s %s", deltaStyle(r.Delta(), true).Render(formatDelta(r.Delta())), r.Name))
+		}
+		lines = append(lines, "") // spacer
+	}
+
+	return helpers.VStack(lines...)
+}
+
+const traceDiffDurationWidth = 12
+
+func (m TraceDiffModel) columnsView() string {
+	nameWidth := max(1, m.width-3*traceDiffDurationWidth)
+	header := lipgloss.NewStyle().Bold(true)
+
+	return helpers.HStack(
+		header.Width(nameWidth).Render("Span"),
+		header.Width(traceDiffDurationWidth).Align(lipgloss.Right).Render("Base"),
+		header.Width(traceDiffDurationWidth).Align(lipgloss.Right).Render("Target"),
+		header.Width(traceDiffDurationWidth).Align(lipgloss.Right).Render("Delta"),
+	)
+}
+
+func (m TraceDiffModel) rowsView() string {
+	if len(m.rows) == 0 {
+		return lipgloss.NewStyle().Faint(true).Render("Loading traces…")
+	}
+
+	nameWidth := max(1, m.width-3*traceDiffDurationWidth)
+	duration := lipgloss.NewStyle().Width(traceDiffDurationWidth).Align(lipgloss.Right)
+
+	end := min(len(m.rows), m.yOffset+m.rowsHeight())
+	rows := make([]string, 0, end-m.yOffset)
+	for i := m.yOffset; i < end; i++ {
+		row := m.rows[i]
+		n := row.node
+
+		marker := "  "
+		nameStyle := lipgloss.NewStyle()
+		base, target := formatDiffDuration(n.Base), formatDiffDuration(n.Target)
+		switch n.Status {
+		case flamegraph.DiffAdded:
+			marker = "+ "
+			base = "-"
+		case flamegraph.DiffMissing:
+			marker = "- "
+			target = "-"
+			nameStyle = nameStyle.Foreground(helpers.ColorMutedForeground)
+		}
+
+		highlighted := false
+		for _, r := range m.regressions {
+			highlighted = highlighted || r == n
+		}
+
+		cursorStyle := lipgloss.NewStyle()
+		if i == m.cursor {
+			cursorStyle = helpers.Highlight(cursorStyle, helpers.ColorPrimary, helpers.ColorPrimaryForeground)
+			nameStyle = nameStyle.Inherit(cursorStyle)
+		}
+
+		rows = append(
+			rows,
+			helpers.HStack(
+				nameStyle.Width(nameWidth).MaxWidth(nameWidth).Render(strings.Repeat("  ", row.depth)+marker+n.Name),
+				duration.Inherit(cursorStyle).Render(base),
+				duration.Inherit(cursorStyle).Render(target),
+				duration.Inherit(deltaStyle(n.Delta(), highlighted)).Inherit(cursorStyle).Render(formatDelta(n.Delta())),
+			),
+		)
+	}
+
+	return helpers.VStack(rows...)
+}
+
+func formatDiffDuration(d time.Duration) string {
+	return d.Round(time.Microsecond).String()
+}
+
+func formatDelta(d time.Duration) string {
+	if d > 0 {
+		return "+" + formatDiffDuration(d)
+	}
+
+	return formatDiffDuration(d)
+}
+
+// deltaStyle colors regressions red and improvements green.
+func deltaStyle(d time.Duration, highlighted bool) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	switch {
+	case d > 0:
+		style = style.Foreground(helpers.ColorDestructive)
+	case d < 0:
+		style = style.Foreground(helpers.ColorAccent)
+	default:
+		style = style.Faint(true)
+	}
+
+	return style.Bold(highlighted)
+}
+
+func (m *TraceDiffModel) SetWidth(w int) {
+	m.width = w
+}
+
+func (m *TraceDiffModel) SetHeight(h int) {
+	m.height = h
+}
@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fredrikaugust/otelly/ui/helpers"
+)
+
+// TracePromptModel asks for the ID of a trace to open. It's filled in with
+// the clipboard if that holds a trace ID, as it usually comes from a log
+// line or response header someone copied.
+type TracePromptModel struct {
+	width int
+
+	input textinput.Model
+	// err is why the input isn't a trace ID, shown after trying to open it
+	err error
+}
+
+func NewTracePromptModel() TracePromptModel {
+	input := textinput.New()
+	input.Prompt = "Trace ID: "
+	input.Placeholder = "trace ID or traceparent"
+	// A blinking cursor would need its ticks routed to the input
+	input.Cursor.SetMode(cursor.CursorStatic)
+	input.Focus()
+
+	return TracePromptModel{input: input}
+}
+
+func (m TracePromptModel) Init() tea.Cmd {
+	return func() tea.Msg {
+		text, err := clipboard.ReadAll()
+		if err != nil {
+			// There's no clipboard to read in e.g. a container, and the ID
+			// can still be typed or pasted
+			return nil
+		}
+
+		return MsgClipboardRead{text}
+	}
+}
+
+func (m TracePromptModel) Update(msg tea.Msg) (TracePromptModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case MsgClipboardRead:
+		if _, err := helpers.ParseTraceID(msg.text); err == nil && m.input.Value() == "" {
+			m.input.SetValue(msg.text)
+			m.input.CursorEnd()
+		}
+	case tea.KeyMsg:
+		m.input, cmd = m.input.Update(msg)
+		m.err = nil
+	}
+
+	return m, cmd
+}
+
+// TraceID parses the input, remembering the error to show it.
+func (m *TracePromptModel) TraceID() (string, error) {
+	traceID, err := helpers.ParseTraceID(m.input.Value())
+	m.err = err
+
+	return traceID, err
+}
+
+func (m TracePromptModel) View() string {
+	hint := lipgloss.NewStyle().Faint(true).Render("e.g. 4bf92f3577b34da6a3ce929d0e0e4736 or 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if m.err != nil {
+		hint = lipgloss.NewStyle().Foreground(helpers.ColorDestructive).Render(m.err.Error())
+	}
+
+	return helpers.VStack(
+		m.input.View(),
+		"", // spacer
+		lipgloss.NewStyle().Width(m.width).MaxWidth(m.width).Render(hint),
+	)
+}
+
+func (m TracePromptModel) ShortHelp() []key.Binding {
+	return []key.Binding{
+		withHelp(keys.Select, "open"),
+		withHelp(keys.Back, "close"),
+	}
+}
+
+func (m *TracePromptModel) SetWidth(w int) {
+	m.width = w
+	m.input.Width = w - lipgloss.Width(m.input.Prompt) - 1
+}